@@ -7,6 +7,11 @@ import (
 	"OmniLink/internal/config"
 	"OmniLink/internal/initial"
 	jwtMiddleware "OmniLink/internal/middleware/jwt"
+	"OmniLink/internal/middleware/rbac"
+	"OmniLink/internal/middleware/throttle"
+	adminService "OmniLink/internal/modules/admin/application/service"
+	adminPersistence "OmniLink/internal/modules/admin/infrastructure/persistence"
+	adminHandler "OmniLink/internal/modules/admin/interface/http"
 	aiService "OmniLink/internal/modules/ai/application/service"
 	aiRepository "OmniLink/internal/modules/ai/domain/repository"
 	aiChunking "OmniLink/internal/modules/ai/infrastructure/chunking"
@@ -31,17 +36,23 @@ import (
 	omniMcpServer "OmniLink/internal/modules/ai/infrastructure/mcp/server"
 
 	chatService "OmniLink/internal/modules/chat/application/service"
+	chatRepository "OmniLink/internal/modules/chat/domain/repository"
 	chatPersistence "OmniLink/internal/modules/chat/infrastructure/persistence"
+	chatSearch "OmniLink/internal/modules/chat/infrastructure/search"
 	chatHandler "OmniLink/internal/modules/chat/interface/http"
 	contactService "OmniLink/internal/modules/contact/application/service"
 	contactPersistence "OmniLink/internal/modules/contact/infrastructure/persistence"
 	contactHandler "OmniLink/internal/modules/contact/interface/http"
 	"OmniLink/internal/modules/user/application/service"
+	userEntity "OmniLink/internal/modules/user/domain/entity"
 	"OmniLink/internal/modules/user/infrastructure/persistence"
 	userHandler "OmniLink/internal/modules/user/interface/http"
+	"OmniLink/pkg/redis"
+	"OmniLink/pkg/storage"
 	"OmniLink/pkg/ws"
 	"OmniLink/pkg/zlog"
 	"fmt"
+	"os"
 	"strings"
 
 	cors "github.com/gin-contrib/cors"
@@ -52,6 +63,10 @@ var GE *gin.Engine
 
 func init() {
 	GE = gin.Default()
+	// 限流和登录风控依赖 ClientIP，只信任配置的代理转发的 X-Forwarded-For
+	if err := GE.SetTrustedProxies(trustedProxies()); err != nil {
+		zlog.Fatal(err.Error())
+	}
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"*"}
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
@@ -59,15 +74,41 @@ func init() {
 	GE.Use(cors.New(corsConfig))
 	// GE.Use(ssl.TlsHandler(config.GetConfig().MainConfig.Host, config.GetConfig().MainConfig.Port))
 	wsHub := ws.NewHub()
+	presenceStore := ws.NewMemoryPresenceStore()
+	if redis.IsConnected() {
+		// 待确认队列放到 Redis，重连到任意节点都能补发
+		wsHub.SetPendingStore(ws.NewRedisPendingStore())
+		presenceStore = ws.NewRedisPresenceStore()
+	}
+	if conf := config.GetConfig(); conf.WsConfig.Distributed {
+		nodeID := conf.WsConfig.NodeId
+		if nodeID == "" {
+			host, _ := os.Hostname()
+			nodeID = fmt.Sprintf("%s-%d", host, conf.MainConfig.Port)
+		}
+		if err := wsHub.EnableCluster(nodeID); err != nil {
+			// 分布式模式启动失败时退化为单机模式，不影响本节点服务
+			zlog.Error("ws cluster enable failed: " + err.Error())
+		}
+	}
 	userRepo := persistence.NewUserInfoRepository(initial.GormDB)
+	deviceRepo := persistence.NewUserDeviceRepository(initial.GormDB)
+	refreshTokenRepo := persistence.NewRefreshTokenRepository(initial.GormDB)
+	auditLogRepo := adminPersistence.NewAdminAuditLogRepository(initial.GormDB)
 	contactRepo := contactPersistence.NewUserContactRepository(initial.GormDB)
 	applyRepo := contactPersistence.NewContactApplyRepository(initial.GormDB)
 	groupRepo := contactPersistence.NewGroupInfoRepository(initial.GormDB)
 	uow := contactPersistence.NewContactUnitOfWork(initial.GormDB)
 	sessionRepo := chatPersistence.NewSessionRepository(initial.GormDB)
 	messageRepo := chatPersistence.NewMessageRepository(initial.GormDB)
+	go backfillMessageSeq(messageRepo)
 	mentionRepo := chatPersistence.NewMessageMentionRepository(initial.GormDB)
+	reactionRepo := chatPersistence.NewMessageReactionRepository(initial.GormDB)
+	readCursorRepo := chatPersistence.NewSessionReadCursorRepository(initial.GormDB)
+	fileRepo := chatPersistence.NewFileRepository(initial.GormDB)
 	conf := config.GetConfig()
+	fileStore, localStore := newFileStorage(conf)
+	msgSearcher := newMessageSearcher(conf, messageRepo)
 	var aiAdminH *aiHTTP.AdminHandler
 	var aiQueryH *aiHTTP.QueryHandler
 	var aiAssistantH *aiHTTP.AssistantHandler
@@ -218,12 +259,17 @@ func init() {
 	} else {
 		zlog.Warn("ai milvus client is nil; ai routes disabled")
 	}
-	userSvc := service.NewUserInfoService(userRepo, userLifecycleSvc, aiJobSvc)
-	contactSvc := contactService.NewContactService(contactRepo, applyRepo, userRepo, uow, aiAsyncIngest)
-	groupSvc := contactService.NewGroupService(contactRepo, groupRepo, userRepo, uow, aiAsyncIngest)
-	sessionSvc := chatService.NewSessionService(sessionRepo, contactRepo, userRepo, groupRepo)
-	messageSvc := chatService.NewMessageService(messageRepo, contactRepo, mentionRepo)
-	realtimeSvc := chatService.NewRealtimeService(messageRepo, sessionRepo, contactRepo, userRepo, groupRepo, mentionRepo, aiAsyncIngest)
+	deviceSvc := service.NewDeviceService(deviceRepo, refreshTokenRepo, userRepo, wsHub)
+	userSvc := service.NewUserInfoService(userRepo, deviceSvc, userLifecycleSvc, aiJobSvc)
+	adminSvc := adminService.NewAdminService(userRepo, groupRepo, deviceSvc)
+	auditSvc := adminService.NewAuditService(auditLogRepo)
+	contactSvc := contactService.NewContactService(contactRepo, applyRepo, userRepo, uow, aiAsyncIngest, presenceStore)
+	sessionSvc := chatService.NewSessionService(sessionRepo, contactRepo, userRepo, groupRepo, messageRepo, mentionRepo, readCursorRepo)
+	uploadSvc := chatService.NewUploadService(fileRepo, messageRepo, contactRepo, fileStore)
+	messageSvc := chatService.NewMessageService(messageRepo, contactRepo, mentionRepo, reactionRepo, uploadSvc)
+	realtimeSvc := chatService.NewRealtimeService(messageRepo, sessionRepo, contactRepo, userRepo, groupRepo, mentionRepo, readCursorRepo, reactionRepo, msgSearcher, uploadSvc, aiAsyncIngest)
+	groupSvc := contactService.NewGroupService(contactRepo, groupRepo, userRepo, uow, aiAsyncIngest, wsHub, chatService.NewGroupSystemMessenger(realtimeSvc, wsHub))
+	searchSvc := chatService.NewMessageSearchService(msgSearcher, contactRepo)
 
 	// MCP Initialization
 	if conf.MCPConfig.Enabled {
@@ -341,24 +387,40 @@ func init() {
 	}
 
 	userH := userHandler.NewUserInfoHandler(userSvc)
+	deviceH := userHandler.NewDeviceHandler(deviceSvc)
+	authH := userHandler.NewAuthHandler(deviceSvc)
+	adminH := adminHandler.NewAdminHandler(adminSvc, auditSvc)
 	contactH := contactHandler.NewContactHandler(contactSvc, wsHub)
 	groupH := contactHandler.NewGroupHandler(groupSvc)
 	sessionH := chatHandler.NewSessionHandler(sessionSvc)
-	messageH := chatHandler.NewMessageHandler(messageSvc)
-	wsH := chatHandler.NewWsHandler(wsHub, realtimeSvc, userRepo)
-	GE.POST("/login", userH.Login)
-	GE.POST("/register", userH.Register)
-	GE.GET("/wss", wsH.Connect)
+	messageH := chatHandler.NewMessageHandler(messageSvc, realtimeSvc, searchSvc, wsHub)
+	uploadH := chatHandler.NewUploadHandler(uploadSvc, localStore)
+	callSvc := chatService.NewCallService(contactRepo, realtimeSvc, wsHub)
+	presenceSvc := chatService.NewPresenceService(presenceStore, contactRepo, userRepo, wsHub)
+	presenceH := chatHandler.NewPresenceHandler(presenceSvc)
+	wsH := chatHandler.NewWsHandler(wsHub, realtimeSvc, messageSvc, callSvc, presenceSvc, deviceSvc, userRepo)
+	limit := throttle.Route()
+	GE.POST("/login", limit, userH.Login)
+	GE.POST("/register", limit, userH.Register)
+	GE.POST("/auth/refresh", limit, authH.Refresh)
+	GE.GET("/wss", limit, wsH.Connect)
+	GE.GET("/file/download", uploadH.Download)
+	GE.GET("/file/avatar/:file_id", uploadH.Avatar)
 	authed := GE.Group("/")
-	authed.Use(jwtMiddleware.Auth())
+	authed.Use(jwtMiddleware.Auth(), limit)
 	authed.GET("/auth/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"uuid":     c.GetString("uuid"),
 			"username": c.GetString("username"),
 		})
 	})
+	authed.POST("/auth/logout", authH.Logout)
+	// admin 权限校验 + 审计，管理接口与 AI 内部接口共用
+	admin := func(perm userEntity.Permission, action string) []gin.HandlerFunc {
+		return []gin.HandlerFunc{rbac.Require(userSvc, perm), rbac.Audit(auditSvc, action)}
+	}
 	if aiAdminH != nil {
-		authed.POST("/ai/internal/rag/backfill", aiAdminH.Backfill)
+		authed.POST("/ai/internal/rag/backfill", append(admin(userEntity.PermAIInternal, "ai.rag.backfill"), aiAdminH.Backfill)...)
 	}
 	if aiQueryH != nil {
 		authed.POST("/ai/rag/query", aiQueryH.Query)
@@ -378,7 +440,11 @@ func init() {
 			aiGroup.POST("/sessions", aiAssistantH.CreateSession)
 		}
 	}
-	authed.POST("/user/internal/getUserInfo", userH.GetUserInfoInternal)
+	authed.POST("/user/internal/getUserInfo", append(admin(userEntity.PermUserRead, "user.getInfo"), userH.GetUserInfoInternal)...)
+	authed.POST("/user/changePassword", userH.ChangePassword)
+	authed.POST("/user/getDeviceList", deviceH.GetDeviceList)
+	authed.POST("/user/logoutDevice", deviceH.LogoutDevice)
+	authed.POST("/user/kickDevice", deviceH.KickDevice)
 	authed.POST("/contact/getUserList", contactH.GetUserList)
 	authed.POST("/contact/loadMyJoinedGroup", contactH.LoadMyJoinedGroup)
 	authed.POST("/contact/getContactInfo", contactH.GetContactInfo)
@@ -386,18 +452,69 @@ func init() {
 	authed.POST("/contact/getNewContactList", contactH.GetNewContactList)
 	authed.POST("/contact/passContactApply", contactH.PassContactApply)
 	authed.POST("/contact/refuseContactApply", contactH.RefuseContactApply)
+	authed.POST("/contact/deleteContact", contactH.DeleteContact)
+	authed.POST("/contact/blackContact", contactH.BlackContact)
+	authed.POST("/contact/cancelBlackContact", contactH.CancelBlackContact)
 	authed.POST("/session/checkOpenSessionAllowed", sessionH.CheckOpenSessionAllowed)
 	authed.POST("/session/openSession", sessionH.OpenSession)
 	authed.POST("/session/getUserSessionList", sessionH.GetUserSessionList)
 	authed.POST("/session/getGroupSessionList", sessionH.GetGroupSessionList)
 	authed.POST("/message/getMessageList", messageH.GetMessageList)
 	authed.POST("/message/getGroupMessageList", messageH.GetGroupMessageList)
+	authed.POST("/message/sync", messageH.SyncMessages)
+	authed.GET("/ws/deliveryStats", wsH.DeliveryStats)
+	authed.POST("/ws/ticket", wsH.IssueTicket)
+	authed.POST("/presence/getOnlineStatus", presenceH.GetOnlineStatus)
+	authed.POST("/message/recall", messageH.RecallMessage)
+	authed.POST("/message/edit", messageH.EditMessage)
+	authed.POST("/message/getEditHistory", messageH.GetEditHistory)
+	authed.POST("/message/getThreadMessages", messageH.GetThreadMessages)
+	authed.POST("/message/getThreadCount", messageH.GetThreadCount)
+	authed.POST("/message/addReaction", messageH.AddReaction)
+	authed.POST("/message/removeReaction", messageH.RemoveReaction)
+	authed.POST("/message/forward", messageH.ForwardMessages)
+	authed.POST("/message/getForwardRecord", messageH.GetForwardRecord)
+	authed.POST("/message/search", messageH.SearchMessages)
+	authed.POST("/message/markRead", messageH.MarkRead)
+	authed.POST("/message/uploadFile", uploadH.UploadFile)
+	authed.POST("/message/uploadAvatar", uploadH.UploadAvatar)
+	authed.POST("/file/initUpload", uploadH.InitUpload)
+	authed.POST("/file/uploadChunk", uploadH.UploadChunk)
+	authed.POST("/file/uploadStatus", uploadH.GetUploadStatus)
+	authed.POST("/file/completeUpload", uploadH.CompleteUpload)
+	authed.POST("/file/getFileUrl", uploadH.GetFileUrl)
 	authed.POST("/group/createGroup", groupH.CreateGroup)
 	authed.POST("/group/getGroupInfo", groupH.GetGroupInfo)
 	authed.POST("/group/getGroupMemberList", groupH.GetGroupMemberList)
 	authed.POST("/group/inviteGroupMembers", groupH.InviteGroupMembers)
 	authed.POST("/group/leaveGroup", groupH.LeaveGroup)
 	authed.POST("/group/dismissGroup", groupH.DismissGroup)
+	authed.POST("/group/applyJoinGroup", groupH.ApplyJoinGroup)
+	authed.POST("/group/getGroupApplyList", groupH.GetGroupApplyList)
+	authed.POST("/group/passGroupApply", groupH.PassGroupApply)
+	authed.POST("/group/refuseGroupApply", groupH.RefuseGroupApply)
+	authed.POST("/group/batchPassGroupApply", groupH.BatchPassGroupApply)
+	authed.POST("/group/transferGroupOwner", groupH.TransferGroupOwner)
+	authed.POST("/group/setGroupAdmin", groupH.SetGroupAdmin)
+	authed.POST("/group/removeGroupMembers", groupH.RemoveGroupMembers)
+	authed.POST("/group/muteGroupMember", groupH.MuteGroupMember)
+	authed.POST("/group/muteGroup", groupH.MuteGroup)
+	authed.POST("/group/updateGroupInfo", groupH.UpdateGroupInfo)
+	authed.POST("/group/getGroupInfoHistory", groupH.GetGroupInfoHistory)
+	authed.POST("/group/createGroupInvite", groupH.CreateGroupInvite)
+	authed.POST("/group/getGroupInviteList", groupH.GetGroupInviteList)
+	authed.POST("/group/revokeGroupInvite", groupH.RevokeGroupInvite)
+	authed.POST("/group/getGroupInviteInfo", groupH.GetGroupInviteInfo)
+	authed.POST("/group/redeemGroupInvite", groupH.RedeemGroupInvite)
+	adminGroup := authed.Group("/admin")
+	adminGroup.POST("/user/getUserList", append(admin(userEntity.PermUserRead, "user.list"), adminH.GetUserList)...)
+	adminGroup.POST("/user/disableUsers", append(admin(userEntity.PermUserWrite, "user.disable"), adminH.DisableUsers)...)
+	adminGroup.POST("/user/ableUsers", append(admin(userEntity.PermUserWrite, "user.able"), adminH.AbleUsers)...)
+	adminGroup.POST("/user/deleteUsers", append(admin(userEntity.PermUserWrite, "user.delete"), adminH.DeleteUsers)...)
+	adminGroup.POST("/user/setAdmin", append(admin(userEntity.PermUserWrite, "user.setAdmin"), adminH.SetAdmin)...)
+	adminGroup.POST("/group/getGroupList", append(admin(userEntity.PermGroupRead, "group.list"), adminH.GetGroupList)...)
+	adminGroup.POST("/group/setGroupsStatus", append(admin(userEntity.PermGroupWrite, "group.setStatus"), adminH.SetGroupsStatus)...)
+	adminGroup.POST("/audit/getAuditLogList", append(admin(userEntity.PermAuditRead, "audit.list"), adminH.GetAuditLogList)...)
 	//GE.POST("/user/updateUserInfo", v1.UpdateUserInfo)
 	// GE.POST("/user/getUserInfoList", v1.GetUserInfoList)
 	// GE.POST("/user/ableUsers", v1.AbleUsers)
@@ -446,3 +563,80 @@ func init() {
 	// GE.GET("/wss", v1.WsLogin)
 
 }
+
+// newFileStorage 按配置创建文件存储；S3 初始化失败时退化为本地存储，保证上传可用
+func newFileStorage(conf *config.Config) (storage.Storage, *storage.LocalStorage) {
+	uc := conf.UploadConfig
+	if strings.EqualFold(uc.Storage, "s3") {
+		s3, err := storage.NewS3Storage(context.Background(), storage.S3Config{
+			Endpoint:  uc.S3Endpoint,
+			AccessKey: uc.S3AccessKey,
+			SecretKey: uc.S3SecretKey,
+			Bucket:    uc.S3Bucket,
+			Region:    uc.S3Region,
+			UseSSL:    uc.S3UseSSL,
+		})
+		if err == nil {
+			return s3, nil
+		}
+		zlog.Error("s3 storage init failed: " + err.Error() + "; fallback to local")
+	}
+
+	dir := uc.LocalDir
+	if dir == "" {
+		dir = "./data/uploads"
+	}
+	secret := uc.SignSecret
+	if secret == "" {
+		secret = conf.JwtConfig.Key
+	}
+	baseURL := strings.TrimRight(uc.PublicBaseUrl, "/")
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("http://%s:%d", conf.MainConfig.Host, conf.MainConfig.Port)
+	}
+	local, err := storage.NewLocalStorage(dir, baseURL+"/file/download", secret)
+	if err != nil {
+		zlog.Fatal("local storage init failed: " + err.Error())
+	}
+	return local, local
+}
+
+// newMessageSearcher 按配置选择消息检索实现；MySQL 全文索引创建失败时退回进程内倒排索引
+func newMessageSearcher(conf *config.Config, messageRepo chatRepository.MessageRepository) chatRepository.MessageSearcher {
+	if !strings.EqualFold(conf.ChatConfig.SearchEngine, "memory") {
+		searcher, err := chatSearch.NewMySQLMessageSearcher(initial.GormDB)
+		if err == nil {
+			return searcher
+		}
+		zlog.Error("mysql fulltext search init failed: " + err.Error() + "; fallback to memory index")
+	}
+	return chatSearch.NewMemoryMessageSearcher(messageRepo)
+}
+
+// trustedProxies 返回配置的可信代理列表，未配置时返回 nil（不信任任何代理）
+func trustedProxies() []string {
+	proxies := config.GetConfig().MainConfig.TrustedProxies
+	if len(proxies) == 0 {
+		return nil
+	}
+	return proxies
+}
+
+// backfillMessageSeq 后台为老消息补齐会话序列号，补齐之前这些消息不会出现在增量同步中
+func backfillMessageSeq(messageRepo chatRepository.MessageRepository) {
+	total := 0
+	for {
+		n, err := messageRepo.BackfillSeq(500)
+		if err != nil {
+			zlog.Error("message seq backfill failed: " + err.Error())
+			return
+		}
+		total += n
+		if n < 500 {
+			break
+		}
+	}
+	if total > 0 {
+		zlog.Info(fmt.Sprintf("message seq backfilled: %d", total))
+	}
+}
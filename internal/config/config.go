@@ -8,9 +8,10 @@ import (
 )
 
 type MainConfig struct {
-	AppName string `toml:"appName"`
-	Host    string `toml:"host"`
-	Port    int    `toml:"port"`
+	AppName        string   `toml:"appName"`
+	Host           string   `toml:"host"`
+	Port           int      `toml:"port"`
+	TrustedProxies []string `toml:"trustedProxies"` // 可信反向代理地址/网段，仅信任其 X-Forwarded-For；为空时不信任任何代理，ClientIP 取直连地址
 }
 
 type MysqlConfig struct {
@@ -26,9 +27,20 @@ type LogConfig struct {
 }
 
 type JwtConfig struct {
-	Key         string `toml:"key"`
-	ExpireHours int    `toml:"expireHours"`
-	Issuer      string `toml:"issuer"`
+	Key                 string `toml:"key"`
+	ExpireHours         int    `toml:"expireHours"`         // 未配置 accessExpireMinutes 时访问令牌的有效期（小时）
+	AccessExpireMinutes int    `toml:"accessExpireMinutes"` // 访问令牌有效期（分钟），过期后用刷新令牌换取
+	RefreshExpireHours  int    `toml:"refreshExpireHours"`  // 刷新令牌有效期（小时），默认720
+	Issuer              string `toml:"issuer"`
+}
+
+// AuthConfig 登录与会话配置
+type AuthConfig struct {
+	SingleSessionPerPlatform bool `toml:"singleSessionPerPlatform"` // 同一平台只保留一个登录设备，新设备登录时顶替旧设备
+	PasswordMinLength        int  `toml:"passwordMinLength"`        // 密码最小长度，默认8
+	LoginMaxUserFailures     int  `toml:"loginMaxUserFailures"`     // 锁定窗口内单个账号允许的登录失败次数，默认5
+	LoginMaxIpFailures       int  `toml:"loginMaxIpFailures"`       // 锁定窗口内单个IP允许的登录失败次数，默认20
+	LoginLockMinutes         int  `toml:"loginLockMinutes"`         // 失败计数窗口，也是超限后的锁定时长（分钟），默认15
 }
 
 type MilvusConfig struct {
@@ -117,16 +129,79 @@ type RedisConfig struct {
 	MinIdleConns int    `toml:"minIdleConns"`
 }
 
+// WsConfig WebSocket 网关配置
+type WsConfig struct {
+	Distributed bool   `toml:"distributed"` // 是否开启多节点模式（依赖 Redis 做 presence 与 pub/sub 路由）
+	NodeId      string `toml:"nodeId"`      // 节点ID，为空时使用 主机名-端口
+
+	AllowedOrigins   []string `toml:"allowedOrigins"`   // 允许发起握手的浏览器 Origin，"*" 表示不限制；为空时仅允许同源
+	TicketTTLSeconds int      `toml:"ticketTTLSeconds"` // 一次性连接票据有效期（秒），默认30
+}
+
+// ChatConfig 即时通讯相关配置
+type ChatConfig struct {
+	RecallWindowSeconds int    `toml:"recallWindowSeconds"` // 消息可撤回时间窗口（秒），默认120
+	EditWindowSeconds   int    `toml:"editWindowSeconds"`   // 文本消息可编辑时间窗口（秒），默认86400
+	SyncBatchSize       int    `toml:"syncBatchSize"`       // 断线重连增量同步单批最大消息数，默认200
+	CallRingSeconds     int    `toml:"callRingSeconds"`     // 通话呼叫超时（秒），超时未接听视为未接通，默认60
+	CallMaxParticipants int    `toml:"callMaxParticipants"` // 群通话最大人数（mesh 拓扑，含发起人），默认9
+	SearchEngine        string `toml:"searchEngine"`        // 消息检索实现：mysql（FULLTEXT ngram 分词）/ memory（进程内倒排索引），默认 mysql
+	GroupInviteBaseUrl  string `toml:"groupInviteBaseUrl"`  // 群邀请链接前缀，如 https://omnilink.example.com/invite，为空时二维码内容使用 omnilink:// 协议
+}
+
+// RateLimitRule 令牌桶限流规则：每 WindowSeconds 秒补充 Limit 个令牌，桶容量为 Burst
+type RateLimitRule struct {
+	Limit         int `toml:"limit"`         // 窗口内允许的次数，<=0 表示不限
+	WindowSeconds int `toml:"windowSeconds"` // 窗口长度（秒），默认1
+	Burst         int `toml:"burst"`         // 突发容量，默认等于 limit
+}
+
+// RateLimitConfig 限流配置。已登录请求按用户计数，未登录请求按 IP 计数；
+// 开启 Redis 时多节点共享计数，否则按单节点计数
+type RateLimitConfig struct {
+	Enabled  bool                     `toml:"enabled"`
+	Routes   map[string]RateLimitRule `toml:"routes"`   // 路由路径 -> 规则，如 "/contact/applyContact"
+	WsFrames map[string]RateLimitRule `toml:"wsFrames"` // WS 帧类型 -> 规则，如 "send"
+	Actions  map[string]RateLimitRule `toml:"actions"`  // 跨接口共享的业务配额，如 "ai.llm"
+}
+
+// UploadConfig 文件上传与存储配置
+type UploadConfig struct {
+	Storage          string   `toml:"storage"`          // 存储类型：local / s3，默认 local
+	LocalDir         string   `toml:"localDir"`         // 本地存储根目录
+	PublicBaseUrl    string   `toml:"publicBaseUrl"`    // 本地存储下载地址前缀，如 http://127.0.0.1:8000
+	SignSecret       string   `toml:"signSecret"`       // 本地下载地址签名密钥，为空时使用 jwt key
+	UrlExpireSeconds int      `toml:"urlExpireSeconds"` // 下载地址有效期（秒），默认3600
+	ChunkSize        int64    `toml:"chunkSize"`        // 分片大小（字节），默认5MB
+	MaxFileSize      int64    `toml:"maxFileSize"`      // 普通文件大小上限（字节），默认100MB
+	MaxImageSize     int64    `toml:"maxImageSize"`     // 图片大小上限（字节），默认10MB
+	MaxVoiceSize     int64    `toml:"maxVoiceSize"`     // 语音大小上限（字节），默认10MB
+	MaxAvatarSize    int64    `toml:"maxAvatarSize"`    // 头像大小上限（字节），默认2MB
+	BlockedExts      []string `toml:"blockedExts"`      // 禁止上传的文件扩展名
+	S3Endpoint       string   `toml:"s3Endpoint"`
+	S3AccessKey      string   `toml:"s3AccessKey"`
+	S3SecretKey      string   `toml:"s3SecretKey"`
+	S3Bucket         string   `toml:"s3Bucket"`
+	S3Region         string   `toml:"s3Region"`
+	S3UseSSL         bool     `toml:"s3UseSSL"`
+}
+
 type Config struct {
 	MainConfig   `toml:"mainConfig"`
 	MysqlConfig  `toml:"mysqlConfig"`
 	JwtConfig    `toml:"jwtConfig"`
+	AuthConfig   `toml:"authConfig"`
 	MilvusConfig `toml:"milvusConfig"`
 	KafkaConfig  `toml:"kafkaConfig"`
 	AIConfig     `toml:"aiConfig"`
 	LogConfig    `toml:"logConfig"`
 	MCPConfig    `toml:"mcpConfig"`
 	RedisConfig  `toml:"redisConfig"`
+	ChatConfig   `toml:"chatConfig"`
+	WsConfig     `toml:"wsConfig"`
+	UploadConfig `toml:"uploadConfig"`
+
+	RateLimitConfig `toml:"rateLimitConfig"`
 }
 
 var config *Config
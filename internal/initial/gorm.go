@@ -2,6 +2,7 @@ package initial
 
 import (
 	"OmniLink/internal/config"
+	adminEntity "OmniLink/internal/modules/admin/domain/entity"
 	aiAgent "OmniLink/internal/modules/ai/domain/agent"
 	aiAssistant "OmniLink/internal/modules/ai/domain/assistant"
 	aiJob "OmniLink/internal/modules/ai/domain/job"
@@ -49,12 +50,25 @@ func init() {
 	}
 	err = GormDB.AutoMigrate(
 		&userEntity.UserInfo{},
+		&userEntity.UserDevice{},
+		&userEntity.RefreshToken{},
+		&adminEntity.AdminAuditLog{},
 		&contactEntity.UserContact{},
 		&contactEntity.ContactApply{},
 		&contactEntity.GroupInfo{},
+		&contactEntity.GroupProfileHistory{},
+		&contactEntity.GroupInvite{},
+		&contactEntity.GroupInviteUse{},
 		&chatEntity.Session{},
 		&chatEntity.Message{},
+		&chatEntity.MessageSeq{},
 		&chatEntity.MessageMention{},
+		&chatEntity.MessageReaction{},
+		&chatEntity.MessageEditHistory{},
+		&chatEntity.SessionReadCursor{},
+		&chatEntity.FileObject{},
+		&chatEntity.UploadSession{},
+		&chatEntity.UploadChunk{},
 
 		&aiRag.AIKnowledgeBase{},
 		&aiRag.AIKnowledgeSource{},
@@ -25,9 +25,16 @@ func Auth() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if myjwt.IsRevoked(claims.ID) {
+			back.Error(c, xerr.Unauthorized, "token revoked")
+			c.Abort()
+			return
+		}
 
 		c.Set("uuid", claims.Uuid)
 		c.Set("username", claims.Username)
+		c.Set("device_id", claims.DeviceId)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
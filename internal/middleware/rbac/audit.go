@@ -0,0 +1,99 @@
+package rbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"OmniLink/internal/modules/admin/domain/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// auditMaxBody 请求与响应最多缓存的字节数，超出部分不计入审计
+	auditMaxBody = 16 << 10
+	// auditMaxRequest 审计日志中保存的请求参数上限（字节）
+	auditMaxRequest = 4 << 10
+)
+
+// AuditRecorder 持久化审计记录
+type AuditRecorder interface {
+	Record(log *entity.AdminAuditLog)
+}
+
+// Audit 记录管理操作：操作人、请求参数与处理结果，无论成功失败都会记录。
+// 需挂在 Require 之后，未通过权限校验的请求不进入审计
+func Audit(recorder AuditRecorder, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody []byte
+		if c.Request.Body != nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, auditMaxBody))
+			// 未读完的部分拼回去，保证处理函数拿到完整的请求体
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqBody), c.Request.Body), c.Request.Body}
+		}
+
+		w := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		start := time.Now()
+
+		c.Next()
+
+		var resp struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		// 只有大体积的成功响应才会被截断而解析失败，此时退回 HTTP 状态码
+		_ = json.Unmarshal(w.body.Bytes(), &resp)
+		if resp.Code == 0 {
+			resp.Code = w.Status()
+		}
+
+		recorder.Record(&entity.AdminAuditLog{
+			OperatorId: c.GetString("uuid"),
+			Role:       c.GetString("role"),
+			Action:     action,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Ip:         c.ClientIP(),
+			Request:    strings.ToValidUTF8(string(truncateBytes(reqBody, auditMaxRequest)), ""),
+			Code:       resp.Code,
+			Message:    resp.Message,
+			DurationMs: time.Since(start).Milliseconds(),
+			CreatedAt:  start,
+		})
+	}
+}
+
+// auditWriter 在写出响应的同时缓存响应体前缀，用于解析业务返回码
+type auditWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	if rest := auditMaxBody - w.body.Len(); rest > 0 {
+		if len(b) > rest {
+			w.body.Write(b[:rest])
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func truncateBytes(b []byte, n int) []byte {
+	if len(b) <= n {
+		return b
+	}
+	return b[:n]
+}
@@ -0,0 +1,49 @@
+package rbac
+
+import (
+	userEntity "OmniLink/internal/modules/user/domain/entity"
+	"OmniLink/pkg/back"
+	"OmniLink/pkg/xerr"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleResolver 查询用户当前角色。每次请求实时查询，取消管理员或禁用账号后立即生效
+type RoleResolver interface {
+	GetRole(uuid string) (userEntity.Role, error)
+}
+
+// Require 要求当前用户的角色拥有全部指定权限，需挂在 jwt.Auth 之后
+func Require(resolver RoleResolver, perms ...userEntity.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uuid := c.GetString("uuid")
+		if uuid == "" {
+			back.Error(c, xerr.Unauthorized, "未登录")
+			c.Abort()
+			return
+		}
+
+		role, err := resolver.GetRole(uuid)
+		if err != nil {
+			var ce *xerr.CodeError
+			if errors.As(err, &ce) {
+				back.Error(c, ce.Code, ce.Message)
+			} else {
+				back.Error(c, xerr.InternalServerError, xerr.ErrServerError.Message)
+			}
+			c.Abort()
+			return
+		}
+		for _, p := range perms {
+			if !role.Can(p) {
+				back.Error(c, xerr.Forbidden, "无权限访问")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("role", string(role))
+		c.Next()
+	}
+}
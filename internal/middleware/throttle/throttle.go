@@ -0,0 +1,23 @@
+package throttle
+
+import (
+	"OmniLink/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route 按 rateLimitConfig.routes 中配置的路由规则限流。
+// 挂在 jwt.Auth 之后时按用户计数，公开接口按 IP 计数；未配置规则的路由直接放行
+func Route() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject := c.GetString("uuid")
+		if subject == "" {
+			subject = "ip:" + c.ClientIP()
+		}
+		if res := ratelimit.Check(ratelimit.ScopeRoute, c.FullPath(), subject); !res.Allowed {
+			ratelimit.Reject(c, res)
+			return
+		}
+		c.Next()
+	}
+}
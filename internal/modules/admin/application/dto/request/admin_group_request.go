@@ -0,0 +1,16 @@
+package request
+
+// AdminGroupListRequest 管理端群组列表
+type AdminGroupListRequest struct {
+	Keyword  string `json:"keyword"`  // 群名模糊匹配，或群 uuid
+	OwnerId  string `json:"owner_id"` // 群主 uuid
+	Status   *int8  `json:"status"`   // 0.正常，1.禁用，2.解散，不传表示全部
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+// SetGroupsStatusRequest 批量启用/禁用群组
+type SetGroupsStatusRequest struct {
+	GroupIds []string `json:"group_ids"`
+	Status   int8     `json:"status"` // 0.启用，1.禁用
+}
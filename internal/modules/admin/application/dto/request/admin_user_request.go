@@ -0,0 +1,21 @@
+package request
+
+// AdminUserListRequest 管理端用户列表
+type AdminUserListRequest struct {
+	Keyword  string `json:"keyword"`  // 用户名或昵称，模糊匹配
+	Status   *int8  `json:"status"`   // 0.正常，1.禁用，不传表示全部
+	IsAdmin  *int8  `json:"is_admin"` // 0.普通用户，1.管理员，不传表示全部
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+// AdminUserIdsRequest 批量启用、禁用、删除用户
+type AdminUserIdsRequest struct {
+	UserIds []string `json:"user_ids"`
+}
+
+// SetAdminRequest 设置或取消管理员
+type SetAdminRequest struct {
+	UserId  string `json:"user_id"`
+	IsAdmin int8   `json:"is_admin"` // 0.取消，1.设置
+}
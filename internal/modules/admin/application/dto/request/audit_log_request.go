@@ -0,0 +1,12 @@
+package request
+
+// AuditLogListRequest 管理操作审计日志查询
+type AuditLogListRequest struct {
+	OperatorId string `json:"operator_id"`
+	Action     string `json:"action"`     // 前缀匹配，如 user.
+	Keyword    string `json:"keyword"`    // 在请求参数中模糊匹配，如目标用户 uuid
+	StartTime  int64  `json:"start_time"` // Unix 秒，0 表示不限
+	EndTime    int64  `json:"end_time"`   // Unix 秒，0 表示不限
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+}
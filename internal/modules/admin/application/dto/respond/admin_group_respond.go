@@ -0,0 +1,20 @@
+package respond
+
+type AdminGroupItem struct {
+	Uuid      string `json:"uuid"`
+	Name      string `json:"name"`
+	Avatar    string `json:"avatar"`
+	OwnerId   string `json:"owner_id"`
+	MemberCnt int    `json:"member_cnt"`
+	AddMode   int8   `json:"add_mode"`
+	Status    int8   `json:"status"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type AdminGroupListRespond struct {
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	Items    []AdminGroupItem `json:"items"`
+}
@@ -0,0 +1,26 @@
+package respond
+
+type AdminUserItem struct {
+	Uuid          string `json:"uuid"`
+	Username      string `json:"username"`
+	Nickname      string `json:"nickname"`
+	Avatar        string `json:"avatar"`
+	Gender        int8   `json:"gender"`
+	IsAdmin       int8   `json:"is_admin"`
+	Status        int8   `json:"status"`
+	CreatedAt     string `json:"created_at"`
+	LastOnlineAt  string `json:"last_online_at"`
+	LastOfflineAt string `json:"last_offline_at"`
+}
+
+type AdminUserListRespond struct {
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+	Items    []AdminUserItem `json:"items"`
+}
+
+// AdminBatchRespond 批量操作结果
+type AdminBatchRespond struct {
+	Affected int64 `json:"affected"` // 实际发生变化的条数，已处于目标状态的不计入
+}
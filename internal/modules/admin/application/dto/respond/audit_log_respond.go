@@ -0,0 +1,23 @@
+package respond
+
+type AuditLogItem struct {
+	Id         int64  `json:"id"`
+	OperatorId string `json:"operator_id"`
+	Role       string `json:"role"`
+	Action     string `json:"action"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Ip         string `json:"ip"`
+	Request    string `json:"request"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	DurationMs int64  `json:"duration_ms"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type AuditLogListRespond struct {
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Items    []AuditLogItem `json:"items"`
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"OmniLink/internal/modules/admin/application/dto/request"
+	"OmniLink/internal/modules/admin/application/dto/respond"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	userService "OmniLink/internal/modules/user/application/service"
+	userRepository "OmniLink/internal/modules/user/domain/repository"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxBatchSize 单次批量操作的最大条数
+	maxBatchSize = 100
+	timeLayout   = "2006-01-02 15:04:05"
+)
+
+// AdminService 管理端用户与群组管理。权限校验与审计由路由上的 rbac 中间件完成，这里只做业务约束：
+// 不能对自己或其他管理员执行禁用、删除，避免误操作导致无人可管
+type AdminService interface {
+	ListUsers(req request.AdminUserListRequest) (*respond.AdminUserListRespond, error)
+	// DisableUsers 禁用用户并让其全部设备下线
+	DisableUsers(operatorID string, req request.AdminUserIdsRequest) (*respond.AdminBatchRespond, error)
+	AbleUsers(operatorID string, req request.AdminUserIdsRequest) (*respond.AdminBatchRespond, error)
+	// DeleteUsers 软删除用户并让其全部设备下线
+	DeleteUsers(operatorID string, req request.AdminUserIdsRequest) (*respond.AdminBatchRespond, error)
+	SetAdmin(operatorID string, req request.SetAdminRequest) error
+
+	ListGroups(req request.AdminGroupListRequest) (*respond.AdminGroupListRespond, error)
+	// SetGroupsStatus 启用或禁用群组，禁用后群内无法收发消息；已解散的群不受影响
+	SetGroupsStatus(req request.SetGroupsStatusRequest) (*respond.AdminBatchRespond, error)
+}
+
+type adminServiceImpl struct {
+	userRepo  userRepository.UserInfoRepository
+	groupRepo contactRepository.GroupInfoRepository
+	deviceSvc userService.DeviceService
+}
+
+func NewAdminService(userRepo userRepository.UserInfoRepository, groupRepo contactRepository.GroupInfoRepository, deviceSvc userService.DeviceService) AdminService {
+	return &adminServiceImpl{userRepo: userRepo, groupRepo: groupRepo, deviceSvc: deviceSvc}
+}
+
+func (s *adminServiceImpl) ListUsers(req request.AdminUserListRequest) (*respond.AdminUserListRespond, error) {
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+	users, total, err := s.userRepo.ListUsers(userRepository.UserQuery{
+		Keyword: strings.TrimSpace(req.Keyword),
+		Status:  req.Status,
+		IsAdmin: req.IsAdmin,
+		Offset:  (page - 1) * pageSize,
+		Limit:   pageSize,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	items := make([]respond.AdminUserItem, 0, len(users))
+	for _, u := range users {
+		item := respond.AdminUserItem{
+			Uuid:      u.Uuid,
+			Username:  u.Username,
+			Nickname:  u.Nickname,
+			Avatar:    u.Avatar,
+			Gender:    u.Gender,
+			IsAdmin:   u.IsAdmin,
+			Status:    u.Status,
+			CreatedAt: u.CreatedAt.Format(timeLayout),
+		}
+		if u.LastOnlineAt.Valid {
+			item.LastOnlineAt = u.LastOnlineAt.Time.Format(timeLayout)
+		}
+		if u.LastOfflineAt.Valid {
+			item.LastOfflineAt = u.LastOfflineAt.Time.Format(timeLayout)
+		}
+		items = append(items, item)
+	}
+	return &respond.AdminUserListRespond{Total: total, Page: page, PageSize: pageSize, Items: items}, nil
+}
+
+func (s *adminServiceImpl) DisableUsers(operatorID string, req request.AdminUserIdsRequest) (*respond.AdminBatchRespond, error) {
+	ids, err := s.checkTargets(operatorID, req.UserIds)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.userRepo.UpdateStatusByUUIDs(ids, 1)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	s.forceLogout(ids)
+	return &respond.AdminBatchRespond{Affected: n}, nil
+}
+
+func (s *adminServiceImpl) AbleUsers(operatorID string, req request.AdminUserIdsRequest) (*respond.AdminBatchRespond, error) {
+	ids, err := cleanIDs(req.UserIds, "user_ids")
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.userRepo.UpdateStatusByUUIDs(ids, 0)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	return &respond.AdminBatchRespond{Affected: n}, nil
+}
+
+func (s *adminServiceImpl) DeleteUsers(operatorID string, req request.AdminUserIdsRequest) (*respond.AdminBatchRespond, error) {
+	ids, err := s.checkTargets(operatorID, req.UserIds)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.userRepo.DeleteByUUIDs(ids)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	s.forceLogout(ids)
+	return &respond.AdminBatchRespond{Affected: n}, nil
+}
+
+func (s *adminServiceImpl) SetAdmin(operatorID string, req request.SetAdminRequest) error {
+	userID := strings.TrimSpace(req.UserId)
+	if userID == "" {
+		return xerr.New(xerr.BadRequest, "user_id 不能为空")
+	}
+	if req.IsAdmin != 0 && req.IsAdmin != 1 {
+		return xerr.New(xerr.BadRequest, "is_admin 只能为 0 或 1")
+	}
+	if userID == operatorID {
+		return xerr.New(xerr.Forbidden, "不能修改自己的管理员身份")
+	}
+	user, err := s.userRepo.GetUserInfoByUUIDWithoutPassword(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return xerr.New(xerr.NotFound, "用户不存在")
+		}
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	if req.IsAdmin == 1 && user.Status != 0 {
+		return xerr.New(xerr.Forbidden, "不能将已禁用的用户设为管理员")
+	}
+	if user.IsAdmin == req.IsAdmin {
+		return nil
+	}
+	if err := s.userRepo.UpdateIsAdmin(userID, req.IsAdmin); err != nil {
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	return nil
+}
+
+func (s *adminServiceImpl) ListGroups(req request.AdminGroupListRequest) (*respond.AdminGroupListRespond, error) {
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+	groups, total, err := s.groupRepo.ListGroups(contactRepository.GroupQuery{
+		Keyword: strings.TrimSpace(req.Keyword),
+		OwnerId: strings.TrimSpace(req.OwnerId),
+		Status:  req.Status,
+		Offset:  (page - 1) * pageSize,
+		Limit:   pageSize,
+	})
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	items := make([]respond.AdminGroupItem, 0, len(groups))
+	for _, g := range groups {
+		items = append(items, respond.AdminGroupItem{
+			Uuid:      g.Uuid,
+			Name:      g.Name,
+			Avatar:    g.Avatar,
+			OwnerId:   g.OwnerId,
+			MemberCnt: g.MemberCnt,
+			AddMode:   g.AddMode,
+			Status:    g.Status,
+			CreatedAt: g.CreatedAt.Format(timeLayout),
+			UpdatedAt: g.UpdatedAt.Format(timeLayout),
+		})
+	}
+	return &respond.AdminGroupListRespond{Total: total, Page: page, PageSize: pageSize, Items: items}, nil
+}
+
+func (s *adminServiceImpl) SetGroupsStatus(req request.SetGroupsStatusRequest) (*respond.AdminBatchRespond, error) {
+	if req.Status != 0 && req.Status != 1 {
+		return nil, xerr.New(xerr.BadRequest, "status 只能为 0 或 1")
+	}
+	ids, err := cleanIDs(req.GroupIds, "group_ids")
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.groupRepo.UpdateStatusByUUIDs(ids, req.Status)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	return &respond.AdminBatchRespond{Affected: n}, nil
+}
+
+// checkTargets 校验禁用、删除的目标：不能包含自己和管理员
+func (s *adminServiceImpl) checkTargets(operatorID string, userIDs []string) ([]string, error) {
+	ids, err := cleanIDs(userIDs, "user_ids")
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.userRepo.GetBatchUserInfoWithoutPassword(ids)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	for _, u := range users {
+		if u.Uuid == operatorID {
+			return nil, xerr.New(xerr.Forbidden, "不能对自己执行该操作")
+		}
+		if u.IsAdmin == 1 {
+			return nil, xerr.New(xerr.Forbidden, "不能对管理员执行该操作，请先取消其管理员身份")
+		}
+	}
+	return ids, nil
+}
+
+func (s *adminServiceImpl) forceLogout(userIDs []string) {
+	for _, uid := range userIDs {
+		if err := s.deviceSvc.ForceLogout(uid); err != nil {
+			zlog.Error("admin force logout failed, user=" + uid + ": " + err.Error())
+		}
+	}
+}
+
+// cleanIDs 去空去重，并限制批量大小
+func cleanIDs(ids []string, field string) ([]string, error) {
+	seen := make(map[string]struct{}, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	if len(out) == 0 {
+		return nil, xerr.New(xerr.BadRequest, field+" 不能为空")
+	}
+	if len(out) > maxBatchSize {
+		return nil, xerr.New(xerr.BadRequest, "单次最多操作 100 条")
+	}
+	return out, nil
+}
+
+func normalizePage(page int, pageSize int) (int, int) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	return page, pageSize
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"OmniLink/internal/modules/admin/application/dto/request"
+	"OmniLink/internal/modules/admin/application/dto/respond"
+	"OmniLink/internal/modules/admin/domain/entity"
+	"OmniLink/internal/modules/admin/domain/repository"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+)
+
+// AuditService 管理操作审计日志，由 rbac.Audit 中间件写入
+type AuditService interface {
+	// Record 写入一条审计日志；写入失败只记录错误日志，不影响已完成的管理操作
+	Record(log *entity.AdminAuditLog)
+	ListAuditLogs(req request.AuditLogListRequest) (*respond.AuditLogListRespond, error)
+}
+
+type auditServiceImpl struct {
+	repo repository.AdminAuditLogRepository
+}
+
+func NewAuditService(repo repository.AdminAuditLogRepository) AuditService {
+	return &auditServiceImpl{repo: repo}
+}
+
+func (s *auditServiceImpl) Record(log *entity.AdminAuditLog) {
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	if r := []rune(log.Message); len(r) > 255 {
+		log.Message = string(r[:255])
+	}
+	if err := s.repo.Create(log); err != nil {
+		zlog.Error("admin audit log write failed: " + err.Error())
+	}
+}
+
+func (s *auditServiceImpl) ListAuditLogs(req request.AuditLogListRequest) (*respond.AuditLogListRespond, error) {
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+	q := repository.AuditLogQuery{
+		OperatorId: strings.TrimSpace(req.OperatorId),
+		Action:     strings.TrimSpace(req.Action),
+		Keyword:    strings.TrimSpace(req.Keyword),
+		Offset:     (page - 1) * pageSize,
+		Limit:      pageSize,
+	}
+	if req.StartTime > 0 {
+		t := time.Unix(req.StartTime, 0)
+		q.Since = &t
+	}
+	if req.EndTime > 0 {
+		t := time.Unix(req.EndTime, 0)
+		q.Until = &t
+	}
+
+	logs, total, err := s.repo.List(q)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	items := make([]respond.AuditLogItem, 0, len(logs))
+	for _, l := range logs {
+		items = append(items, respond.AuditLogItem{
+			Id:         l.Id,
+			OperatorId: l.OperatorId,
+			Role:       l.Role,
+			Action:     l.Action,
+			Method:     l.Method,
+			Path:       l.Path,
+			Ip:         l.Ip,
+			Request:    l.Request,
+			Code:       l.Code,
+			Message:    l.Message,
+			DurationMs: l.DurationMs,
+			CreatedAt:  l.CreatedAt.Format(timeLayout),
+		})
+	}
+	return &respond.AuditLogListRespond{Total: total, Page: page, PageSize: pageSize, Items: items}, nil
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// AdminAuditLog 管理操作审计日志，每次调用管理接口（无论成功失败）写入一条，只增不改
+type AdminAuditLog struct {
+	Id         int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	OperatorId string    `gorm:"column:operator_id;index:idx_operator_created,priority:1;type:char(20);not null;comment:操作人uuid"`
+	Role       string    `gorm:"column:role;type:varchar(16);comment:操作时的角色"`
+	Action     string    `gorm:"column:action;index;type:varchar(64);not null;comment:操作，如 user.disable"`
+	Method     string    `gorm:"column:method;type:varchar(8);comment:HTTP方法"`
+	Path       string    `gorm:"column:path;type:varchar(255);comment:请求路径"`
+	Ip         string    `gorm:"column:ip;type:varchar(64);comment:操作人IP"`
+	Request    string    `gorm:"column:request;type:text;comment:请求参数"`
+	Code       int       `gorm:"column:code;comment:业务返回码，200为成功"`
+	Message    string    `gorm:"column:message;type:varchar(255);comment:返回信息"`
+	DurationMs int64     `gorm:"column:duration_ms;comment:处理耗时（毫秒）"`
+	CreatedAt  time.Time `gorm:"column:created_at;index;index:idx_operator_created,priority:2;type:datetime;not null;comment:操作时间"`
+}
+
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_log"
+}
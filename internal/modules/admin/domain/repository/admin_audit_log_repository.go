@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"time"
+
+	"OmniLink/internal/modules/admin/domain/entity"
+)
+
+// AuditLogQuery 审计日志查询条件
+type AuditLogQuery struct {
+	OperatorId string
+	Action     string // 前缀匹配，如 user. 查询全部用户管理操作
+	Keyword    string // 在请求参数中模糊匹配，用于按目标 uuid 追溯
+	Since      *time.Time
+	Until      *time.Time
+	Offset     int
+	Limit      int
+}
+
+// AdminAuditLogRepository 管理操作审计日志
+type AdminAuditLogRepository interface {
+	Create(log *entity.AdminAuditLog) error
+	// List 按时间倒序分页查询，返回当前页与总数
+	List(q AuditLogQuery) ([]entity.AdminAuditLog, int64, error)
+}
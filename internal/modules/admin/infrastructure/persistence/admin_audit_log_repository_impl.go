@@ -0,0 +1,53 @@
+package persistence
+
+import (
+	"OmniLink/internal/modules/admin/domain/entity"
+	"OmniLink/internal/modules/admin/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+type adminAuditLogRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAdminAuditLogRepository(db *gorm.DB) repository.AdminAuditLogRepository {
+	return &adminAuditLogRepositoryImpl{db: db}
+}
+
+func (r *adminAuditLogRepositoryImpl) Create(log *entity.AdminAuditLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *adminAuditLogRepositoryImpl) List(q repository.AuditLogQuery) ([]entity.AdminAuditLog, int64, error) {
+	query := r.db.Model(&entity.AdminAuditLog{})
+	if q.OperatorId != "" {
+		query = query.Where("operator_id = ?", q.OperatorId)
+	}
+	if q.Action != "" {
+		query = query.Where("action LIKE ?", q.Action+"%")
+	}
+	if q.Keyword != "" {
+		query = query.Where("request LIKE ?", "%"+q.Keyword+"%")
+	}
+	if q.Since != nil {
+		query = query.Where("created_at >= ?", *q.Since)
+	}
+	if q.Until != nil {
+		query = query.Where("created_at < ?", *q.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []entity.AdminAuditLog
+	err := query.Order("created_at DESC, id DESC").
+		Offset(q.Offset).
+		Limit(q.Limit).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"OmniLink/internal/modules/admin/application/dto/request"
+	"OmniLink/internal/modules/admin/application/service"
+	"OmniLink/pkg/back"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理端接口，路由上需挂 rbac.Require 与 rbac.Audit
+type AdminHandler struct {
+	svc      service.AdminService
+	auditSvc service.AuditService
+}
+
+func NewAdminHandler(svc service.AdminService, auditSvc service.AuditService) *AdminHandler {
+	return &AdminHandler{svc: svc, auditSvc: auditSvc}
+}
+
+// GetUserList 分页查询用户
+func (h *AdminHandler) GetUserList(c *gin.Context) {
+	var req request.AdminUserListRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.ListUsers(req)
+	back.Result(c, data, err)
+}
+
+// DisableUsers 批量禁用用户
+func (h *AdminHandler) DisableUsers(c *gin.Context) {
+	var req request.AdminUserIdsRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.DisableUsers(c.GetString("uuid"), req)
+	back.Result(c, data, err)
+}
+
+// AbleUsers 批量启用用户
+func (h *AdminHandler) AbleUsers(c *gin.Context) {
+	var req request.AdminUserIdsRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.AbleUsers(c.GetString("uuid"), req)
+	back.Result(c, data, err)
+}
+
+// DeleteUsers 批量删除用户
+func (h *AdminHandler) DeleteUsers(c *gin.Context) {
+	var req request.AdminUserIdsRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.DeleteUsers(c.GetString("uuid"), req)
+	back.Result(c, data, err)
+}
+
+// SetAdmin 设置或取消管理员
+func (h *AdminHandler) SetAdmin(c *gin.Context) {
+	var req request.SetAdminRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	err := h.svc.SetAdmin(c.GetString("uuid"), req)
+	back.Result(c, nil, err)
+}
+
+// GetGroupList 分页查询群组
+func (h *AdminHandler) GetGroupList(c *gin.Context) {
+	var req request.AdminGroupListRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.ListGroups(req)
+	back.Result(c, data, err)
+}
+
+// SetGroupsStatus 批量启用/禁用群组
+func (h *AdminHandler) SetGroupsStatus(c *gin.Context) {
+	var req request.SetGroupsStatusRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.SetGroupsStatus(req)
+	back.Result(c, data, err)
+}
+
+// GetAuditLogList 分页查询管理操作审计日志
+func (h *AdminHandler) GetAuditLogList(c *gin.Context) {
+	var req request.AuditLogListRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.auditSvc.ListAuditLogs(req)
+	back.Result(c, data, err)
+}
@@ -40,6 +40,9 @@ type ChatMessagesPageRequest struct {
 	SourceKey  string
 
 	DedupExtra string
+
+	// ReplaceMessageUUID 非空时先清除该消息已有的分片再入库，用于消息撤回、编辑后重建索引；此时 SessionUUID 可为空，表示只清除不入库
+	ReplaceMessageUUID string
 }
 type BackfillRequest struct {
 	TenantUserID       string `json:"tenant_user_id"`
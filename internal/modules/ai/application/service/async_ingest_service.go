@@ -70,8 +70,9 @@ func (s *asyncIngestService) EnqueueChatMessagesPage(ctx context.Context, req re
 		return xerr.New(xerr.BadRequest, "missing tenant_user_id")
 	}
 
+	// 没有会话时只允许清除分片（ReplaceMessageUUID 非空），不重新入库
 	sessUUID := strings.TrimSpace(req.SessionUUID)
-	if sessUUID == "" {
+	if sessUUID == "" && strings.TrimSpace(req.ReplaceMessageUUID) == "" {
 		return xerr.New(xerr.BadRequest, "missing session_uuid")
 	}
 
@@ -123,6 +124,9 @@ func (s *asyncIngestService) EnqueueChatMessagesPage(ctx context.Context, req re
 		"since":        sinceStr,
 		"until":        untilStr,
 	}
+	if replace := strings.TrimSpace(req.ReplaceMessageUUID); replace != "" {
+		payload["replace_message_uuid"] = replace
+	}
 
 	dedupExtra := strings.TrimSpace(req.DedupExtra)
 	if dedupExtra == "" {
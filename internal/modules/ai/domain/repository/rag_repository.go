@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"OmniLink/internal/modules/ai/domain/rag"
 )
@@ -13,6 +14,11 @@ type RAGRepository interface {
 	GetKnowledgeSource(ctx context.Context, kbID int64, tenantUserID, sourceType, sourceKey string) (*rag.AIKnowledgeSource, error)
 	ListVectorIDsBySourceID(ctx context.Context, sourceID int64) ([]string, error)
 	DeleteChunksAndVectorRecordsBySourceID(ctx context.Context, sourceID int64) error
+	// ListVectorIDsByMessageUUID/DeleteChunksAndVectorRecordsByMessageUUID 按 metadata_json.message_uuids 定位覆盖某条聊天消息的分片（用于撤回与编辑）
+	ListVectorIDsByMessageUUID(ctx context.Context, sourceID int64, messageUUID string) ([]string, error)
+	DeleteChunksAndVectorRecordsByMessageUUID(ctx context.Context, sourceID int64, messageUUID string) error
+	// ListSegmentStartsByMessageUUID 返回覆盖该消息的分片所在片段的起始时间（metadata_json.segment_start）
+	ListSegmentStartsByMessageUUID(ctx context.Context, sourceID int64, messageUUID string) ([]time.Time, error)
 	UpdateKnowledgeSourceStatus(ctx context.Context, sourceID int64, status int8) error
 
 	GetChunkByChunkKey(ctx context.Context, chunkKey string) (*rag.AIKnowledgeChunk, error)
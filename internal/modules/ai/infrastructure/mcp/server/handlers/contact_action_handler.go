@@ -43,7 +43,7 @@ func (h *ContactActionToolHandler) RegisterTools(s *server.MCPServer) {
 	s.AddTool(refuseFriendApplyTool, h.handleRefuseFriendApply)
 	// 加入群聊工具(申请)
 	joinGroupTool := mcp.NewTool("group_join",
-		mcp.WithDescription("申请加入群聊。**需要用户确认后才会执行**"),
+		mcp.WithDescription("申请加入群聊,直接入群的群立即加入,需审核的群提交申请等待群主或管理员审批。**需要用户确认后才会执行**"),
 		mcp.WithString("tenant_user_id", mcp.Required(), mcp.Description("租户用户ID")),
 		mcp.WithString("group_id", mcp.Required(), mcp.Description("群组ID")),
 		mcp.WithString("message", mcp.Description("申请消息")),
@@ -166,19 +166,28 @@ func (h *ContactActionToolHandler) handleJoinGroup(ctx context.Context, request
 			"next_step":             "请用户明确回复'确认'后,再次调用此工具并传入 confirmed=true",
 		})
 	}
-	// 调用 ApplyContact 服务(群聊申请)
-	_, err := h.contactSvc.ApplyContact(contactRequest.ApplyContactRequest{
-		OwnerId:   tenantUserID,
-		ContactId: groupID,
-		Message:   message,
+	// 按群的加群方式直接入群或提交入群申请
+	res, err := h.groupSvc.ApplyJoinGroup(contactRequest.ApplyJoinGroupRequest{
+		OwnerId: tenantUserID,
+		GroupId: groupID,
+		Message: message,
 	})
 
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("申请加入群聊失败: %v", err)), nil
 	}
+	if res.Joined {
+		return mcp.NewToolResultJSON(map[string]interface{}{
+			"success": true,
+			"joined":  true,
+			"message": "已成功加入群聊",
+		})
+	}
 	return mcp.NewToolResultJSON(map[string]interface{}{
-		"success": true,
-		"message": "已成功提交入群申请,等待群主审批",
+		"success":  true,
+		"joined":   false,
+		"apply_id": res.ApplyId,
+		"message":  "已成功提交入群申请,等待群主或管理员审批",
 	})
 }
 
@@ -125,10 +125,7 @@ func (h *NotificationToolHandler) handlePushNotification(ctx context.Context, re
 	// 推送 AI 专用消息类型，前端需要兼容处理
 	// 或者，如果前端已经能处理标准IM消息，我们可以直接推送标准类型？
 	// 这里为了区分，还是用 ai_notification，但在前端转换为标准消息处理
-	if err := h.hub.SendJSON(userID, map[string]interface{}{
-		"type":    "ai_notification",
-		"payload": payload,
-	}); err != nil {
+	if err := h.hub.SendNotification(userID, "ai_notification", payload); err != nil {
 		return mcp.NewToolResultError("failed to push notification: " + err.Error()), nil
 	}
 
@@ -219,6 +219,73 @@ func (r *ragRepositoryImpl) DeleteChunksAndVectorRecordsBySourceID(ctx context.C
 	})
 }
 
+func (r *ragRepositoryImpl) ListVectorIDsByMessageUUID(ctx context.Context, sourceID int64, messageUUID string) ([]string, error) {
+	messageUUID = strings.TrimSpace(messageUUID)
+	if sourceID <= 0 || messageUUID == "" {
+		return []string{}, nil
+	}
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Table("ai_vector_record AS vr").
+		Joins("JOIN ai_knowledge_chunk AS c ON c.id = vr.chunk_id").
+		Where("c.source_id = ? AND JSON_CONTAINS(c.metadata_json, JSON_QUOTE(?), '$.message_uuids')", sourceID, messageUUID).
+		Pluck("vr.vector_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
+func (r *ragRepositoryImpl) ListSegmentStartsByMessageUUID(ctx context.Context, sourceID int64, messageUUID string) ([]time.Time, error) {
+	messageUUID = strings.TrimSpace(messageUUID)
+	if sourceID <= 0 || messageUUID == "" {
+		return []time.Time{}, nil
+	}
+	var raws []string
+	err := r.db.WithContext(ctx).
+		Model(&rag.AIKnowledgeChunk{}).
+		Where("source_id = ? AND JSON_CONTAINS(metadata_json, JSON_QUOTE(?), '$.message_uuids')", sourceID, messageUUID).
+		Pluck("JSON_UNQUOTE(JSON_EXTRACT(metadata_json, '$.segment_start'))", &raws).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]time.Time, 0, len(raws))
+	for _, raw := range raws {
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(raw))
+		if err != nil {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (r *ragRepositoryImpl) DeleteChunksAndVectorRecordsByMessageUUID(ctx context.Context, sourceID int64, messageUUID string) error {
+	messageUUID = strings.TrimSpace(messageUUID)
+	if sourceID <= 0 || messageUUID == "" {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sub := tx.Model(&rag.AIKnowledgeChunk{}).Select("id").
+			Where("source_id = ? AND JSON_CONTAINS(metadata_json, JSON_QUOTE(?), '$.message_uuids')", sourceID, messageUUID)
+		if err := tx.Where("chunk_id IN (?)", sub).Delete(&rag.AIVectorRecord{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("source_id = ? AND JSON_CONTAINS(metadata_json, JSON_QUOTE(?), '$.message_uuids')", sourceID, messageUUID).Delete(&rag.AIKnowledgeChunk{}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
 func (r *ragRepositoryImpl) UpdateKnowledgeSourceStatus(ctx context.Context, sourceID int64, status int8) error {
 	if sourceID <= 0 {
 		return nil
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"OmniLink/internal/modules/ai/domain/rag"
+	"OmniLink/internal/modules/ai/infrastructure/transform"
 	"OmniLink/pkg/zlog"
 
 	"github.com/cloudwego/eino/compose"
@@ -152,9 +153,11 @@ func (p *IngestPipeline) mergeTurnsNode(ctx context.Context, st *ingestState, _
 		return st, nil
 	}
 
-	var segments []string
+	var segments []transform.Segment
 	if len(st.Req.Documents) > 0 {
-		segments = st.Req.Documents
+		for _, d := range st.Req.Documents {
+			segments = append(segments, transform.Segment{Content: d})
+		}
 	} else {
 		if p.merger == nil {
 			st.Err = fmt.Errorf("merger is nil")
@@ -165,8 +168,8 @@ func (p *IngestPipeline) mergeTurnsNode(ctx context.Context, st *ingestState, _
 	docs := make([]*schema.Document, 0, len(segments))
 	segIndex := 0
 	for _, seg := range segments {
-		seg = strings.TrimSpace(seg)
-		if seg == "" {
+		content := strings.TrimSpace(seg.Content)
+		if content == "" {
 			continue
 		}
 		md := map[string]any{
@@ -179,7 +182,12 @@ func (p *IngestPipeline) mergeTurnsNode(ctx context.Context, st *ingestState, _
 			"session_name":   st.Req.SessionName,
 			"segment_index":  segIndex,
 		}
-		docs = append(docs, &schema.Document{Content: seg, MetaData: md})
+		// 聊天片段记录覆盖的消息，撤回或编辑时按 message_uuids 清除分片
+		if len(seg.MessageUUIDs) > 0 {
+			md["message_uuids"] = seg.MessageUUIDs
+			md["segment_start"] = seg.StartAt.Format(time.RFC3339)
+		}
+		docs = append(docs, &schema.Document{Content: content, MetaData: md})
 		segIndex++
 	}
 	st.Docs = docs
@@ -232,7 +240,7 @@ func (p *IngestPipeline) chunkNode(ctx context.Context, st *ingestState, _ ...an
 		ckey := "ck_" + sha256Hex(fmt.Sprintf("%s|%s|%s|%d|%d|%s", st.Req.TenantUserID, st.Req.SourceType, st.Req.SourceKey, segIndex, chunkIndex, chash))
 		defaultVID := "v_" + sha256Hex(fmt.Sprintf("%s|%s|%s|%s|%d", st.Req.TenantUserID, st.Req.SourceType, st.Req.SourceKey, ckey, p.vectorDim))[:48]
 
-		metaJSON := buildMetadataJSON(st.Req, segIndex, chunkIndex)
+		metaJSON := buildMetadataJSON(st.Req, segIndex, chunkIndex, d.MetaData)
 		existingChunk, err := p.repo.GetChunkByChunkKey(ctx, ckey)
 		if err != nil {
 			st.Err = err
@@ -511,7 +519,7 @@ func stChunkDocuments(ctx context.Context, p *IngestPipeline, docs []*schema.Doc
 	return p.chunker.ChunkDocuments(ctx, docs)
 }
 
-func buildMetadataJSON(req *IngestRequest, segmentIndex, chunkIndex int) string {
+func buildMetadataJSON(req *IngestRequest, segmentIndex, chunkIndex int, docMeta map[string]any) string {
 	if req == nil {
 		return "{}"
 	}
@@ -522,6 +530,11 @@ func buildMetadataJSON(req *IngestRequest, segmentIndex, chunkIndex int) string
 		"segment_index": segmentIndex,
 		"chunk_index":   chunkIndex,
 	}
+	for _, key := range []string{"message_uuids", "segment_start"} {
+		if v, ok := docMeta[key]; ok && v != nil {
+			m[key] = v
+		}
+	}
 	bs, err := json.Marshal(m)
 	if err != nil || len(bs) == 0 {
 		return "{}"
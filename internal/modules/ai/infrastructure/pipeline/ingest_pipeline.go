@@ -4,9 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 	"time"
 
@@ -15,12 +13,10 @@ import (
 	"OmniLink/internal/modules/ai/infrastructure/chunking"
 	"OmniLink/internal/modules/ai/infrastructure/transform"
 	chatEntity "OmniLink/internal/modules/chat/domain/entity"
-	"OmniLink/pkg/zlog"
 
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/compose"
-	"go.uber.org/zap"
 )
 
 type IngestRequest struct {
@@ -131,221 +127,59 @@ func (p *IngestPipeline) PurgeSource(ctx context.Context, tenantUserID, sourceTy
 	return nil
 }
 
-func (p *IngestPipeline) ingestNode(ctx context.Context, req *IngestRequest, _ ...any) (*IngestResult, error) {
-	start := time.Now()
-	if req == nil {
-		return nil, fmt.Errorf("nil request")
-	}
-	tenant := strings.TrimSpace(req.TenantUserID)
-	if tenant == "" {
-		return nil, fmt.Errorf("missing tenant_user_id")
+// PurgeMessage 删除某个数据源下覆盖该消息的全部分片与向量（消息撤回、编辑时调用），
+// 返回被删除片段中最早的起始时间，调用方需从该时间起重新入库，补回同一片段内其他消息的内容
+func (p *IngestPipeline) PurgeMessage(ctx context.Context, tenantUserID, sourceType, sourceKey, messageUUID string) (time.Time, error) {
+	if p == nil || p.repo == nil || p.vs == nil {
+		return time.Time{}, fmt.Errorf("pipeline repo/vs is nil")
 	}
-	if strings.TrimSpace(req.SourceType) == "" || strings.TrimSpace(req.SourceKey) == "" {
-		return nil, fmt.Errorf("missing source_type/source_key")
+	tenant := strings.TrimSpace(tenantUserID)
+	sourceType = strings.TrimSpace(sourceType)
+	sourceKey = strings.TrimSpace(sourceKey)
+	messageUUID = strings.TrimSpace(messageUUID)
+	if tenant == "" || sourceType == "" || sourceKey == "" || messageUUID == "" {
+		return time.Time{}, fmt.Errorf("missing tenant/source/message")
 	}
 
 	now := time.Now()
 	kb := &rag.AIKnowledgeBase{OwnerType: "user", OwnerId: tenant, KBType: "global", Name: "global", Status: rag.CommonStatusEnabled, CreatedAt: now, UpdatedAt: now}
 	kbID, err := p.repo.EnsureKnowledgeBase(ctx, kb)
 	if err != nil {
-		return nil, err
-	}
-	src := &rag.AIKnowledgeSource{KBId: kbID, SourceType: req.SourceType, SourceKey: req.SourceKey, TenantUserId: tenant, Version: 1, Status: rag.CommonStatusEnabled, CreatedAt: now, UpdatedAt: now}
-	sourceID, err := p.repo.EnsureKnowledgeSource(ctx, src)
-	if err != nil {
-		return nil, err
-	}
-
-	type chunkItem struct {
-		unitKey string
-		subIdx  int
-		msg     *chatEntity.Message
-		content string
+		return time.Time{}, err
 	}
 
-	chunks := make([]chunkItem, 0, 64)
-
-	if len(req.Documents) > 0 {
-		for di, d := range req.Documents {
-			d = strings.TrimSpace(d)
-			if d == "" {
-				continue
-			}
-			parts := p.chunker.Chunk(d)
-			for si, part := range parts {
-				part = strings.TrimSpace(part)
-				if part == "" {
-					continue
-				}
-				chunks = append(chunks, chunkItem{unitKey: fmt.Sprintf("doc_%d", di), subIdx: si, content: part})
-			}
-		}
-	} else if req.SourceType == "chat_private" || req.SourceType == "chat_group" {
-		msgs := make([]chatEntity.Message, 0, len(req.Messages))
-		for _, m := range req.Messages {
-			if m.Type != 0 {
-				continue
-			}
-			m.Content = strings.TrimSpace(m.Content)
-			if m.Content == "" {
-				continue
-			}
-			msgs = append(msgs, m)
-		}
-		sort.Slice(msgs, func(i, j int) bool {
-			if msgs[i].CreatedAt.Equal(msgs[j].CreatedAt) {
-				return msgs[i].Uuid < msgs[j].Uuid
-			}
-			return msgs[i].CreatedAt.Before(msgs[j].CreatedAt)
-		})
-		for _, m := range msgs {
-			mm := m
-			text := fmt.Sprintf("%s(%s): %s", strings.TrimSpace(mm.SendName), mm.CreatedAt.Format("15:04:05"), mm.Content)
-			parts := p.chunker.Chunk(text)
-			for si, part := range parts {
-				part = strings.TrimSpace(part)
-				if part == "" {
-					continue
-				}
-				chunks = append(chunks, chunkItem{unitKey: strings.TrimSpace(mm.Uuid), subIdx: si, msg: &mm, content: part})
-			}
-		}
-	} else {
-		segments := p.merger.Merge(req.Messages)
-		for gi, seg := range segments {
-			seg = strings.TrimSpace(seg)
-			if seg == "" {
-				continue
-			}
-			parts := p.chunker.Chunk(seg)
-			for si, part := range parts {
-				part = strings.TrimSpace(part)
-				if part == "" {
-					continue
-				}
-				chunks = append(chunks, chunkItem{unitKey: fmt.Sprintf("seg_%d", gi), subIdx: si, content: part})
-			}
-		}
+	src, err := p.repo.GetKnowledgeSource(ctx, kbID, tenant, sourceType, sourceKey)
+	if err != nil {
+		return time.Time{}, err
 	}
-
-	res := &IngestResult{TenantUserID: tenant, SourceType: req.SourceType, SourceKey: req.SourceKey, KBID: kbID, SourceID: sourceID, Messages: len(req.Messages), Segments: 0, Chunks: len(chunks)}
-	if len(chunks) == 0 {
-		res.DurationMs = time.Since(start).Milliseconds()
-		return res, nil
+	if src == nil || src.Id <= 0 {
+		return time.Time{}, nil
 	}
 
-	type upsertItem struct {
-		VectorID   string
-		ChunkID    int64
-		Content    string
-		MetaJSON   string
-		TextForEmb string
+	var earliest time.Time
+	starts, err := p.repo.ListSegmentStartsByMessageUUID(ctx, src.Id, messageUUID)
+	if err != nil {
+		return time.Time{}, err
 	}
-	items := make([]upsertItem, 0, len(chunks))
-
-	for i := range chunks {
-		content := chunks[i].content
-		chash := sha256Hex(content)
-		unitKey := strings.TrimSpace(chunks[i].unitKey)
-		if unitKey == "" {
-			unitKey = fmt.Sprintf("idx_%d", i)
-		}
-		ckey := "ck_" + sha256Hex(fmt.Sprintf("%s|%s|%s|%s|%d|%s", tenant, req.SourceType, req.SourceKey, unitKey, chunks[i].subIdx, chash))
-		vid := "v_" + sha256Hex(fmt.Sprintf("%s|%s|%s|%s|%d", tenant, req.SourceType, req.SourceKey, ckey, p.vectorDim))[:48]
-
-		metaJSON := safeMeta(req, i)
-		if chunks[i].msg != nil {
-			metaJSON = safeChatMsgMeta(req, i, *chunks[i].msg)
-		}
-
-		existingChunk, err := p.repo.GetChunkByChunkKey(ctx, ckey)
-		if err != nil {
-			res.VectorsFail++
-			continue
+	for _, t := range starts {
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
 		}
-		if existingChunk != nil {
-			vr, err := p.repo.GetVectorRecordByChunkID(ctx, existingChunk.Id)
-			if err != nil {
-				res.VectorsFail++
-				continue
-			}
-			if vr != nil && vr.EmbedStatus == rag.VectorEmbedStatusSucceeded {
-				res.VectorsSkip++
-				continue
-			}
-			if vr == nil {
-				err = p.repo.CreateVectorRecord(ctx, &rag.AIVectorRecord{ChunkId: existingChunk.Id, VectorStore: "milvus", Collection: p.collection, VectorId: vid, EmbeddingProvider: "mock", EmbeddingModel: "mock", Dim: p.vectorDim, EmbedStatus: rag.VectorEmbedStatusPending, CreatedAt: now, UpdatedAt: now})
-				if err != nil {
-					res.VectorsFail++
-					continue
-				}
-			}
-			items = append(items, upsertItem{VectorID: vid, ChunkID: existingChunk.Id, Content: truncate4096(content), MetaJSON: metaJSON, TextForEmb: content})
-			continue
-		}
-
-		chunk := &rag.AIKnowledgeChunk{KBId: kbID, SourceId: sourceID, ChunkKey: ckey, ChunkIndex: i, Content: content, ContentHash: chash, MetadataJson: metaJSON, Status: rag.CommonStatusEnabled, CreatedAt: now, UpdatedAt: now}
-		record := &rag.AIVectorRecord{VectorStore: "milvus", Collection: p.collection, VectorId: vid, EmbeddingProvider: "mock", EmbeddingModel: "mock", Dim: p.vectorDim, EmbedStatus: rag.VectorEmbedStatusPending, CreatedAt: now, UpdatedAt: now}
-		if err := p.repo.CreateChunkAndVectorRecord(ctx, chunk, record); err != nil {
-			res.VectorsFail++
-			continue
-		}
-		items = append(items, upsertItem{VectorID: vid, ChunkID: chunk.Id, Content: truncate4096(content), MetaJSON: chunk.MetadataJson, TextForEmb: content})
 	}
 
-	if len(items) == 0 {
-		res.DurationMs = time.Since(start).Milliseconds()
-		return res, nil
-	}
-
-	texts := make([]string, 0, len(items))
-	for _, it := range items {
-		texts = append(texts, it.TextForEmb)
-	}
-	vecs, err := p.embedder.EmbedStrings(ctx, texts)
+	ids, err := p.repo.ListVectorIDsByMessageUUID(ctx, src.Id, messageUUID)
 	if err != nil {
-		for _, it := range items {
-			_ = p.repo.UpdateVectorStatus(ctx, it.VectorID, rag.VectorEmbedStatusFailed, err.Error())
-		}
-		res.VectorsFail += len(items)
-		res.DurationMs = time.Since(start).Milliseconds()
-		return res, err
+		return time.Time{}, err
 	}
-
-	upserts := make([]repository.VectorUpsertItem, 0, len(items))
-	for i := range items {
-		vec64 := vecs[i]
-		if len(vec64) != p.vectorDim {
-			_ = p.repo.UpdateVectorStatus(ctx, items[i].VectorID, rag.VectorEmbedStatusFailed, fmt.Sprintf("vector dim mismatch got=%d want=%d", len(vec64), p.vectorDim))
-			res.VectorsFail++
-			continue
-		}
-		vec32 := make([]float32, len(vec64))
-		for j := range vec64 {
-			vec32[j] = float32(vec64[j])
+	if len(ids) > 0 {
+		if err := p.vs.DeleteByIDs(ctx, ids); err != nil {
+			return time.Time{}, err
 		}
-		upserts = append(upserts, repository.VectorUpsertItem{ID: items[i].VectorID, Vector: vec32, TenantUserID: tenant, KBID: kbID, SourceType: req.SourceType, SourceKey: req.SourceKey, ChunkID: items[i].ChunkID, Content: items[i].Content, MetadataJSON: items[i].MetaJSON})
 	}
-
-	if len(upserts) > 0 {
-		_, err = p.vs.Upsert(ctx, upserts)
-		if err != nil {
-			for _, it := range upserts {
-				_ = p.repo.UpdateVectorStatus(ctx, it.ID, rag.VectorEmbedStatusFailed, err.Error())
-			}
-			res.VectorsFail += len(upserts)
-			res.DurationMs = time.Since(start).Milliseconds()
-			return res, err
-		}
-		for _, it := range upserts {
-			_ = p.repo.UpdateVectorStatus(ctx, it.ID, rag.VectorEmbedStatusSucceeded, "")
-			res.VectorsOK++
-		}
+	if err := p.repo.DeleteChunksAndVectorRecordsByMessageUUID(ctx, src.Id, messageUUID); err != nil {
+		return time.Time{}, err
 	}
-
-	zlog.Info("ai ingest done", zap.String("tenant_user_id", tenant), zap.String("source_type", req.SourceType), zap.String("source_key", req.SourceKey), zap.Int("chunks", res.Chunks), zap.Int("ok", res.VectorsOK), zap.Int("skip", res.VectorsSkip), zap.Int("fail", res.VectorsFail), zap.Int64("ms", time.Since(start).Milliseconds()))
-	res.DurationMs = time.Since(start).Milliseconds()
-	return res, nil
+	return earliest, nil
 }
 
 func sha256Hex(s string) string {
@@ -360,37 +194,3 @@ func truncate4096(s string) string {
 	}
 	return string(r[:4096])
 }
-
-func safeMeta(req *IngestRequest, chunkIndex int) string {
-	m := map[string]any{"session_uuid": req.SessionUUID, "session_type": req.SessionType, "session_name": req.SessionName, "chunk_index": chunkIndex}
-	bs, err := json.Marshal(m)
-	if err != nil {
-		return "{}"
-	}
-	if len(bs) == 0 {
-		return "{}"
-	}
-	return string(bs)
-}
-
-func safeChatMsgMeta(req *IngestRequest, chunkIndex int, msg chatEntity.Message) string {
-	m := map[string]any{
-		"session_uuid": req.SessionUUID,
-		"session_type": req.SessionType,
-		"session_name": req.SessionName,
-		"chunk_index":  chunkIndex,
-		"message_uuid": strings.TrimSpace(msg.Uuid),
-		"message_time": msg.CreatedAt.Format(time.RFC3339),
-		"send_id":      strings.TrimSpace(msg.SendId),
-		"send_name":    strings.TrimSpace(msg.SendName),
-		"receive_id":   strings.TrimSpace(msg.ReceiveId),
-	}
-	bs, err := json.Marshal(m)
-	if err != nil {
-		return "{}"
-	}
-	if len(bs) == 0 {
-		return "{}"
-	}
-	return string(bs)
-}
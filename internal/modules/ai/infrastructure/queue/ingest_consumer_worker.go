@@ -167,8 +167,14 @@ func (w *IngestConsumerWorker) processEvent(ctx context.Context, ev *rag.AIInges
 			return err
 		}
 		doc = strings.TrimSpace(doc)
+
+		// 已删除或拉黑的好友不再保留画像
 		if doc == "" {
-			return nil
+			return w.pipeline.PurgeSource(ctx, ev.TenantUserId, "contact_profile", cid, true)
+		}
+
+		if err := w.pipeline.PurgeSource(ctx, ev.TenantUserId, "contact_profile", cid, false); err != nil {
+			return err
 		}
 		_, err = w.pipeline.Ingest(ctx, pipeline.IngestRequest{
 			TenantUserID: ev.TenantUserId,
@@ -234,13 +240,15 @@ func (w *IngestConsumerWorker) processEvent(ctx context.Context, ev *rag.AIInges
 			PageSize    int    `json:"page_size"`
 			Since       string `json:"since"`
 			Until       string `json:"until"`
+
+			ReplaceMessageUUID string `json:"replace_message_uuid"`
 		}
 		if err := json.Unmarshal([]byte(ev.PayloadJson), &p); err != nil {
 			return err
 		}
 
 		sessUUID := strings.TrimSpace(p.SessionUUID)
-		if sessUUID == "" {
+		if sessUUID == "" && strings.TrimSpace(p.ReplaceMessageUUID) == "" {
 			return errors.New("missing session_uuid")
 		}
 		targetID := strings.TrimSpace(p.TargetID)
@@ -276,6 +284,22 @@ func (w *IngestConsumerWorker) processEvent(ctx context.Context, ev *rag.AIInges
 			}
 		}
 
+		// 消息被撤回或编辑：先按 message_uuids 清除覆盖该消息的分片，再从被清除片段的起点重新入库，
+		// 避免同一片段内其他消息的内容随之丢失
+		if replace := strings.TrimSpace(p.ReplaceMessageUUID); replace != "" {
+			earliest, err := w.pipeline.PurgeMessage(ctx, ev.TenantUserId, strings.TrimSpace(ev.SourceType), strings.TrimSpace(ev.SourceKey), replace)
+			if err != nil {
+				return err
+			}
+			if !earliest.IsZero() && (since == nil || earliest.Before(*since)) {
+				since = &earliest
+			}
+			// 该用户没有会话：只清除，不重新入库
+			if sessUUID == "" {
+				return nil
+			}
+		}
+
 		sess := reader.ChatSessionItem{
 			SessionUUID: sessUUID,
 			TargetID:    targetID,
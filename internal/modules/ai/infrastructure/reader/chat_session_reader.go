@@ -110,6 +110,9 @@ func (r *ChatSessionReader) ReadMessages(ctx context.Context, userID string, ses
 		if msg.Type != 0 {
 			continue
 		}
+		if msg.IsRecalled {
+			continue
+		}
 		if strings.TrimSpace(msg.Content) == "" {
 			continue
 		}
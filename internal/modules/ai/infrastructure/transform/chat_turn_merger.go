@@ -14,6 +14,13 @@ type ChatTurnMerger struct {
 	TimeWindow time.Duration
 }
 
+// Segment 一个对话片段及其包含的消息，MessageUUIDs 用于撤回/编辑时定位需要清除的分片
+type Segment struct {
+	Content      string
+	MessageUUIDs []string
+	StartAt      time.Time // 片段内最早一条消息的时间
+}
+
 // NewChatTurnMerger 创建一个默认时间窗口为 5 分钟的聚合器
 func NewChatTurnMerger() *ChatTurnMerger {
 	return &ChatTurnMerger{
@@ -23,9 +30,11 @@ func NewChatTurnMerger() *ChatTurnMerger {
 
 // Merge 将消息聚合为多个对话片段。
 // 它会先按 SessionId 分组，再在每组内按 TimeWindow 合并相邻消息。
-func (m *ChatTurnMerger) Merge(messages []entity.Message) []string {
+// 引用回复会在行内标出被回复的消息；回复当前片段内的消息时即使超出时间窗口也不切分片段。
+// 已撤回的消息不会进入任何片段。
+func (m *ChatTurnMerger) Merge(messages []entity.Message) []Segment {
 	if len(messages) == 0 {
-		return []string{}
+		return []Segment{}
 	}
 
 	// 1) 按 SessionId 分组（防御性：避免混入不同会话的消息）
@@ -40,7 +49,15 @@ func (m *ChatTurnMerger) Merge(messages []entity.Message) []string {
 	}
 	sort.Strings(sessionIDs)
 
-	var result []string
+	// 引用回复优先使用批次内原消息的完整内容，不在批次内时退回到冗余的摘要
+	byUUID := make(map[string]*entity.Message, len(messages))
+	for i := range messages {
+		if messages[i].Uuid != "" {
+			byUUID[messages[i].Uuid] = &messages[i]
+		}
+	}
+
+	var result []Segment
 
 	// 2) 逐个会话处理
 	for _, sid := range sessionIDs {
@@ -55,23 +72,29 @@ func (m *ChatTurnMerger) Merge(messages []entity.Message) []string {
 		})
 
 		var currentSegment strings.Builder
+		var current Segment
 		var lastTime time.Time
 		isFirst := true
+		inSegment := make(map[string]struct{})
 
 		for _, msg := range sessionMsgs {
-			// 跳过空内容
+			// 跳过空内容与已撤回的消息
 			content := strings.TrimSpace(msg.Content)
-			if content == "" {
+			if content == "" || msg.IsRecalled {
 				continue
 			}
 
 			// 判断是否需要开启新的对话片段
 			if !isFirst {
-				if msg.CreatedAt.Sub(lastTime) > m.TimeWindow {
+				_, continues := inSegment[msg.ReplyToUuid]
+				if msg.CreatedAt.Sub(lastTime) > m.TimeWindow && !continues {
 					// 超过时间窗口：把当前片段收口，开始新片段
 					if currentSegment.Len() > 0 {
-						result = append(result, currentSegment.String())
+						current.Content = currentSegment.String()
+						result = append(result, current)
 						currentSegment.Reset()
+						current = Segment{}
+						inSegment = make(map[string]struct{})
 					}
 				} else {
 					// 在同一个时间窗口内：用换行分隔多条消息
@@ -90,8 +113,15 @@ func (m *ChatTurnMerger) Merge(messages []entity.Message) []string {
 			if receiverName == "" {
 				receiverName = "unknown"
 			}
-			line := fmt.Sprintf("%s[%s]->%s[%s](%s): %s", senderName, senderID, receiverName, receiverID, timeStr, content)
+			line := fmt.Sprintf("%s[%s]->%s[%s](%s)%s: %s", senderName, senderID, receiverName, receiverID, timeStr, replyQuote(msg, byUUID), content)
 			currentSegment.WriteString(line)
+			inSegment[msg.Uuid] = struct{}{}
+			if current.StartAt.IsZero() {
+				current.StartAt = msg.CreatedAt
+			}
+			if msg.Uuid != "" {
+				current.MessageUUIDs = append(current.MessageUUIDs, msg.Uuid)
+			}
 
 			lastTime = msg.CreatedAt
 			isFirst = false
@@ -99,9 +129,33 @@ func (m *ChatTurnMerger) Merge(messages []entity.Message) []string {
 
 		// 收口最后一个片段
 		if currentSegment.Len() > 0 {
-			result = append(result, currentSegment.String())
+			current.Content = currentSegment.String()
+			result = append(result, current)
 		}
 	}
 
 	return result
 }
+
+// replyQuote 生成引用回复标注，如 ` 回复 张三[U1]「原消息」`
+func replyQuote(msg entity.Message, byUUID map[string]*entity.Message) string {
+	if msg.ReplyToUuid == "" {
+		return ""
+	}
+	name := strings.TrimSpace(msg.ReplySendName)
+	id := strings.TrimSpace(msg.ReplySendId)
+	quote := strings.TrimSpace(msg.ReplySnippet)
+	if parent, ok := byUUID[msg.ReplyToUuid]; ok && !parent.IsRecalled {
+		if c := strings.TrimSpace(parent.Content); c != "" {
+			quote = c
+		}
+	}
+	r := []rune(quote)
+	if len(r) > 100 {
+		quote = string(r[:100]) + "…"
+	}
+	if name == "" {
+		name = id
+	}
+	return fmt.Sprintf(" 回复 %s[%s]「%s」", name, id, quote)
+}
@@ -42,11 +42,8 @@ func (h *AdminHandler) Backfill(c *gin.Context) {
 		return
 	}
 
+	// 路由已由 rbac 限定为管理员，可为任意用户回填；未指定时回填自己
 	userID := strings.TrimSpace(req.UserID)
-	if userID != "" && userID != uuid {
-		back.Error(c, xerr.Forbidden, "user_id 不匹配")
-		return
-	}
 	if userID == "" {
 		userID = uuid
 	}
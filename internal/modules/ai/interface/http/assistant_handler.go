@@ -8,6 +8,7 @@ import (
 	aiRequest "OmniLink/internal/modules/ai/application/dto/request"
 	"OmniLink/internal/modules/ai/application/service"
 	"OmniLink/pkg/back"
+	"OmniLink/pkg/ratelimit"
 	"OmniLink/pkg/xerr"
 	"OmniLink/pkg/zlog"
 
@@ -15,6 +16,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// llmQuotaAction 调用大模型的接口共用的配额，见 rateLimitConfig.actions
+const llmQuotaAction = "ai.llm"
+
 // AssistantHandler AI助手HTTP Handler
 type AssistantHandler struct {
 	svc service.AssistantService
@@ -46,6 +50,11 @@ func (h *AssistantHandler) Chat(c *gin.Context) {
 		return
 	}
 
+	if res := ratelimit.Check(ratelimit.ScopeAction, llmQuotaAction, uuid); !res.Allowed {
+		ratelimit.Reject(c, res)
+		return
+	}
+
 	// 调用Service执行聊天
 	data, err := h.svc.Chat(c.Request.Context(), req, uuid)
 	if err != nil {
@@ -75,6 +84,11 @@ func (h *AssistantHandler) ChatStream(c *gin.Context) {
 		return
 	}
 
+	if res := ratelimit.Check(ratelimit.ScopeAction, llmQuotaAction, uuid); !res.Allowed {
+		ratelimit.Reject(c, res)
+		return
+	}
+
 	// 设置SSE响应头
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
@@ -130,6 +144,11 @@ func (h *AssistantHandler) SmartCommand(c *gin.Context) {
 		back.Error(c, xerr.Unauthorized, "未登录")
 		return
 	}
+	if res := ratelimit.Check(ratelimit.ScopeAction, llmQuotaAction, uuid); !res.Allowed {
+		ratelimit.Reject(c, res)
+		return
+	}
+
 	data, err := h.svc.SmartCommand(c.Request.Context(), req, uuid)
 	if err != nil {
 		zlog.Error("assistant smart command failed", zap.Error(err), zap.String("uuid", uuid))
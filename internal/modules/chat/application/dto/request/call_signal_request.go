@@ -0,0 +1,15 @@
+package request
+
+import "encoding/json"
+
+// CallSignalRequest 通话信令，Action 取值：
+// invite / ringing / accept / reject / cancel / hangup / join / sdp / ice
+type CallSignalRequest struct {
+	Action     string          `json:"action"`
+	CallId     string          `json:"call_id"`
+	TargetId   string          `json:"target_id"`   // invite：对方用户或群组 uuid
+	Media      string          `json:"media"`       // invite：audio / video，默认 audio
+	InviteeIds []string        `json:"invitee_ids"` // 群通话 invite 指定邀请的成员，为空时邀请全部成员
+	To         string          `json:"to"`          // sdp / ice 的接收方
+	Data       json.RawMessage `json:"data"`        // sdp / ice 内容，服务端原样转发
+}
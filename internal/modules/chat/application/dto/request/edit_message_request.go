@@ -0,0 +1,10 @@
+package request
+
+type EditMessageRequest struct {
+	MessageId string `json:"message_id"`
+	Content   string `json:"content"`
+}
+
+type GetEditHistoryRequest struct {
+	MessageId string `json:"message_id"`
+}
@@ -0,0 +1,12 @@
+package request
+
+type ForwardMessagesRequest struct {
+	ClientMsgId string   `json:"client_msg_id"` // 重试时保持不变；逐条转发时按序追加后缀
+	MessageIds  []string `json:"message_ids"`   // 须来自同一会话
+	TargetId    string   `json:"target_id"`     // 好友 uuid 或群组 uuid
+	Mode        string   `json:"mode"`          // single 逐条转发 / merge 合并转发；为空时单条逐条、多条合并
+}
+
+type GetForwardRecordRequest struct {
+	MessageId string `json:"message_id"`
+}
@@ -0,0 +1,6 @@
+package request
+
+type MarkReadRequest struct {
+	TargetId  string `json:"target_id"`  // 会话对端：好友uuid 或 群组uuid
+	MessageId string `json:"message_id"` // 已读到的消息uuid，为空表示读到最新
+}
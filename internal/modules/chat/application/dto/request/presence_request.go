@@ -0,0 +1,13 @@
+package request
+
+// PresenceRequest presence 帧上行内容，Action 为 set / subscribe / unsubscribe
+type PresenceRequest struct {
+	Action  string   `json:"action"`
+	State   string   `json:"state,omitempty"`    // set 时使用：online / away
+	UserIds []string `json:"user_ids,omitempty"` // subscribe / unsubscribe 的好友uuid；unsubscribe 为空表示取消全部
+}
+
+// GetOnlineStatusRequest 批量查询好友在线状态，UserIds 为空时返回全部好友
+type GetOnlineStatusRequest struct {
+	UserIds []string `json:"user_ids"`
+}
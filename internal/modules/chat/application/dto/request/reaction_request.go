@@ -0,0 +1,7 @@
+package request
+
+type ReactMessageRequest struct {
+	MessageId string `json:"message_id"`
+	Emoji     string `json:"emoji"`
+	Action    string `json:"action"` // WS 帧使用：add / remove，HTTP 接口按路由区分
+}
@@ -0,0 +1,11 @@
+package request
+
+type SearchMessagesRequest struct {
+	Keyword   string `json:"keyword"`    // 多个关键词以空格分隔，需全部命中
+	SenderId  string `json:"sender_id"`  // 限定发送者
+	TargetId  string `json:"target_id"`  // 限定会话：好友 uuid 或群组 uuid
+	StartTime int64  `json:"start_time"` // Unix 秒，0 表示不限
+	EndTime   int64  `json:"end_time"`   // Unix 秒，0 表示不限
+	Page      int    `json:"page"`
+	PageSize  int    `json:"page_size"`
+}
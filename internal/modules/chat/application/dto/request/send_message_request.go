@@ -1,11 +1,14 @@
 package request
 
 type SendMessageRequest struct {
-	SessionId string `json:"session_id"`
-	ReceiveId string `json:"receive_id"`
-	Type      int8   `json:"type"`
-	Content   string `json:"content"`
-	Url       string `json:"url"`
+	ClientMsgId string `json:"client_msg_id"` // 客户端生成的消息ID，重试时保持不变，用于服务端去重
+	SessionId   string `json:"session_id"`
+	ReceiveId   string `json:"receive_id"`
+	Type        int8   `json:"type"`
+	Content     string `json:"content"`
+	Url         string `json:"url"`
+	FileId      string `json:"file_id"`       // 语音/文件消息引用的已上传文件，必须属于发送者
+	ReplyToUuid string `json:"reply_to_uuid"` // 引用回复的消息，须属于同一会话
 
 	FileType string `json:"file_type"`
 	FileName string `json:"file_name"`
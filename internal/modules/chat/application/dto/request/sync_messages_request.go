@@ -0,0 +1,7 @@
+package request
+
+type SyncMessagesRequest struct {
+	// Cursor 上次同步返回的续传游标，格式为 "conv_id:seq,conv_id:seq"；未出现的会话从头同步
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
@@ -0,0 +1,12 @@
+package request
+
+type GetThreadMessagesRequest struct {
+	MessageId string `json:"message_id"` // 话题根消息，或话题内任意一条回复
+	Page      int    `json:"page"`
+	PageSize  int    `json:"page_size"`
+}
+
+type GetThreadCountRequest struct {
+	GroupId    string   `json:"group_id"`
+	MessageIds []string `json:"message_ids"`
+}
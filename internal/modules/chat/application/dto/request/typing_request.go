@@ -0,0 +1,6 @@
+package request
+
+type TypingRequest struct {
+	TargetId string `json:"target_id"`       // 好友uuid 或 群组uuid
+	State    string `json:"state,omitempty"` // start / stop，为空按 start 处理
+}
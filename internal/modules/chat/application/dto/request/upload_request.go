@@ -0,0 +1,22 @@
+package request
+
+// InitUploadRequest 初始化分片上传；携带 sha256 时服务端会先尝试秒传（仅限本人已上传过的文件）与断点续传
+type InitUploadRequest struct {
+	FileName string `json:"file_name"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+	Sha256   string `json:"sha256"`
+	Category string `json:"category"` // image / voice / file，默认 file
+}
+
+type UploadStatusRequest struct {
+	UploadId string `json:"upload_id"`
+}
+
+type CompleteUploadRequest struct {
+	UploadId string `json:"upload_id"`
+}
+
+type GetFileUrlRequest struct {
+	FileId string `json:"file_id"`
+}
@@ -0,0 +1,6 @@
+package request
+
+// WsAuthRequest 连接内续期：客户端刷新访问令牌后通过 auth 帧上报新令牌，避免断线重连
+type WsAuthRequest struct {
+	Token string `json:"token"`
+}
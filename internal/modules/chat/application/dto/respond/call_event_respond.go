@@ -0,0 +1,26 @@
+package respond
+
+import "encoding/json"
+
+// CallEvent 服务端下发的通话信令，Action 取值：
+// calling / invite / ringing / accepted / rejected / joined / left / sdp / ice / busy / missed / ended
+type CallEvent struct {
+	Action       string            `json:"action"`
+	CallId       string            `json:"call_id"`
+	From         string            `json:"from,omitempty"`
+	FromDevice   string            `json:"from_device,omitempty"`
+	InitiatorId  string            `json:"initiator_id,omitempty"`
+	TargetId     string            `json:"target_id,omitempty"`
+	Media        string            `json:"media,omitempty"`
+	IsGroup      bool              `json:"is_group,omitempty"`
+	Participants []CallParticipant `json:"participants,omitempty"`
+	Reason       string            `json:"reason,omitempty"`   // ended：completed / canceled / rejected / missed / busy
+	Duration     int64             `json:"duration,omitempty"` // ended：通话时长（秒）
+	Data         json.RawMessage   `json:"data,omitempty"`
+}
+
+type CallParticipant struct {
+	UserId   string `json:"user_id"`
+	State    string `json:"state"` // invited / ringing / joined / left / rejected / missed / busy
+	DeviceId string `json:"device_id,omitempty"`
+}
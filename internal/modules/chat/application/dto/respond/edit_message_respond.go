@@ -0,0 +1,21 @@
+package respond
+
+// EditMessageRespond 编辑结果，同时作为 WS 推送帧下发给会话内所有接收者
+type EditMessageRespond struct {
+	Type        string `json:"type"`
+	MessageId   string `json:"message_id"`
+	SendId      string `json:"send_id"`
+	ReceiveId   string `json:"receive_id"`
+	Content     string `json:"content"`
+	Version     int    `json:"version"` // 已产生的历史版本数
+	EditedAt    string `json:"edited_at"`
+	LastMessage string `json:"last_message,omitempty"` // 编辑的是会话最新一条时，返回新的会话摘要
+}
+
+// MessageEditHistoryItem 消息的一个历史版本
+type MessageEditHistoryItem struct {
+	Version   int    `json:"version"`
+	Content   string `json:"content"`
+	EditorId  string `json:"editor_id"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,8 @@
+package respond
+
+// ForwardRecordRespond 合并转发的聊天记录详情，文件地址按需重新签发
+type ForwardRecordRespond struct {
+	MessageId string        `json:"message_id"`
+	Title     string        `json:"title"`
+	Items     []MessageItem `json:"items"`
+}
@@ -1,20 +1,38 @@
 package respond
 
 type MessageItem struct {
-	Uuid       string `json:"uuid"`
-	SessionId  string `json:"session_id,omitempty"`
-	SendId     string `json:"send_id"`
-	SendName   string `json:"send_name,omitempty"`
-	SendAvatar string `json:"send_avatar,omitempty"`
-	ReceiveId  string `json:"receive_id"`
-	Type       int8   `json:"type"`
-	Content    string `json:"content,omitempty"`
-	Url        string `json:"url,omitempty"`
-	FileType   string `json:"file_type,omitempty"`
-	FileName   string `json:"file_name,omitempty"`
-	FileSize   string `json:"file_size,omitempty"`
-	CreatedAt  string `json:"created_at"`
+	Uuid        string `json:"uuid"`
+	ClientMsgId string `json:"client_msg_id,omitempty"`
+	SessionId   string `json:"session_id,omitempty"`
+	SendId      string `json:"send_id"`
+	SendName    string `json:"send_name,omitempty"`
+	SendAvatar  string `json:"send_avatar,omitempty"`
+	ReceiveId   string `json:"receive_id"`
+	Type        int8   `json:"type"`
+	Content     string `json:"content,omitempty"`
+	Url         string `json:"url,omitempty"`
+	FileType    string `json:"file_type,omitempty"`
+	FileName    string `json:"file_name,omitempty"`
+	FileSize    string `json:"file_size,omitempty"`
+	FileId      string `json:"file_id,omitempty"`
+	AVdata      string `json:"av_data,omitempty"`      // 通话记录详情（JSON），仅 type=3
+	ForwardData string `json:"forward_data,omitempty"` // 合并转发的聊天记录（JSON），仅 type=4
+	CreatedAt   string `json:"created_at"`
+	IsRecalled  bool   `json:"is_recalled,omitempty"`
+	IsEdited    bool   `json:"is_edited,omitempty"`
+	EditedAt    string `json:"edited_at,omitempty"`
+	ConvId      string `json:"conv_id,omitempty"`
+	Seq         int64  `json:"seq,omitempty"`
+
+	ReplyToUuid    string `json:"reply_to_uuid,omitempty"`
+	ReplySendId    string `json:"reply_send_id,omitempty"`
+	ReplySendName  string `json:"reply_send_name,omitempty"`
+	ReplySnippet   string `json:"reply_snippet,omitempty"`    // 被引用消息摘要
+	ThreadRootUuid string `json:"thread_root_uuid,omitempty"` // 所属话题的根消息
+	ReplyCount     int64  `json:"reply_count,omitempty"`      // 群话题根消息下的回复数
 
 	MentionedUserIds []string `json:"mentioned_user_ids,omitempty"` // 被提及的用户ID列表
 	MentionAll       bool     `json:"mention_all,omitempty"`        // 是否提及所有人
+
+	Reactions []ReactionItem `json:"reactions,omitempty"` // 表情回应聚合
 }
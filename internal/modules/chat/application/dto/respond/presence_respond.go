@@ -0,0 +1,20 @@
+package respond
+
+// PresenceItem 单个用户的在线状态
+type PresenceItem struct {
+	UserId        string `json:"user_id"`
+	State         string `json:"state"`                     // online / away / offline
+	LastOfflineAt string `json:"last_offline_at,omitempty"` // 仅 offline 时返回
+}
+
+// PresenceEventRespond 订阅对象的状态变化
+type PresenceEventRespond struct {
+	Type string `json:"type"` // presence.update
+	PresenceItem
+}
+
+// PresenceListRespond 批量在线状态：订阅时的当前快照或批量查询结果
+type PresenceListRespond struct {
+	Type  string         `json:"type"` // presence.list
+	Items []PresenceItem `json:"items"`
+}
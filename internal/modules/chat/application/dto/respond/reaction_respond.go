@@ -0,0 +1,21 @@
+package respond
+
+// ReactionItem 消息上一个表情的聚合回应
+type ReactionItem struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted,omitempty"` // 当前用户是否回应过
+}
+
+// ReactionEventRespond 回应变更结果，同时作为 WS 推送帧下发给会话内所有接收者
+type ReactionEventRespond struct {
+	Type      string `json:"type"`
+	Action    string `json:"action"` // add / remove
+	MessageId string `json:"message_id"`
+	SendId    string `json:"send_id"`
+	ReceiveId string `json:"receive_id"`
+	UserId    string `json:"user_id"` // 回应者
+	Emoji     string `json:"emoji"`
+	Count     int64  `json:"count"` // 变更后该表情的回应人数
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package respond
+
+// ReadReceiptRespond 已读回执，私聊推送给对方用于推进消息状态，同时同步给自己的其他端
+type ReadReceiptRespond struct {
+	Type              string `json:"type"`
+	ReaderId          string `json:"reader_id"`
+	TargetId          string `json:"target_id"`
+	LastReadMessageId string `json:"last_read_message_id,omitempty"`
+	ReadAt            string `json:"read_at"`
+}
@@ -0,0 +1,12 @@
+package respond
+
+// RecallMessageRespond 撤回结果，同时作为 WS 推送帧下发给会话内所有接收者
+type RecallMessageRespond struct {
+	Type        string `json:"type"`
+	MessageId   string `json:"message_id"`
+	SendId      string `json:"send_id"`
+	ReceiveId   string `json:"receive_id"`
+	RecalledBy  string `json:"recalled_by"`
+	RecalledAt  string `json:"recalled_at"`
+	LastMessage string `json:"last_message,omitempty"` // 撤回的是会话最新一条时，返回新的会话摘要
+}
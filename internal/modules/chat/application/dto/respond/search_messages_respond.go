@@ -0,0 +1,13 @@
+package respond
+
+type SearchMessageItem struct {
+	MessageItem
+	Highlight string `json:"highlight"` // 命中片段，关键词以 <em></em> 包裹，其余内容已做 HTML 转义
+}
+
+type SearchMessagesRespond struct {
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Items    []SearchMessageItem `json:"items"`
+}
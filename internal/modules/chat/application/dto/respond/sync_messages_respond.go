@@ -0,0 +1,8 @@
+package respond
+
+// SyncMessagesRespond 增量同步结果，WS 重连回放时作为 sync 帧的 payload 整批下发
+type SyncMessagesRespond struct {
+	Messages []MessageItem `json:"messages"`
+	Cursor   string        `json:"cursor"`
+	HasMore  bool          `json:"has_more"`
+}
@@ -0,0 +1,8 @@
+package respond
+
+// ThreadMessagesRespond 群话题：根消息 + 按时间正序的回复
+type ThreadMessagesRespond struct {
+	Root    MessageItem   `json:"root"`
+	Replies []MessageItem `json:"replies"`
+	Total   int64         `json:"total"` // 未撤回的回复总数
+}
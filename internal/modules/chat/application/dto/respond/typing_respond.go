@@ -0,0 +1,8 @@
+package respond
+
+// TypingRespond 正在输入事件，转发给会话内的其他人
+type TypingRespond struct {
+	FromId   string `json:"from_id"`
+	TargetId string `json:"target_id"`
+	State    string `json:"state"` // start / stop
+}
@@ -0,0 +1,34 @@
+package respond
+
+// InitUploadRespond 初始化上传结果；Instant 为 true 表示秒传成功，File 即为最终文件
+type InitUploadRespond struct {
+	Instant        bool      `json:"instant"`
+	File           *FileItem `json:"file,omitempty"`
+	UploadId       string    `json:"upload_id,omitempty"`
+	ChunkSize      int64     `json:"chunk_size,omitempty"`
+	TotalChunks    int       `json:"total_chunks,omitempty"`
+	UploadedChunks []int     `json:"uploaded_chunks,omitempty"` // 断点续传时已接收的分片序号
+	ExpireAt       string    `json:"expire_at,omitempty"`
+}
+
+type UploadStatusRespond struct {
+	UploadId       string `json:"upload_id"`
+	Status         int8   `json:"status"`
+	TotalChunks    int    `json:"total_chunks"`
+	UploadedChunks []int  `json:"uploaded_chunks"`
+	FileId         string `json:"file_id,omitempty"`
+}
+
+// FileItem 文件信息，Url 为限时签名地址
+type FileItem struct {
+	FileId    string `json:"file_id"`
+	FileName  string `json:"file_name"`
+	FileType  string `json:"file_type"`
+	MimeType  string `json:"mime_type,omitempty"`
+	Size      int64  `json:"size"`
+	Sha256    string `json:"sha256"`
+	Category  string `json:"category"`
+	Url       string `json:"url"`
+	ExpireAt  string `json:"expire_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package respond
+
+// WsTicketRespond 一次性 WS 连接票据，连接时以 /wss?ticket=xxx 携带
+type WsTicketRespond struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"` // 票据有效期（秒）
+}
+
+// WsAuthRespond 连接鉴权状态，expires_at 为当前令牌过期时间（unix 秒）
+type WsAuthRespond struct {
+	ExpiresAt int64 `json:"expires_at"`
+}
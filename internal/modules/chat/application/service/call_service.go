@@ -0,0 +1,755 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"OmniLink/internal/config"
+	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
+	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	"OmniLink/pkg/util"
+	"OmniLink/pkg/ws"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"gorm.io/gorm"
+)
+
+// 通话信令动作
+const (
+	CallActionInvite   = "invite"
+	CallActionRinging  = "ringing"
+	CallActionAccept   = "accept"
+	CallActionReject   = "reject"
+	CallActionCancel   = "cancel"
+	CallActionHangup   = "hangup"
+	CallActionJoin     = "join"
+	CallActionSdp      = "sdp"
+	CallActionIce      = "ice"
+	CallActionCalling  = "calling"
+	CallActionAccepted = "accepted"
+	CallActionRejected = "rejected"
+	CallActionJoined   = "joined"
+	CallActionLeft     = "left"
+	CallActionBusy     = "busy"
+	CallActionMissed   = "missed"
+	CallActionEnded    = "ended"
+)
+
+// 参与者状态
+const (
+	callInvited  = "invited"
+	callRinging  = "ringing"
+	callJoined   = "joined"
+	callLeft     = "left"
+	callRejected = "rejected"
+	callMissed   = "missed"
+	callBusy     = "busy"
+)
+
+// 通话结果
+const (
+	callCompleted = "completed"
+	callCanceled  = "canceled"
+)
+
+const (
+	defaultCallRingTimeout     = 60 * time.Second
+	defaultCallMaxParticipants = 9
+)
+
+// CallPusher 信令下发通道，由 ws.Hub 实现
+type CallPusher interface {
+	SendFrame(userID string, frameType string, clientMsgID string, payload interface{}) error
+}
+
+type CallService interface {
+	// Handle 处理客户端上行的通话信令，错误由调用方以 error 帧返回
+	Handle(userID string, deviceID string, clientMsgID string, req chatRequest.CallSignalRequest) error
+	// Disconnected 连接断开时调用；接通所在设备掉线视为挂断，online 表示该用户在本节点是否还有其他连接
+	Disconnected(userID string, deviceID string, online bool)
+}
+
+type callParticipant struct {
+	state    string
+	deviceID string
+	joined   bool // 是否曾接通，用于通话记录
+}
+
+type activeCall struct {
+	id           string
+	initiatorID  string
+	targetID     string
+	media        string
+	isGroup      bool
+	answered     bool
+	createdAt    time.Time
+	answeredAt   time.Time
+	participants map[string]*callParticipant
+	order        []string
+	timer        *time.Timer
+}
+
+type callOutbound struct {
+	userID      string
+	clientMsgID string
+	event       *chatRespond.CallEvent
+}
+
+type callRecord struct {
+	callerID string
+	targetID string
+	content  string
+	avData   string
+}
+
+// callRecordData 通话记录详情，序列化后写入 Message.AVdata
+type callRecordData struct {
+	CallId       string   `json:"call_id"`
+	Media        string   `json:"media"`
+	Outcome      string   `json:"outcome"`
+	Duration     int64    `json:"duration"`
+	InitiatorId  string   `json:"initiator_id"`
+	Participants []string `json:"participants,omitempty"`
+	StartedAt    string   `json:"started_at"`
+	AnsweredAt   string   `json:"answered_at,omitempty"`
+	EndedAt      string   `json:"ended_at"`
+}
+
+// callServiceImpl 通话状态机。状态保存在进程内，
+// 分布式部署时同一通话的信令须由同一节点处理（可按发起人做会话粘滞）
+type callServiceImpl struct {
+	mu        sync.Mutex
+	calls     map[string]*activeCall
+	userCalls map[string]string // userID -> 所在通话（含呼叫中），用于忙线检测
+
+	contactRepo contactRepository.UserContactRepository
+	realtime    RealtimeService
+	pusher      CallPusher
+}
+
+func NewCallService(contactRepo contactRepository.UserContactRepository, realtime RealtimeService, pusher CallPusher) CallService {
+	return &callServiceImpl{
+		calls:       make(map[string]*activeCall),
+		userCalls:   make(map[string]string),
+		contactRepo: contactRepo,
+		realtime:    realtime,
+		pusher:      pusher,
+	}
+}
+
+func (s *callServiceImpl) Handle(userID string, deviceID string, clientMsgID string, req chatRequest.CallSignalRequest) error {
+	if userID == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if req.Action == CallActionInvite {
+		return s.invite(userID, deviceID, clientMsgID, req)
+	}
+	if req.CallId == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if req.Action == CallActionJoin {
+		if err := s.checkJoin(userID, req.CallId); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	c := s.calls[req.CallId]
+	if c == nil {
+		s.mu.Unlock()
+		return xerr.New(xerr.NotFound, "通话不存在或已结束")
+	}
+	if c.participants[userID] == nil && req.Action != CallActionJoin {
+		s.mu.Unlock()
+		return xerr.New(xerr.Forbidden, "不在该通话中")
+	}
+
+	var (
+		out []callOutbound
+		rec *callRecord
+		err error
+	)
+	switch req.Action {
+	case CallActionRinging:
+		out, err = s.ringingLocked(c, userID)
+	case CallActionAccept, CallActionJoin:
+		out, err = s.acceptLocked(c, userID, deviceID)
+	case CallActionReject:
+		out, rec, err = s.rejectLocked(c, userID)
+	case CallActionCancel:
+		if userID != c.initiatorID {
+			err = xerr.New(xerr.Forbidden, "只有发起人可以取消通话")
+		} else if c.answered {
+			err = xerr.New(xerr.BadRequest, "通话已接通，请挂断")
+		} else {
+			out, rec = s.endLocked(c, callCanceled)
+		}
+	case CallActionHangup:
+		out, rec = s.leaveLocked(c, userID)
+	case CallActionSdp, CallActionIce:
+		out, err = s.relayLocked(c, userID, deviceID, req)
+	default:
+		err = xerr.New(xerr.BadRequest, "不支持的通话动作: "+req.Action)
+	}
+	s.mu.Unlock()
+
+	s.flush(out)
+	s.saveRecord(rec)
+	return err
+}
+
+func (s *callServiceImpl) Disconnected(userID string, deviceID string, online bool) {
+	s.mu.Lock()
+	c := s.calls[s.userCalls[userID]]
+	if c == nil {
+		s.mu.Unlock()
+		return
+	}
+	p := c.participants[userID]
+	// 只处理接通所在的设备；呼叫中的被叫掉线交给超时处理
+	if p == nil || p.state != callJoined ||
+		(p.deviceID != "" && p.deviceID != deviceID) ||
+		(p.deviceID == "" && online) {
+		s.mu.Unlock()
+		return
+	}
+	out, rec := s.leaveLocked(c, userID)
+	s.mu.Unlock()
+
+	s.flush(out)
+	s.saveRecord(rec)
+}
+
+func (s *callServiceImpl) invite(userID string, deviceID string, clientMsgID string, req chatRequest.CallSignalRequest) error {
+	if req.TargetId == "" || req.TargetId == userID {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	media := req.Media
+	if media == "" {
+		media = "audio"
+	}
+	if media != "audio" && media != "video" {
+		return xerr.New(xerr.BadRequest, "media 仅支持 audio / video")
+	}
+
+	isGroup := strings.HasPrefix(req.TargetId, "G")
+	invitees, err := s.invitees(userID, req.TargetId, isGroup, req.InviteeIds)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	c := &activeCall{
+		id:           util.GenerateID("C"),
+		initiatorID:  userID,
+		targetID:     req.TargetId,
+		media:        media,
+		isGroup:      isGroup,
+		createdAt:    now,
+		participants: make(map[string]*callParticipant, len(invitees)+1),
+	}
+	c.participants[userID] = &callParticipant{state: callJoined, deviceID: deviceID, joined: true}
+	c.order = append(c.order, userID)
+
+	s.mu.Lock()
+	if _, busy := s.userCalls[userID]; busy {
+		s.mu.Unlock()
+		return xerr.New(xerr.Forbidden, "你正在通话中")
+	}
+	pending := 0
+	for _, uid := range invitees {
+		state := callInvited
+		if _, busy := s.userCalls[uid]; busy {
+			state = callBusy
+		} else {
+			pending++
+		}
+		c.participants[uid] = &callParticipant{state: state}
+		c.order = append(c.order, uid)
+	}
+
+	// 被叫全部忙线：不建立通话，直接记录
+	if pending == 0 {
+		out, rec := s.endLocked(c, callBusy)
+		s.mu.Unlock()
+		for i := range out {
+			if out[i].userID == userID {
+				out[i].clientMsgID = clientMsgID
+				out[i].event.Action = CallActionBusy
+			}
+		}
+		s.flush(out)
+		s.saveRecord(rec)
+		return nil
+	}
+
+	s.calls[c.id] = c
+	for uid, p := range c.participants {
+		if p.state == callJoined || p.state == callInvited {
+			s.userCalls[uid] = c.id
+		}
+	}
+	c.timer = time.AfterFunc(callRingTimeout(), func() { s.ringTimeout(c.id) })
+
+	out := []callOutbound{{userID: userID, clientMsgID: clientMsgID, event: s.eventOf(c, CallActionCalling, userID, deviceID)}}
+	for _, uid := range invitees {
+		if c.participants[uid].state == callInvited {
+			out = append(out, callOutbound{userID: uid, event: s.eventOf(c, CallActionInvite, userID, deviceID)})
+		}
+	}
+	s.mu.Unlock()
+
+	s.flush(out)
+	return nil
+}
+
+// invitees 校验发起权限并返回被叫列表
+func (s *callServiceImpl) invitees(userID string, targetID string, isGroup bool, wanted []string) ([]string, error) {
+	if !isGroup {
+		rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, targetID, 0)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, xerr.New(xerr.Forbidden, "非好友关系，无法发起通话")
+			}
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		if rel.Status != 0 {
+			return nil, xerr.New(xerr.Forbidden, "无法向对方发起通话")
+		}
+		return []string{targetID}, nil
+	}
+
+	now := time.Now()
+	rel, err := loadGroupMember(s.contactRepo, userID, targetID, now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.Forbidden, "非群成员，无法发起通话")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if err := checkNotMuted(rel, now); err != nil {
+		return nil, err
+	}
+	if rel.Status != 0 {
+		return nil, xerr.New(xerr.Forbidden, "无权发起群通话")
+	}
+	members, err := s.contactRepo.GetGroupMembers(targetID)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	memberSet := make(map[string]struct{}, len(members))
+	all := make([]string, 0, len(members))
+	for _, m := range members {
+		if m.UserId == userID || (m.Status != 0 && m.Status != 5) {
+			continue
+		}
+		memberSet[m.UserId] = struct{}{}
+		all = append(all, m.UserId)
+	}
+
+	out := all
+	if len(wanted) > 0 {
+		out = make([]string, 0, len(wanted))
+		seen := make(map[string]struct{}, len(wanted))
+		for _, uid := range wanted {
+			if _, ok := memberSet[uid]; !ok {
+				continue
+			}
+			if _, dup := seen[uid]; dup {
+				continue
+			}
+			seen[uid] = struct{}{}
+			out = append(out, uid)
+		}
+	}
+	if len(out) == 0 {
+		return nil, xerr.New(xerr.BadRequest, "没有可邀请的群成员")
+	}
+	if max := callMaxParticipants(); len(out)+1 > max {
+		return nil, xerr.New(xerr.BadRequest, fmt.Sprintf("群通话最多 %d 人", max))
+	}
+	return out, nil
+}
+
+// checkJoin 中途加入群通话前校验群成员身份（数据库查询放在锁外）
+func (s *callServiceImpl) checkJoin(userID string, callID string) error {
+	s.mu.Lock()
+	c := s.calls[callID]
+	if c == nil {
+		s.mu.Unlock()
+		return xerr.New(xerr.NotFound, "通话不存在或已结束")
+	}
+	isGroup, groupID := c.isGroup, c.targetID
+	s.mu.Unlock()
+
+	if !isGroup {
+		return nil
+	}
+	rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, groupID, 1)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return xerr.New(xerr.Forbidden, "非群成员，无法加入通话")
+		}
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	if rel.Status != 0 && rel.Status != 5 {
+		return xerr.New(xerr.Forbidden, "非群成员，无法加入通话")
+	}
+	return nil
+}
+
+func (s *callServiceImpl) ringingLocked(c *activeCall, userID string) ([]callOutbound, error) {
+	p := c.participants[userID]
+	if p.state != callInvited && p.state != callRinging {
+		return nil, nil
+	}
+	p.state = callRinging
+	return []callOutbound{{userID: c.initiatorID, event: s.eventOf(c, CallActionRinging, userID, "")}}, nil
+}
+
+func (s *callServiceImpl) acceptLocked(c *activeCall, userID string, deviceID string) ([]callOutbound, error) {
+	p := c.participants[userID]
+	switch {
+	case p == nil:
+		if !c.isGroup {
+			return nil, xerr.New(xerr.Forbidden, "不在该通话中")
+		}
+		p = &callParticipant{state: callLeft}
+		c.participants[userID] = p
+		c.order = append(c.order, userID)
+	case p.state == callJoined:
+		return nil, xerr.New(xerr.BadRequest, "已在通话中")
+	case p.state != callInvited && p.state != callRinging && !c.isGroup:
+		return nil, xerr.New(xerr.BadRequest, "通话状态已变化")
+	}
+
+	if other, busy := s.userCalls[userID]; busy && other != c.id {
+		return nil, xerr.New(xerr.Forbidden, "你正在其他通话中")
+	}
+	if c.isGroup && s.countLocked(c, callJoined) >= callMaxParticipants() {
+		return nil, xerr.New(xerr.Forbidden, "通话人数已满")
+	}
+
+	p.state = callJoined
+	p.deviceID = deviceID
+	p.joined = true
+	s.userCalls[userID] = c.id
+	if !c.answered {
+		c.answered = true
+		c.answeredAt = time.Now()
+	}
+	if s.countPendingLocked(c) == 0 && c.timer != nil {
+		c.timer.Stop()
+	}
+
+	action := CallActionAccepted
+	if c.isGroup {
+		action = CallActionJoined
+	}
+	// 发给所有已接通的人（含自己的其他设备，使其停止振铃）；mesh 下新加入者据参与者列表逐一建立连接
+	var out []callOutbound
+	for _, uid := range c.order {
+		if c.participants[uid].state == callJoined {
+			out = append(out, callOutbound{userID: uid, event: s.eventOf(c, action, userID, deviceID)})
+		}
+	}
+	return out, nil
+}
+
+func (s *callServiceImpl) rejectLocked(c *activeCall, userID string) ([]callOutbound, *callRecord, error) {
+	p := c.participants[userID]
+	if p.state != callInvited && p.state != callRinging {
+		return nil, nil, xerr.New(xerr.BadRequest, "通话状态已变化")
+	}
+	p.state = callRejected
+	s.releaseLocked(c, userID)
+	if !c.isGroup {
+		out, rec := s.endLocked(c, callRejected)
+		return out, rec, nil
+	}
+
+	out := s.broadcastLocked(c, CallActionRejected, userID, "", userID)
+	more, rec := s.checkGroupEndLocked(c)
+	return append(out, more...), rec, nil
+}
+
+// leaveLocked 挂断：呼叫中的发起人视为取消，未接听的被叫视为拒绝
+func (s *callServiceImpl) leaveLocked(c *activeCall, userID string) ([]callOutbound, *callRecord) {
+	p := c.participants[userID]
+	if p.state == callInvited || p.state == callRinging {
+		out, rec, _ := s.rejectLocked(c, userID)
+		return out, rec
+	}
+	if p.state != callJoined {
+		return nil, nil
+	}
+	if !c.isGroup {
+		if c.answered {
+			return s.endLocked(c, callCompleted)
+		}
+		return s.endLocked(c, callCanceled)
+	}
+
+	p.state = callLeft
+	s.releaseLocked(c, userID)
+	out := s.broadcastLocked(c, CallActionLeft, userID, p.deviceID, userID)
+	more, rec := s.checkGroupEndLocked(c)
+	return append(out, more...), rec
+}
+
+func (s *callServiceImpl) relayLocked(c *activeCall, userID string, deviceID string, req chatRequest.CallSignalRequest) ([]callOutbound, error) {
+	if c.participants[userID].state != callJoined {
+		return nil, xerr.New(xerr.BadRequest, "尚未接通")
+	}
+	if req.To == "" || req.To == userID || len(req.Data) == 0 {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	target := c.participants[req.To]
+	if target == nil || (target.state != callJoined && target.state != callInvited && target.state != callRinging) {
+		return nil, xerr.New(xerr.BadRequest, "对方不在通话中")
+	}
+	ev := &chatRespond.CallEvent{
+		Action:     req.Action,
+		CallId:     c.id,
+		From:       userID,
+		FromDevice: deviceID,
+		Data:       req.Data,
+	}
+	return []callOutbound{{userID: req.To, event: ev}}, nil
+}
+
+func (s *callServiceImpl) ringTimeout(callID string) {
+	s.mu.Lock()
+	c := s.calls[callID]
+	if c == nil {
+		s.mu.Unlock()
+		return
+	}
+	var out []callOutbound
+	for _, uid := range c.order {
+		p := c.participants[uid]
+		if p.state == callInvited || p.state == callRinging {
+			p.state = callMissed
+			s.releaseLocked(c, uid)
+			out = append(out, callOutbound{userID: uid, event: s.eventOf(c, CallActionMissed, "", "")})
+		}
+	}
+	var rec *callRecord
+	if !c.isGroup {
+		var more []callOutbound
+		more, rec = s.endLocked(c, callMissed)
+		out = append(out, more...)
+	} else {
+		var more []callOutbound
+		more, rec = s.checkGroupEndLocked(c)
+		out = append(out, more...)
+	}
+	s.mu.Unlock()
+
+	s.flush(out)
+	s.saveRecord(rec)
+}
+
+// checkGroupEndLocked 群通话接通后不足两人即结束；未接通时所有被叫都有结果才结束
+func (s *callServiceImpl) checkGroupEndLocked(c *activeCall) ([]callOutbound, *callRecord) {
+	joined := s.countLocked(c, callJoined)
+	if joined >= 2 {
+		return nil, nil
+	}
+	if c.answered {
+		return s.endLocked(c, callCompleted)
+	}
+	if c.participants[c.initiatorID].state != callJoined {
+		return s.endLocked(c, callCanceled)
+	}
+	if s.countPendingLocked(c) > 0 {
+		return nil, nil
+	}
+	if s.countLocked(c, callRejected) > 0 {
+		return s.endLocked(c, callRejected)
+	}
+	return s.endLocked(c, callMissed)
+}
+
+// endLocked 结束通话并通知所有参与者，返回需要落库的通话记录
+func (s *callServiceImpl) endLocked(c *activeCall, reason string) ([]callOutbound, *callRecord) {
+	now := time.Now()
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	delete(s.calls, c.id)
+	for uid := range c.participants {
+		s.releaseLocked(c, uid)
+	}
+
+	var duration int64
+	if c.answered {
+		duration = int64(now.Sub(c.answeredAt).Seconds())
+	}
+
+	out := make([]callOutbound, 0, len(c.order))
+	for _, uid := range c.order {
+		// 忙线的被叫从未收到邀请，无需通知
+		if uid != c.initiatorID && c.participants[uid].state == callBusy {
+			continue
+		}
+		ev := s.eventOf(c, CallActionEnded, "", "")
+		ev.Reason = reason
+		ev.Duration = duration
+		out = append(out, callOutbound{userID: uid, event: ev})
+	}
+
+	data := callRecordData{
+		CallId:      c.id,
+		Media:       c.media,
+		Outcome:     reason,
+		Duration:    duration,
+		InitiatorId: c.initiatorID,
+		StartedAt:   c.createdAt.Format(time.RFC3339),
+		EndedAt:     now.Format(time.RFC3339),
+	}
+	if c.answered {
+		data.AnsweredAt = c.answeredAt.Format(time.RFC3339)
+	}
+	for _, uid := range c.order {
+		if c.participants[uid].joined {
+			data.Participants = append(data.Participants, uid)
+		}
+	}
+	b, _ := json.Marshal(data)
+	return out, &callRecord{
+		callerID: c.initiatorID,
+		targetID: c.targetID,
+		content:  callRecordContent(c, reason, duration),
+		avData:   string(b),
+	}
+}
+
+// broadcastLocked 向所有已接通的参与者及 self 的全部设备推送事件
+func (s *callServiceImpl) broadcastLocked(c *activeCall, action string, from string, fromDevice string, self string) []callOutbound {
+	var out []callOutbound
+	for _, uid := range c.order {
+		if uid == self || c.participants[uid].state == callJoined {
+			out = append(out, callOutbound{userID: uid, event: s.eventOf(c, action, from, fromDevice)})
+		}
+	}
+	return out
+}
+
+func (s *callServiceImpl) releaseLocked(c *activeCall, userID string) {
+	if s.userCalls[userID] == c.id {
+		delete(s.userCalls, userID)
+	}
+}
+
+func (s *callServiceImpl) countLocked(c *activeCall, state string) int {
+	n := 0
+	for _, p := range c.participants {
+		if p.state == state {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *callServiceImpl) countPendingLocked(c *activeCall) int {
+	return s.countLocked(c, callInvited) + s.countLocked(c, callRinging)
+}
+
+func (s *callServiceImpl) eventOf(c *activeCall, action string, from string, fromDevice string) *chatRespond.CallEvent {
+	ev := &chatRespond.CallEvent{
+		Action:      action,
+		CallId:      c.id,
+		From:        from,
+		FromDevice:  fromDevice,
+		InitiatorId: c.initiatorID,
+		TargetId:    c.targetID,
+		Media:       c.media,
+		IsGroup:     c.isGroup,
+	}
+	ev.Participants = make([]chatRespond.CallParticipant, 0, len(c.order))
+	for _, uid := range c.order {
+		p := c.participants[uid]
+		ev.Participants = append(ev.Participants, chatRespond.CallParticipant{UserId: uid, State: p.state, DeviceId: p.deviceID})
+	}
+	return ev
+}
+
+func (s *callServiceImpl) flush(out []callOutbound) {
+	for _, o := range out {
+		if err := s.pusher.SendFrame(o.userID, ws.FrameCall, o.clientMsgID, o.event); err != nil {
+			zlog.Warn("push call event failed: " + err.Error())
+		}
+	}
+}
+
+// saveRecord 通话记录以发起人名义写入会话，并按普通消息推送
+func (s *callServiceImpl) saveRecord(rec *callRecord) {
+	if rec == nil || s.realtime == nil {
+		return
+	}
+	items, err := s.realtime.SendCallRecord(rec.callerID, rec.targetID, rec.content, rec.avData)
+	if err != nil {
+		zlog.Warn("save call record failed: " + err.Error())
+		return
+	}
+	for uid, item := range items {
+		_ = s.pusher.SendFrame(uid, ws.FrameMessage, "", item)
+	}
+}
+
+func callRecordContent(c *activeCall, reason string, duration int64) string {
+	label := "语音通话"
+	if c.media == "video" {
+		label = "视频通话"
+	}
+	if c.isGroup {
+		if reason == callCompleted {
+			return fmt.Sprintf("群%s已结束，时长 %s", label, formatCallDuration(duration))
+		}
+		return "群" + label + "未接通"
+	}
+	switch reason {
+	case callCompleted:
+		return label + " 通话时长 " + formatCallDuration(duration)
+	case callCanceled:
+		return label + " 已取消"
+	case callRejected:
+		return label + " 已拒绝"
+	case callBusy:
+		return label + " 对方忙线"
+	default:
+		return label + " 未接听"
+	}
+}
+
+func formatCallDuration(sec int64) string {
+	if sec >= 3600 {
+		return fmt.Sprintf("%02d:%02d:%02d", sec/3600, sec%3600/60, sec%60)
+	}
+	return fmt.Sprintf("%02d:%02d", sec/60, sec%60)
+}
+
+func callRingTimeout() time.Duration {
+	if sec := config.GetConfig().ChatConfig.CallRingSeconds; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultCallRingTimeout
+}
+
+func callMaxParticipants() int {
+	if n := config.GetConfig().ChatConfig.CallMaxParticipants; n >= 2 {
+		return n
+	}
+	return defaultCallMaxParticipants
+}
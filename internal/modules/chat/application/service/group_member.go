@@ -0,0 +1,38 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	contactEntity "OmniLink/internal/modules/contact/domain/entity"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	"OmniLink/pkg/zlog"
+)
+
+// loadGroupMember 读取用户在群内的成员关系，禁言已到期的顺带恢复为正常状态；不在群内时返回 gorm.ErrRecordNotFound
+func loadGroupMember(contactRepo contactRepository.UserContactRepository, userID string, groupID string, now time.Time) (*contactEntity.UserContact, error) {
+	rel, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, groupID, 1)
+	if err != nil {
+		return nil, err
+	}
+	clearExpiredMute(contactRepo, rel, now)
+	return rel, nil
+}
+
+// clearExpiredMute 定时禁言到期后不会有任务主动解除，由各读写路径在读取成员关系时顺带恢复
+func clearExpiredMute(contactRepo contactRepository.UserContactRepository, rel *contactEntity.UserContact, now time.Time) {
+	if rel.Status != 5 || rel.IsMuted(now) {
+		return
+	}
+	rel.Status = 0
+	rel.MuteUntil = sql.NullTime{}
+	rel.UpdateAt = now
+	if err := contactRepo.UpdateUserContact(rel); err != nil {
+		zlog.Error("reset expired group mute failed: " + err.Error())
+	}
+}
+
+// isGroupReader 正常与被禁言的成员都可以查看消息、回应表情和显示输入状态
+func isGroupReader(rel *contactEntity.UserContact) bool {
+	return rel.Status == 0 || rel.Status == 5
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"OmniLink/pkg/ws"
+	"OmniLink/pkg/zlog"
+)
+
+// FramePusher 服务端主动下发帧的通道，由 ws.Hub 实现
+type FramePusher interface {
+	SendFrame(userID string, frameType string, clientMsgID string, payload interface{}) error
+}
+
+// GroupSystemMessenger 群管理操作（转让群主、设置管理员、移除成员、禁言等）产生的系统消息，
+// 写入群会话后推送给当前成员
+type GroupSystemMessenger struct {
+	realtime RealtimeService
+	pusher   FramePusher
+}
+
+func NewGroupSystemMessenger(realtime RealtimeService, pusher FramePusher) *GroupSystemMessenger {
+	return &GroupSystemMessenger{realtime: realtime, pusher: pusher}
+}
+
+// SendGroupSystemMessage 系统消息发送失败只记录日志，不影响已完成的群管理操作
+func (m *GroupSystemMessenger) SendGroupSystemMessage(operatorID string, groupID string, content string) {
+	memberIDs, item, err := m.realtime.SendGroupSystemMessage(operatorID, groupID, content)
+	if err != nil {
+		zlog.Error("send group system message failed: " + err.Error())
+		return
+	}
+	if m.pusher == nil {
+		return
+	}
+	for _, uid := range memberIDs {
+		_ = m.pusher.SendFrame(uid, ws.FrameMessage, "", item)
+	}
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
+	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
+	chatRepository "OmniLink/internal/modules/chat/domain/repository"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxSearchKeywordRunes = 64
+	maxSearchTerms        = 5
+	highlightRadius       = 30 // 命中片段在首个命中处前后保留的字数
+)
+
+type MessageSearchService interface {
+	// SearchMessages 在调用者可见的私聊与群聊中按关键词检索文本消息
+	SearchMessages(userID string, req chatRequest.SearchMessagesRequest) (*chatRespond.SearchMessagesRespond, error)
+}
+
+type messageSearchServiceImpl struct {
+	searcher    chatRepository.MessageSearcher
+	contactRepo contactRepository.UserContactRepository
+}
+
+func NewMessageSearchService(searcher chatRepository.MessageSearcher, contactRepo contactRepository.UserContactRepository) MessageSearchService {
+	return &messageSearchServiceImpl{searcher: searcher, contactRepo: contactRepo}
+}
+
+func (s *messageSearchServiceImpl) SearchMessages(userID string, req chatRequest.SearchMessagesRequest) (*chatRespond.SearchMessagesRespond, error) {
+	if userID == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	keyword := strings.TrimSpace(req.Keyword)
+	if keyword == "" {
+		return nil, xerr.New(xerr.BadRequest, "搜索关键词不能为空")
+	}
+	if utf8.RuneCountInString(keyword) > maxSearchKeywordRunes {
+		return nil, xerr.New(xerr.BadRequest, "搜索关键词过长")
+	}
+	terms := strings.Fields(keyword)
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+
+	page := req.Page
+	pageSize := req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	q := chatRepository.MessageSearchQuery{
+		UserId:   userID,
+		Terms:    terms,
+		SenderId: req.SenderId,
+		TargetId: req.TargetId,
+		Offset:   (page - 1) * pageSize,
+		Limit:    pageSize,
+	}
+	if req.StartTime > 0 {
+		t := time.Unix(req.StartTime, 0)
+		q.Since = &t
+	}
+	if req.EndTime > 0 {
+		t := time.Unix(req.EndTime, 0)
+		q.Until = &t
+	}
+	if q.Since != nil && q.Until != nil && q.Until.Before(*q.Since) {
+		return nil, xerr.New(xerr.BadRequest, "结束时间不能早于开始时间")
+	}
+
+	groups, err := s.searchableGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+	q.Groups = groups
+	if strings.HasPrefix(req.TargetId, "G") {
+		if _, ok := groups[req.TargetId]; !ok {
+			return nil, xerr.New(xerr.Forbidden, "非群成员，无权查看消息")
+		}
+	}
+
+	msgs, total, err := s.searcher.Search(context.Background(), q)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	items := make([]chatRespond.SearchMessageItem, 0, len(msgs))
+	for i := range msgs {
+		m := &msgs[i]
+		item := chatRespond.MessageItem{
+			Uuid:       m.Uuid,
+			SessionId:  m.SessionId,
+			SendId:     m.SendId,
+			SendName:   m.SendName,
+			SendAvatar: m.SendAvatar,
+			ReceiveId:  m.ReceiveId,
+			Type:       m.Type,
+			Content:    m.Content,
+			CreatedAt:  m.CreatedAt.Format(time.RFC3339),
+			ConvId:     m.ConvId,
+			Seq:        m.Seq,
+		}
+		markEdited(&item, m)
+		items = append(items, chatRespond.SearchMessageItem{
+			MessageItem: item,
+			Highlight:   highlightSnippet(m.Content, terms, highlightRadius),
+		})
+	}
+	return &chatRespond.SearchMessagesRespond{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Items:    items,
+	}, nil
+}
+
+// searchableGroups 仍在群内（正常或被禁言）的群才可检索，且只检索入群之后的消息
+func (s *messageSearchServiceImpl) searchableGroups(userID string) (map[string]time.Time, error) {
+	contacts, err := s.contactRepo.GetUserContactsByUserID(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	groups := make(map[string]time.Time)
+	for _, c := range contacts {
+		if c.ContactType != 1 || (c.Status != 0 && c.Status != 5) {
+			continue
+		}
+		groups[c.ContactId] = c.JoinTime()
+	}
+	return groups, nil
+}
+
+// highlightSnippet 截取首个命中处附近的片段，关键词（忽略大小写）以 <em></em> 包裹，其余内容做 HTML 转义
+func highlightSnippet(content string, terms []string, radius int) string {
+	text := []rune(content)
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// 标记所有命中位置
+	marked := make([]bool, len(text))
+	first := -1
+	for _, t := range terms {
+		sub := []rune(t)
+		for i, r := range sub {
+			sub[i] = unicode.ToLower(r)
+		}
+		if len(sub) == 0 {
+			continue
+		}
+		for i := 0; i+len(sub) <= len(lower); i++ {
+			if string(lower[i:i+len(sub)]) != string(sub) {
+				continue
+			}
+			for j := i; j < i+len(sub); j++ {
+				marked[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start := first - radius
+	if start < 0 {
+		start = 0
+	}
+	end := first + radius*2
+	if end > len(text) {
+		end = len(text)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		seg := html.EscapeString(string(text[i:j]))
+		if marked[i] {
+			b.WriteString("<em>")
+			b.WriteString(seg)
+			b.WriteString("</em>")
+		} else {
+			b.WriteString(seg)
+		}
+		i = j
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -1,13 +1,19 @@
 package service
 
 import (
+	"OmniLink/internal/config"
 	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
 	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
+	chatEntity "OmniLink/internal/modules/chat/domain/entity"
 	chatRepository "OmniLink/internal/modules/chat/domain/repository"
 	contactRepository "OmniLink/internal/modules/contact/domain/repository"
 	"OmniLink/pkg/xerr"
 	"OmniLink/pkg/zlog"
+	"encoding/json"
 	"errors"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -16,19 +22,33 @@ import (
 type MessageService interface {
 	GetMessageList(req chatRequest.GetMessageListRequest) ([]chatRespond.MessageItem, error)
 	GetGroupMessageList(req chatRequest.GetGroupMessageListRequest, callerID string) ([]chatRespond.MessageItem, error)
+	// SyncMessages 按序列号游标增量拉取用户所有会话中错过的消息
+	SyncMessages(userID string, req chatRequest.SyncMessagesRequest) (*chatRespond.SyncMessagesRespond, error)
+	// GetThreadMessages 返回群话题根消息及其下的回复，传入话题内任意一条回复时按其根消息查询
+	GetThreadMessages(callerID string, req chatRequest.GetThreadMessagesRequest) (*chatRespond.ThreadMessagesRespond, error)
+	// GetThreadCount 批量查询群话题根消息下的回复数
+	GetThreadCount(callerID string, req chatRequest.GetThreadCountRequest) (map[string]int64, error)
+	// GetForwardRecord 展开合并转发的聊天记录，能看到该条转发消息即可查看
+	GetForwardRecord(callerID string, req chatRequest.GetForwardRecordRequest) (*chatRespond.ForwardRecordRespond, error)
+	// GetEditHistory 返回消息的历史版本，仅会话参与者可查看
+	GetEditHistory(callerID string, req chatRequest.GetEditHistoryRequest) ([]chatRespond.MessageEditHistoryItem, error)
 }
 
 type messageServiceImpl struct {
-	messageRepo chatRepository.MessageRepository
-	contactRepo contactRepository.UserContactRepository
-	mentionRepo chatRepository.MessageMentionRepository
+	messageRepo  chatRepository.MessageRepository
+	contactRepo  contactRepository.UserContactRepository
+	mentionRepo  chatRepository.MessageMentionRepository
+	reactionRepo chatRepository.MessageReactionRepository
+	files        FileResolver
 }
 
-func NewMessageService(messageRepo chatRepository.MessageRepository, contactRepo contactRepository.UserContactRepository, mentionRepo chatRepository.MessageMentionRepository) MessageService {
+func NewMessageService(messageRepo chatRepository.MessageRepository, contactRepo contactRepository.UserContactRepository, mentionRepo chatRepository.MessageMentionRepository, reactionRepo chatRepository.MessageReactionRepository, files FileResolver) MessageService {
 	return &messageServiceImpl{
-		messageRepo: messageRepo,
-		contactRepo: contactRepo,
-		mentionRepo: mentionRepo,
+		messageRepo:  messageRepo,
+		contactRepo:  contactRepo,
+		mentionRepo:  mentionRepo,
+		reactionRepo: reactionRepo,
+		files:        files,
 	}
 }
 
@@ -79,22 +99,32 @@ func (s *messageServiceImpl) GetMessageList(req chatRequest.GetMessageListReques
 	out := make([]chatRespond.MessageItem, 0, len(msgs))
 	for i := len(msgs) - 1; i >= 0; i-- {
 		m := msgs[i]
-		out = append(out, chatRespond.MessageItem{
-			Uuid:       m.Uuid,
-			SessionId:  m.SessionId,
-			SendId:     m.SendId,
-			SendName:   m.SendName,
-			SendAvatar: m.SendAvatar,
-			ReceiveId:  m.ReceiveId,
-			Type:       m.Type,
-			Content:    m.Content,
-			Url:        m.Url,
-			FileType:   m.FileType,
-			FileName:   m.FileName,
-			FileSize:   m.FileSize,
-			CreatedAt:  m.CreatedAt.Format(time.RFC3339),
-		})
+		item := chatRespond.MessageItem{
+			Uuid:        m.Uuid,
+			SessionId:   m.SessionId,
+			SendId:      m.SendId,
+			SendName:    m.SendName,
+			SendAvatar:  m.SendAvatar,
+			ReceiveId:   m.ReceiveId,
+			Type:        m.Type,
+			Content:     m.Content,
+			Url:         m.Url,
+			FileType:    m.FileType,
+			FileName:    m.FileName,
+			FileSize:    m.FileSize,
+			AVdata:      m.AVdata,
+			ForwardData: m.ForwardData,
+			CreatedAt:   m.CreatedAt.Format(time.RFC3339),
+			ConvId:      m.ConvId,
+			Seq:         m.Seq,
+		}
+		s.fillFileURL(&item, m.FileId)
+		markEdited(&item, &m)
+		fillReply(&item, &m)
+		maskRecalled(&item, m.IsRecalled)
+		out = append(out, item)
 	}
+	s.fillReactions(req.UserOneId, out)
 
 	return out, nil
 }
@@ -105,7 +135,7 @@ func (s *messageServiceImpl) GetGroupMessageList(req chatRequest.GetGroupMessage
 	}
 
 	// 权限检查: 是否群成员
-	rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(callerID, req.GroupId, 1)
+	rel, err := loadGroupMember(s.contactRepo, callerID, req.GroupId, time.Now())
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, xerr.New(xerr.Forbidden, "非群成员，无权查看消息")
@@ -113,7 +143,7 @@ func (s *messageServiceImpl) GetGroupMessageList(req chatRequest.GetGroupMessage
 		zlog.Error(err.Error())
 		return nil, xerr.ErrServerError
 	}
-	if rel.Status != 0 {
+	if !isGroupReader(rel) {
 		return nil, xerr.New(xerr.Forbidden, "非正常群成员状态")
 	}
 
@@ -135,6 +165,18 @@ func (s *messageServiceImpl) GetGroupMessageList(req chatRequest.GetGroupMessage
 		return nil, xerr.ErrServerError
 	}
 
+	// 仓储按时间倒序返回，展示按时间正序
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	out := s.groupMessageItems(msgs)
+	s.fillReplyCounts(req.GroupId, out)
+	s.fillReactions(callerID, out)
+	return out, nil
+}
+
+// groupMessageItems 按传入顺序构造群消息条目，并批量补齐 @ 信息
+func (s *messageServiceImpl) groupMessageItems(msgs []chatEntity.Message) []chatRespond.MessageItem {
 	// 获取 Mentions
 	var msgUUIDs []string
 	for _, m := range msgs {
@@ -143,9 +185,7 @@ func (s *messageServiceImpl) GetGroupMessageList(req chatRequest.GetGroupMessage
 	mentionsMap, _ := s.mentionRepo.GetMentionsByMessageUUIDs(msgUUIDs)
 
 	out := make([]chatRespond.MessageItem, 0, len(msgs))
-	for i := len(msgs) - 1; i >= 0; i-- {
-		m := msgs[i]
-
+	for _, m := range msgs {
 		var mentionedUserIds []string
 		var mentionAll bool
 		if mentions, ok := mentionsMap[m.Uuid]; ok {
@@ -158,7 +198,102 @@ func (s *messageServiceImpl) GetGroupMessageList(req chatRequest.GetGroupMessage
 			}
 		}
 
-		out = append(out, chatRespond.MessageItem{
+		item := chatRespond.MessageItem{
+			Uuid:             m.Uuid,
+			SessionId:        m.SessionId,
+			SendId:           m.SendId,
+			SendName:         m.SendName,
+			SendAvatar:       m.SendAvatar,
+			ReceiveId:        m.ReceiveId,
+			Type:             m.Type,
+			Content:          m.Content,
+			Url:              m.Url,
+			FileType:         m.FileType,
+			FileName:         m.FileName,
+			FileSize:         m.FileSize,
+			AVdata:           m.AVdata,
+			ForwardData:      m.ForwardData,
+			CreatedAt:        m.CreatedAt.Format(time.RFC3339),
+			ConvId:           m.ConvId,
+			Seq:              m.Seq,
+			MentionedUserIds: mentionedUserIds,
+			MentionAll:       mentionAll,
+		}
+		s.fillFileURL(&item, m.FileId)
+		markEdited(&item, &m)
+		fillReply(&item, &m)
+		maskRecalled(&item, m.IsRecalled)
+		out = append(out, item)
+	}
+	return out
+}
+
+func (s *messageServiceImpl) SyncMessages(userID string, req chatRequest.SyncMessagesRequest) (*chatRespond.SyncMessagesRespond, error) {
+	if userID == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	limit := req.Limit
+	maxLimit := config.GetConfig().ChatConfig.SyncBatchSize
+	if maxLimit <= 0 {
+		maxLimit = 200
+	}
+	if limit <= 0 || limit > maxLimit {
+		limit = maxLimit
+	}
+
+	// 只同步当前仍有权限的会话：正常好友与正常/禁言状态的群
+	contacts, err := s.contactRepo.GetUserContactsByUserID(userID)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	given := parseSyncCursor(req.Cursor)
+	cursors := make(map[string]int64, len(contacts))
+	for _, c := range contacts {
+		var convID string
+		switch {
+		case c.ContactType == 0 && c.Status == 0:
+			convID = chatEntity.ConvIdOf(userID, c.ContactId)
+		case c.ContactType == 1 && (c.Status == 0 || c.Status == 5):
+			convID = c.ContactId
+		default:
+			continue
+		}
+		cursors[convID] = given[convID]
+	}
+
+	msgs, err := s.messageRepo.ListAfterSeq(cursors, limit+1)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	hasMore := len(msgs) > limit
+	if hasMore {
+		msgs = msgs[:limit]
+	}
+
+	var groupMsgUUIDs []string
+	for _, m := range msgs {
+		if strings.HasPrefix(m.ReceiveId, "G") {
+			groupMsgUUIDs = append(groupMsgUUIDs, m.Uuid)
+		}
+	}
+	mentionsMap, _ := s.mentionRepo.GetMentionsByMessageUUIDs(groupMsgUUIDs)
+
+	out := make([]chatRespond.MessageItem, 0, len(msgs))
+	for _, m := range msgs {
+		var mentionedUserIds []string
+		var mentionAll bool
+		for _, mention := range mentionsMap[m.Uuid] {
+			if mention.MentionType == 1 {
+				mentionAll = true
+			} else {
+				mentionedUserIds = append(mentionedUserIds, mention.MentionedUserId)
+			}
+		}
+
+		item := chatRespond.MessageItem{
 			Uuid:             m.Uuid,
 			SessionId:        m.SessionId,
 			SendId:           m.SendId,
@@ -171,10 +306,378 @@ func (s *messageServiceImpl) GetGroupMessageList(req chatRequest.GetGroupMessage
 			FileType:         m.FileType,
 			FileName:         m.FileName,
 			FileSize:         m.FileSize,
+			AVdata:           m.AVdata,
+			ForwardData:      m.ForwardData,
 			CreatedAt:        m.CreatedAt.Format(time.RFC3339),
+			ConvId:           m.ConvId,
+			Seq:              m.Seq,
 			MentionedUserIds: mentionedUserIds,
 			MentionAll:       mentionAll,
+		}
+		s.fillFileURL(&item, m.FileId)
+		markEdited(&item, &m)
+		fillReply(&item, &m)
+		maskRecalled(&item, m.IsRecalled)
+		out = append(out, item)
+
+		if m.Seq > cursors[m.ConvId] {
+			cursors[m.ConvId] = m.Seq
+		}
+	}
+
+	return &chatRespond.SyncMessagesRespond{
+		Messages: out,
+		Cursor:   formatSyncCursor(cursors),
+		HasMore:  hasMore,
+	}, nil
+}
+
+func (s *messageServiceImpl) GetThreadMessages(callerID string, req chatRequest.GetThreadMessagesRequest) (*chatRespond.ThreadMessagesRespond, error) {
+	if callerID == "" || req.MessageId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	root, err := s.messageRepo.GetByUUID(req.MessageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "消息不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if !strings.HasPrefix(root.ReceiveId, "G") {
+		return nil, xerr.New(xerr.BadRequest, "仅群聊支持话题")
+	}
+	if err := s.checkGroupReader(callerID, root.ReceiveId); err != nil {
+		return nil, err
+	}
+	if root.ThreadRootUuid != "" {
+		root, err = s.messageRepo.GetByUUID(root.ThreadRootUuid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, xerr.New(xerr.NotFound, "话题不存在")
+			}
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+	}
+
+	page := req.Page
+	pageSize := req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 200 {
+		pageSize = 200
+	}
+
+	replies, err := s.messageRepo.ListThreadReplies(root.Uuid, page, pageSize)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	counts, err := s.messageRepo.CountThreadReplies(root.ReceiveId, []string{root.Uuid})
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	items := s.groupMessageItems(append([]chatEntity.Message{*root}, replies...))
+	items[0].ReplyCount = counts[root.Uuid]
+	s.fillReactions(callerID, items)
+	return &chatRespond.ThreadMessagesRespond{
+		Root:    items[0],
+		Replies: items[1:],
+		Total:   counts[root.Uuid],
+	}, nil
+}
+
+func (s *messageServiceImpl) GetThreadCount(callerID string, req chatRequest.GetThreadCountRequest) (map[string]int64, error) {
+	if callerID == "" || req.GroupId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if len(req.MessageIds) > 200 {
+		return nil, xerr.New(xerr.BadRequest, "单次最多查询 200 条")
+	}
+	if err := s.checkGroupReader(callerID, req.GroupId); err != nil {
+		return nil, err
+	}
+
+	counts, err := s.messageRepo.CountThreadReplies(req.GroupId, req.MessageIds)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	out := make(map[string]int64, len(req.MessageIds))
+	for _, id := range req.MessageIds {
+		out[id] = counts[id]
+	}
+	return out, nil
+}
+
+func (s *messageServiceImpl) GetForwardRecord(callerID string, req chatRequest.GetForwardRecordRequest) (*chatRespond.ForwardRecordRespond, error) {
+	if callerID == "" || req.MessageId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	msg, err := s.messageRepo.GetByUUID(req.MessageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "消息不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if strings.HasPrefix(msg.ReceiveId, "G") {
+		if err := s.checkGroupReader(callerID, msg.ReceiveId); err != nil {
+			return nil, err
+		}
+	} else if msg.SendId != callerID && msg.ReceiveId != callerID {
+		return nil, xerr.New(xerr.Forbidden, "无权查看该消息")
+	}
+	if msg.Type != 4 {
+		return nil, xerr.New(xerr.BadRequest, "不是合并转发的聊天记录")
+	}
+	if msg.IsRecalled {
+		return nil, xerr.New(xerr.BadRequest, "消息已撤回")
+	}
+
+	var record forwardRecord
+	if err := json.Unmarshal([]byte(msg.ForwardData), &record); err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	items := make([]chatRespond.MessageItem, 0, len(record.Items))
+	for _, it := range record.Items {
+		item := chatRespond.MessageItem{
+			Uuid:        it.MessageUuid,
+			SendId:      it.SendId,
+			SendName:    it.SendName,
+			SendAvatar:  it.SendAvatar,
+			Type:        it.Type,
+			Content:     it.Content,
+			FileType:    it.FileType,
+			FileName:    it.FileName,
+			FileSize:    it.FileSize,
+			AVdata:      it.AVdata,
+			ForwardData: it.ForwardData,
+			CreatedAt:   it.CreatedAt,
+		}
+		s.fillFileURL(&item, it.FileId)
+		items = append(items, item)
+	}
+	return &chatRespond.ForwardRecordRespond{
+		MessageId: msg.Uuid,
+		Title:     record.Title,
+		Items:     items,
+	}, nil
+}
+
+// checkGroupReader 群成员（含被禁言）可查看群消息
+func (s *messageServiceImpl) checkGroupReader(callerID string, groupID string) error {
+	rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(callerID, groupID, 1)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return xerr.New(xerr.Forbidden, "非群成员，无权查看消息")
+		}
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	if rel.Status != 0 && rel.Status != 5 {
+		return xerr.New(xerr.Forbidden, "非群成员，无权查看消息")
+	}
+	return nil
+}
+
+// fillReactions 批量补齐表情回应聚合，并标记当前用户回应过的表情
+func (s *messageServiceImpl) fillReactions(userID string, items []chatRespond.MessageItem) {
+	if len(items) == 0 || s.reactionRepo == nil {
+		return
+	}
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		if !item.IsRecalled {
+			ids = append(ids, item.Uuid)
+		}
+	}
+	counts, err := s.reactionRepo.Aggregate(ids)
+	if err != nil {
+		zlog.Error(err.Error())
+		return
+	}
+	if len(counts) == 0 {
+		return
+	}
+	mine, err := s.reactionRepo.ListByUser(ids, userID)
+	if err != nil {
+		zlog.Error(err.Error())
+	}
+	reacted := make(map[string]struct{}, len(mine))
+	for _, r := range mine {
+		reacted[r.MessageUuid+"|"+r.Emoji] = struct{}{}
+	}
+
+	byMessage := make(map[string][]chatRespond.ReactionItem)
+	for _, c := range counts {
+		_, ok := reacted[c.MessageUuid+"|"+c.Emoji]
+		byMessage[c.MessageUuid] = append(byMessage[c.MessageUuid], chatRespond.ReactionItem{
+			Emoji:   c.Emoji,
+			Count:   c.Count,
+			Reacted: ok,
+		})
+	}
+	for i := range items {
+		items[i].Reactions = byMessage[items[i].Uuid]
+	}
+}
+
+// fillReplyCounts 为群消息列表中的话题根消息补齐回复数
+func (s *messageServiceImpl) fillReplyCounts(groupID string, items []chatRespond.MessageItem) {
+	if len(items) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Uuid)
+	}
+	counts, err := s.messageRepo.CountThreadReplies(groupID, ids)
+	if err != nil {
+		zlog.Error(err.Error())
+		return
+	}
+	for i := range items {
+		items[i].ReplyCount = counts[items[i].Uuid]
+	}
+}
+
+func (s *messageServiceImpl) GetEditHistory(callerID string, req chatRequest.GetEditHistoryRequest) ([]chatRespond.MessageEditHistoryItem, error) {
+	if callerID == "" || req.MessageId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	msg, err := s.messageRepo.GetByUUID(req.MessageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "消息不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	if strings.HasPrefix(msg.ReceiveId, "G") {
+		rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(callerID, msg.ReceiveId, 1)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, xerr.New(xerr.Forbidden, "非群成员，无权查看消息")
+			}
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		if rel.Status != 0 && rel.Status != 5 {
+			return nil, xerr.New(xerr.Forbidden, "非群成员，无权查看消息")
+		}
+	} else if msg.SendId != callerID && msg.ReceiveId != callerID {
+		return nil, xerr.New(xerr.Forbidden, "无权查看该消息")
+	}
+	// 撤回后历史版本一并隐藏
+	if msg.IsRecalled {
+		return []chatRespond.MessageEditHistoryItem{}, nil
+	}
+
+	history, err := s.messageRepo.ListEditHistory(msg.Uuid)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	out := make([]chatRespond.MessageEditHistoryItem, 0, len(history))
+	for _, h := range history {
+		out = append(out, chatRespond.MessageEditHistoryItem{
+			Version:   h.Version,
+			Content:   h.Content,
+			EditorId:  h.EditorId,
+			CreatedAt: h.CreatedAt.Format(time.RFC3339),
 		})
 	}
 	return out, nil
 }
+
+// fillFileURL 引用了上传文件的消息，下发时签发新的限时下载地址
+func (s *messageServiceImpl) fillFileURL(item *chatRespond.MessageItem, fileID string) {
+	if fileID == "" || s.files == nil {
+		return
+	}
+	item.FileId = fileID
+	item.Url = s.files.FileURL(fileID)
+}
+
+// parseSyncCursor 解析 "conv_id:seq,conv_id:seq" 格式的续传游标，非法片段直接忽略
+func parseSyncCursor(cursor string) map[string]int64 {
+	out := make(map[string]int64)
+	for _, part := range strings.Split(cursor, ",") {
+		idx := strings.LastIndex(part, ":")
+		if idx <= 0 {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSpace(part[idx+1:]), 10, 64)
+		if err != nil || seq < 0 {
+			continue
+		}
+		out[strings.TrimSpace(part[:idx])] = seq
+	}
+	return out
+}
+
+func formatSyncCursor(cursors map[string]int64) string {
+	keys := make([]string, 0, len(cursors))
+	for k := range cursors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+":"+strconv.FormatInt(cursors[k], 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// maskRecalled 已撤回的消息只保留元信息，不再向客户端下发原文与附件
+func maskRecalled(item *chatRespond.MessageItem, recalled bool) {
+	if item == nil || !recalled {
+		return
+	}
+	item.IsRecalled = true
+	item.Content = ""
+	item.Url = ""
+	item.FileType = ""
+	item.FileName = ""
+	item.FileSize = ""
+	item.FileId = ""
+	item.ForwardData = ""
+	item.IsEdited = false
+	item.EditedAt = ""
+}
+
+// fillReply 引用回复与话题信息
+func fillReply(item *chatRespond.MessageItem, m *chatEntity.Message) {
+	if item == nil || m == nil {
+		return
+	}
+	item.ReplyToUuid = m.ReplyToUuid
+	item.ReplySendId = m.ReplySendId
+	item.ReplySendName = m.ReplySendName
+	item.ReplySnippet = m.ReplySnippet
+	item.ThreadRootUuid = m.ThreadRootUuid
+}
+
+func markEdited(item *chatRespond.MessageItem, m *chatEntity.Message) {
+	if item == nil || m == nil || !m.IsEdited {
+		return
+	}
+	item.IsEdited = true
+	if m.EditedAt.Valid {
+		item.EditedAt = m.EditedAt.Time.Format(time.RFC3339)
+	}
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
+	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	userRepository "OmniLink/internal/modules/user/domain/repository"
+	"OmniLink/pkg/ws"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+)
+
+// presence 帧动作
+const (
+	PresenceActionSet         = "set"
+	PresenceActionSubscribe   = "subscribe"
+	PresenceActionUnsubscribe = "unsubscribe"
+)
+
+const (
+	maxPresenceTargets = 500 // 单次订阅 / 查询的最多用户数
+	presenceDeadline   = 3 * time.Second
+)
+
+// PresencePusher 状态变化下发通道，由 ws.Hub 实现
+type PresencePusher interface {
+	SendFrame(userID string, frameType string, clientMsgID string, payload interface{}) error
+}
+
+type PresenceService interface {
+	// Connected 连接建立后调用，新连接默认 online
+	Connected(userID string, connID string)
+	// Disconnected 连接断开后调用；用户所有连接都断开后取消其订阅
+	Disconnected(userID string, connID string)
+	// Handle 处理客户端上行的 presence 帧；subscribe 返回订阅对象的当前状态，其余动作返回 nil
+	Handle(userID string, connID string, req chatRequest.PresenceRequest) (*chatRespond.PresenceListRespond, error)
+	// GetOnlineStatus 批量查询好友在线状态
+	GetOnlineStatus(userID string, req chatRequest.GetOnlineStatusRequest) (*chatRespond.PresenceListRespond, error)
+}
+
+// presenceServiceImpl 在 ws.Hub 之上维护多端在线状态：
+// 每个连接单独记录 online / away，对外按 online > away > offline 聚合，聚合结果变化时推给订阅方
+type presenceServiceImpl struct {
+	// mu 保护本节点的连接状态，用于定期刷新存活时间
+	mu    sync.Mutex
+	local map[string]map[string]string // userID -> connID -> 状态
+
+	store       ws.PresenceStore
+	contactRepo contactRepository.UserContactRepository
+	userRepo    userRepository.UserInfoRepository
+	pusher      PresencePusher
+}
+
+func NewPresenceService(store ws.PresenceStore, contactRepo contactRepository.UserContactRepository, userRepo userRepository.UserInfoRepository, pusher PresencePusher) PresenceService {
+	s := &presenceServiceImpl{
+		local:       make(map[string]map[string]string),
+		store:       store,
+		contactRepo: contactRepo,
+		userRepo:    userRepo,
+		pusher:      pusher,
+	}
+	go s.refreshLoop()
+	return s
+}
+
+func (s *presenceServiceImpl) Connected(userID string, connID string) {
+	if userID == "" || connID == "" {
+		return
+	}
+	s.mu.Lock()
+	set := s.local[userID]
+	if set == nil {
+		set = make(map[string]string)
+		s.local[userID] = set
+	}
+	set[connID] = ws.PresenceOnline
+	s.mu.Unlock()
+
+	s.update(userID, func(ctx context.Context) error {
+		return s.store.SetState(ctx, userID, connID, ws.PresenceOnline)
+	})
+}
+
+func (s *presenceServiceImpl) Disconnected(userID string, connID string) {
+	if userID == "" || connID == "" {
+		return
+	}
+	s.mu.Lock()
+	if set := s.local[userID]; set != nil {
+		delete(set, connID)
+		if len(set) == 0 {
+			delete(s.local, userID)
+		}
+	}
+	s.mu.Unlock()
+
+	after := s.update(userID, func(ctx context.Context) error {
+		return s.store.RemoveConn(ctx, userID, connID)
+	})
+	if after != ws.PresenceOffline {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), presenceDeadline)
+	defer cancel()
+	if err := s.store.Unwatch(ctx, userID, nil); err != nil {
+		zlog.Error("presence unwatch failed: " + err.Error())
+	}
+}
+
+func (s *presenceServiceImpl) Handle(userID string, connID string, req chatRequest.PresenceRequest) (*chatRespond.PresenceListRespond, error) {
+	if userID == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	switch req.Action {
+	case PresenceActionSet:
+		return nil, s.setState(userID, connID, req.State)
+	case PresenceActionSubscribe:
+		return s.subscribe(userID, req.UserIds)
+	case PresenceActionUnsubscribe:
+		ctx, cancel := context.WithTimeout(context.Background(), presenceDeadline)
+		defer cancel()
+		if err := s.store.Unwatch(ctx, userID, req.UserIds); err != nil {
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		return nil, nil
+	default:
+		return nil, xerr.New(xerr.BadRequest, "不支持的 presence 动作: "+req.Action)
+	}
+}
+
+func (s *presenceServiceImpl) GetOnlineStatus(userID string, req chatRequest.GetOnlineStatusRequest) (*chatRespond.PresenceListRespond, error) {
+	if userID == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if len(req.UserIds) > maxPresenceTargets {
+		return nil, xerr.New(xerr.BadRequest, "查询人数过多")
+	}
+	ids, err := s.visibleContacts(userID, req.UserIds)
+	if err != nil {
+		return nil, err
+	}
+	return s.snapshot(ids)
+}
+
+func (s *presenceServiceImpl) setState(userID string, connID string, state string) error {
+	if state != ws.PresenceOnline && state != ws.PresenceAway {
+		return xerr.New(xerr.BadRequest, "state 仅支持 online / away")
+	}
+	s.mu.Lock()
+	set := s.local[userID]
+	if set == nil || set[connID] == "" {
+		s.mu.Unlock()
+		return xerr.New(xerr.BadRequest, "连接未登记")
+	}
+	if set[connID] == state {
+		s.mu.Unlock()
+		return nil
+	}
+	set[connID] = state
+	s.mu.Unlock()
+
+	s.update(userID, func(ctx context.Context) error {
+		return s.store.SetState(ctx, userID, connID, state)
+	})
+	return nil
+}
+
+func (s *presenceServiceImpl) subscribe(userID string, targetIDs []string) (*chatRespond.PresenceListRespond, error) {
+	if len(targetIDs) == 0 {
+		return nil, xerr.New(xerr.BadRequest, "user_ids 不能为空")
+	}
+	if len(targetIDs) > maxPresenceTargets {
+		return nil, xerr.New(xerr.BadRequest, "订阅人数过多")
+	}
+	ids, err := s.visibleContacts(userID, targetIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), presenceDeadline)
+		defer cancel()
+		if err := s.store.Watch(ctx, userID, ids); err != nil {
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+	}
+	return s.snapshot(ids)
+}
+
+// visibleContacts 只保留正常状态的好友；wanted 为空时返回全部好友。拉黑或被拉黑的一方看不到状态
+func (s *presenceServiceImpl) visibleContacts(userID string, wanted []string) ([]string, error) {
+	contacts, err := s.contactRepo.GetUserContactsByUserID(userID)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	friends := make(map[string]struct{}, len(contacts))
+	all := make([]string, 0, len(contacts))
+	for _, c := range contacts {
+		if c.ContactType != 0 || c.Status != 0 {
+			continue
+		}
+		if _, dup := friends[c.ContactId]; dup {
+			continue
+		}
+		friends[c.ContactId] = struct{}{}
+		all = append(all, c.ContactId)
+	}
+	if len(wanted) == 0 {
+		return all, nil
+	}
+
+	out := make([]string, 0, len(wanted))
+	seen := make(map[string]struct{}, len(wanted))
+	for _, uid := range wanted {
+		if _, ok := friends[uid]; !ok {
+			continue
+		}
+		if _, dup := seen[uid]; dup {
+			continue
+		}
+		seen[uid] = struct{}{}
+		out = append(out, uid)
+	}
+	return out, nil
+}
+
+// snapshot 查询当前状态，离线用户附带最近离线时间
+func (s *presenceServiceImpl) snapshot(userIDs []string) (*chatRespond.PresenceListRespond, error) {
+	out := &chatRespond.PresenceListRespond{Type: "presence.list", Items: make([]chatRespond.PresenceItem, 0, len(userIDs))}
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), presenceDeadline)
+	defer cancel()
+	states, err := s.store.States(ctx, userIDs)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	var offline []string
+	for _, uid := range userIDs {
+		if states[uid] == ws.PresenceOffline {
+			offline = append(offline, uid)
+		}
+	}
+	lastOffline := make(map[string]string, len(offline))
+	if len(offline) > 0 {
+		users, err := s.userRepo.GetBatchUserInfoWithoutPassword(offline)
+		if err != nil {
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		for _, u := range users {
+			if u.LastOfflineAt.Valid {
+				lastOffline[u.Uuid] = u.LastOfflineAt.Time.Format(time.RFC3339)
+			}
+		}
+	}
+
+	for _, uid := range userIDs {
+		out.Items = append(out.Items, chatRespond.PresenceItem{
+			UserId:        uid,
+			State:         states[uid],
+			LastOfflineAt: lastOffline[uid],
+		})
+	}
+	return out, nil
+}
+
+// update 执行状态写入，聚合状态发生变化时推送给订阅方，返回写入后的聚合状态
+func (s *presenceServiceImpl) update(userID string, write func(ctx context.Context) error) string {
+	ctx, cancel := context.WithTimeout(context.Background(), presenceDeadline)
+	defer cancel()
+
+	before, err := s.store.States(ctx, []string{userID})
+	if err != nil {
+		zlog.Error("presence read failed: " + err.Error())
+		return ""
+	}
+	if err := write(ctx); err != nil {
+		zlog.Error("presence write failed: " + err.Error())
+		return ""
+	}
+	after, err := s.store.States(ctx, []string{userID})
+	if err != nil {
+		zlog.Error("presence read failed: " + err.Error())
+		return ""
+	}
+	if before[userID] == after[userID] {
+		return after[userID]
+	}
+
+	watchers, err := s.store.Watchers(ctx, userID)
+	if err != nil {
+		zlog.Error("presence watchers lookup failed: " + err.Error())
+		return after[userID]
+	}
+	watchers = s.visibleWatchers(ctx, userID, watchers)
+	ev := &chatRespond.PresenceEventRespond{
+		Type:         "presence.update",
+		PresenceItem: chatRespond.PresenceItem{UserId: userID, State: after[userID]},
+	}
+	if ev.State == ws.PresenceOffline {
+		ev.LastOfflineAt = time.Now().Format(time.RFC3339)
+	}
+	for _, uid := range watchers {
+		if err := s.pusher.SendFrame(uid, ws.FramePresence, "", ev); err != nil {
+			zlog.Warn("push presence failed: " + err.Error())
+		}
+	}
+	return after[userID]
+}
+
+// visibleWatchers 推送前重新校验好友关系：订阅后被删除或拉黑的订阅方不再收到状态，并顺带清掉其订阅
+func (s *presenceServiceImpl) visibleWatchers(ctx context.Context, userID string, watchers []string) []string {
+	if len(watchers) == 0 {
+		return watchers
+	}
+	visible, err := s.visibleContacts(userID, watchers)
+	if err != nil {
+		return nil
+	}
+	allowed := make(map[string]struct{}, len(visible))
+	for _, uid := range visible {
+		allowed[uid] = struct{}{}
+	}
+	for _, uid := range watchers {
+		if _, ok := allowed[uid]; ok {
+			continue
+		}
+		if err := s.store.Unwatch(ctx, uid, []string{userID}); err != nil {
+			zlog.Warn("presence unwatch failed: " + err.Error())
+		}
+	}
+	return visible
+}
+
+// refreshLoop 定期重写本节点连接的状态；节点宕机后其连接因不再刷新而过期，不会一直显示在线
+func (s *presenceServiceImpl) refreshLoop() {
+	ticker := time.NewTicker(ws.PresenceRefreshInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		snapshot := make(map[string]map[string]string, len(s.local))
+		for uid, set := range s.local {
+			conns := make(map[string]string, len(set))
+			for connID, state := range set {
+				conns[connID] = state
+			}
+			snapshot[uid] = conns
+		}
+		s.mu.Unlock()
+
+		for uid, conns := range snapshot {
+			for connID, state := range conns {
+				s.refresh(uid, connID, state)
+			}
+		}
+	}
+}
+
+func (s *presenceServiceImpl) refresh(userID string, connID string, state string) {
+	ctx, cancel := context.WithTimeout(context.Background(), presenceDeadline)
+	defer cancel()
+	if err := s.store.SetState(ctx, userID, connID, state); err != nil {
+		zlog.Error("presence refresh failed: " + err.Error())
+		return
+	}
+	// 刷新期间连接已断开时撤销刚写入的记录
+	s.mu.Lock()
+	_, alive := s.local[userID][connID]
+	s.mu.Unlock()
+	if !alive {
+		_ = s.store.RemoveConn(ctx, userID, connID)
+	}
+}
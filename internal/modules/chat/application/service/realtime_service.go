@@ -3,16 +3,22 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"OmniLink/internal/config"
 	aiRequest "OmniLink/internal/modules/ai/application/dto/request"
 	aiIngest "OmniLink/internal/modules/ai/application/service"
 	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
 	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
 	chatEntity "OmniLink/internal/modules/chat/domain/entity"
 	chatRepository "OmniLink/internal/modules/chat/domain/repository"
+	contactEntity "OmniLink/internal/modules/contact/domain/entity"
 	contactRepository "OmniLink/internal/modules/contact/domain/repository"
 	userRepository "OmniLink/internal/modules/user/domain/repository"
 	"OmniLink/pkg/util"
@@ -25,16 +31,37 @@ import (
 type RealtimeService interface {
 	SendPrivateMessage(senderID string, req chatRequest.SendMessageRequest) (*chatRespond.MessageItem, *chatRespond.MessageItem, error)
 	SendGroupMessage(senderID string, req chatRequest.SendMessageRequest) ([]string, *chatRespond.MessageItem, error)
+	// RecallMessage 撤回消息，返回需要推送撤回事件的用户列表
+	RecallMessage(operatorID string, req chatRequest.RecallMessageRequest) ([]string, *chatRespond.RecallMessageRespond, error)
+	// EditMessage 编辑文本消息并保留历史版本，返回需要推送编辑事件的用户列表
+	EditMessage(operatorID string, req chatRequest.EditMessageRequest) ([]string, *chatRespond.EditMessageRespond, error)
+	// ReactMessage 添加或取消表情回应（req.Action 为 add / remove），返回需要推送回应事件的用户列表
+	ReactMessage(userID string, req chatRequest.ReactMessageRequest) ([]string, *chatRespond.ReactionEventRespond, error)
+	// MarkRead 推进已读游标，返回需要推送已读回执的用户列表
+	MarkRead(userID string, req chatRequest.MarkReadRequest) ([]string, *chatRespond.ReadReceiptRespond, error)
+	// Typing 校验会话权限并节流，返回需要转发“正在输入”的用户列表；被节流时返回空列表
+	Typing(userID string, req chatRequest.TypingRequest) ([]string, *chatRespond.TypingRespond, error)
+	// ForwardMessages 逐条或合并转发同一会话中的消息，按发送顺序返回每条新消息的 接收用户 -> 推送消息
+	ForwardMessages(userID string, req chatRequest.ForwardMessagesRequest) ([]map[string]*chatRespond.MessageItem, error)
+	// SendCallRecord 通话结束后以发起人名义写入通话记录（type=3），返回 接收用户 -> 推送消息
+	SendCallRecord(callerID string, targetID string, content string, avData string) (map[string]*chatRespond.MessageItem, error)
+	// SendGroupSystemMessage 以操作人名义写入群系统消息（type=5），不校验成员身份与禁言，返回需要推送的成员
+	SendGroupSystemMessage(operatorID string, groupID string, content string) ([]string, *chatRespond.MessageItem, error)
 }
 
 type realtimeServiceImpl struct {
-	messageRepo chatRepository.MessageRepository
-	sessionRepo chatRepository.SessionRepository
-	contactRepo contactRepository.UserContactRepository
-	userRepo    userRepository.UserInfoRepository
-	groupRepo   contactRepository.GroupInfoRepository
-	mentionRepo chatRepository.MessageMentionRepository
-	aiIngest    aiIngest.AsyncIngestService
+	messageRepo    chatRepository.MessageRepository
+	sessionRepo    chatRepository.SessionRepository
+	contactRepo    contactRepository.UserContactRepository
+	userRepo       userRepository.UserInfoRepository
+	groupRepo      contactRepository.GroupInfoRepository
+	mentionRepo    chatRepository.MessageMentionRepository
+	readCursorRepo chatRepository.SessionReadCursorRepository
+	reactionRepo   chatRepository.MessageReactionRepository
+	searcher       chatRepository.MessageSearcher
+	files          FileResolver
+	aiIngest       aiIngest.AsyncIngestService
+	typing         *typingThrottle
 }
 
 func NewRealtimeService(
@@ -44,29 +71,76 @@ func NewRealtimeService(
 	userRepo userRepository.UserInfoRepository,
 	groupRepo contactRepository.GroupInfoRepository,
 	mentionRepo chatRepository.MessageMentionRepository,
+	readCursorRepo chatRepository.SessionReadCursorRepository,
+	reactionRepo chatRepository.MessageReactionRepository,
+	searcher chatRepository.MessageSearcher,
+	files FileResolver,
 	aiIngestSvc aiIngest.AsyncIngestService,
 ) RealtimeService {
 	return &realtimeServiceImpl{
-		messageRepo: messageRepo,
-		sessionRepo: sessionRepo,
-		contactRepo: contactRepo,
-		userRepo:    userRepo,
-		groupRepo:   groupRepo,
-		mentionRepo: mentionRepo,
-		aiIngest:    aiIngestSvc,
+		messageRepo:    messageRepo,
+		sessionRepo:    sessionRepo,
+		contactRepo:    contactRepo,
+		userRepo:       userRepo,
+		groupRepo:      groupRepo,
+		mentionRepo:    mentionRepo,
+		readCursorRepo: readCursorRepo,
+		reactionRepo:   reactionRepo,
+		searcher:       searcher,
+		files:          files,
+		aiIngest:       aiIngestSvc,
+		typing:         newTypingThrottle(),
 	}
 }
 
 func (s *realtimeServiceImpl) SendPrivateMessage(senderID string, req chatRequest.SendMessageRequest) (*chatRespond.MessageItem, *chatRespond.MessageItem, error) {
+	if err := checkClientType(req.Type); err != nil {
+		return nil, nil, err
+	}
+	return s.sendPrivate(senderID, req, sendOptions{})
+}
+
+// maxClientMsgIDLen 与 message.client_msg_id 的列宽一致
+const maxClientMsgIDLen = 64
+
+// sendOptions 服务端内部发送时携带的附加信息，客户端请求不会设置
+type sendOptions struct {
+	avData        string // 通话记录详情，仅 type=3
+	forwardData   string // 合并转发的聊天记录，仅 type=4
+	forwardedFile bool   // 转发的文件已按来源消息校验过可见性，不要求属于发送者
+	system        bool   // 群系统消息，跳过发送者的成员与禁言校验
+}
+
+// checkClientType 通话记录与合并转发只能由服务端生成
+func checkClientType(t int8) error {
+	switch t {
+	case 3:
+		return xerr.New(xerr.BadRequest, "通话记录由服务端生成")
+	case 4:
+		return xerr.New(xerr.BadRequest, "合并转发请使用转发接口")
+	case 5:
+		return xerr.New(xerr.BadRequest, "系统消息由服务端生成")
+	}
+	return nil
+}
+
+// sendPrivate 私聊消息落库
+func (s *realtimeServiceImpl) sendPrivate(senderID string, req chatRequest.SendMessageRequest, opts sendOptions) (*chatRespond.MessageItem, *chatRespond.MessageItem, error) {
 	if senderID == "" || req.ReceiveId == "" {
 		return nil, nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
 	}
+	if err := checkClientMsgID(req.ClientMsgId); err != nil {
+		return nil, nil, err
+	}
 	if senderID == req.ReceiveId {
 		return nil, nil, xerr.New(xerr.BadRequest, "不能给自己发消息")
 	}
 	if req.Type == 0 && req.Content == "" {
 		return nil, nil, xerr.New(xerr.BadRequest, "消息内容不能为空")
 	}
+	if err := s.attachFile(senderID, &req, opts.forwardedFile); err != nil {
+		return nil, nil, err
+	}
 
 	rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(senderID, req.ReceiveId, 0)
 	if err != nil {
@@ -85,6 +159,10 @@ func (s *realtimeServiceImpl) SendPrivateMessage(senderID string, req chatReques
 	if rel.Status != 0 {
 		return nil, nil, xerr.New(xerr.Forbidden, "无权发送消息")
 	}
+	replyTo, err := s.resolveReply(senderID, req.ReceiveId, req.ReplyToUuid)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	sessSender, err := s.sessionRepo.GetBySendAndReceive(senderID, req.ReceiveId)
 	if err != nil {
@@ -121,34 +199,53 @@ func (s *realtimeServiceImpl) SendPrivateMessage(senderID string, req chatReques
 		sendName = briefs[0].Username
 	}
 
+	// 客户端重试同一 client_msg_id 时不重复落库，直接返回已有消息
+	if existing, err := s.findByClientMsgID(senderID, req); err != nil {
+		return nil, nil, err
+	} else if existing != nil {
+		return s.newMessageItem(existing, sessSender.Uuid), s.newMessageItem(existing, sessReceiver.Uuid), nil
+	}
+
 	now := time.Now()
 	msg := &chatEntity.Message{
-		Uuid:       util.GenerateMessageID(),
-		SessionId:  sessSender.Uuid,
-		Type:       req.Type,
-		Content:    req.Content,
-		Url:        req.Url,
-		SendId:     senderID,
-		SendName:   sendName,
-		SendAvatar: briefs[0].Avatar,
-		ReceiveId:  req.ReceiveId,
-		FileType:   req.FileType,
-		FileName:   req.FileName,
-		FileSize:   req.FileSize,
-		Status:     1,
-		CreatedAt:  now,
-		SendAt:     sql.NullTime{Time: now, Valid: true},
+		Uuid:        util.GenerateMessageID(),
+		ClientMsgId: sql.NullString{String: req.ClientMsgId, Valid: req.ClientMsgId != ""},
+		SessionId:   sessSender.Uuid,
+		Type:        req.Type,
+		Content:     req.Content,
+		Url:         req.Url,
+		SendId:      senderID,
+		SendName:    sendName,
+		SendAvatar:  briefs[0].Avatar,
+		ReceiveId:   req.ReceiveId,
+		FileType:    req.FileType,
+		FileName:    req.FileName,
+		FileSize:    req.FileSize,
+		FileId:      req.FileId,
+		AVdata:      opts.avData,
+		ForwardData: opts.forwardData,
+		Status:      1,
+		CreatedAt:   now,
+		SendAt:      sql.NullTime{Time: now, Valid: true},
 	}
+	applyReply(msg, replyTo)
 
 	if err := s.messageRepo.Create(msg); err != nil {
+		// 并发重试撞上唯一索引时，以先落库的那条为准
+		if existing, ferr := s.findByClientMsgID(senderID, req); ferr != nil {
+			return nil, nil, ferr
+		} else if existing != nil {
+			return s.newMessageItem(existing, sessSender.Uuid), s.newMessageItem(existing, sessReceiver.Uuid), nil
+		}
 		zlog.Error(err.Error())
 		return nil, nil, xerr.ErrServerError
 	}
 
-	lastMessage := msg.Content
-	if msg.Type != 0 {
-		lastMessage = "[多媒体消息]"
+	if s.searcher != nil {
+		s.searcher.Index(msg)
 	}
+
+	lastMessage := lastMessageOf(msg)
 	_ = s.sessionRepo.UpdateLastMessageBySendAndReceive(senderID, req.ReceiveId, lastMessage, now)
 	_ = s.sessionRepo.UpdateLastMessageBySendAndReceive(req.ReceiveId, senderID, lastMessage, now)
 
@@ -182,44 +279,30 @@ func (s *realtimeServiceImpl) SendPrivateMessage(senderID string, req chatReques
 		})
 	}
 
-	senderItem := &chatRespond.MessageItem{
-		Uuid:       msg.Uuid,
-		SessionId:  sessSender.Uuid,
-		SendId:     msg.SendId,
-		SendName:   msg.SendName,
-		SendAvatar: msg.SendAvatar,
-		ReceiveId:  msg.ReceiveId,
-		Type:       msg.Type,
-		Content:    msg.Content,
-		Url:        msg.Url,
-		FileType:   msg.FileType,
-		FileName:   msg.FileName,
-		FileSize:   msg.FileSize,
-		CreatedAt:  msg.CreatedAt.Format(time.RFC3339),
-	}
-	receiverItem := &chatRespond.MessageItem{
-		Uuid:       msg.Uuid,
-		SessionId:  sessReceiver.Uuid,
-		SendId:     msg.SendId,
-		SendName:   msg.SendName,
-		SendAvatar: msg.SendAvatar,
-		ReceiveId:  msg.ReceiveId,
-		Type:       msg.Type,
-		Content:    msg.Content,
-		Url:        msg.Url,
-		FileType:   msg.FileType,
-		FileName:   msg.FileName,
-		FileSize:   msg.FileSize,
-		CreatedAt:  msg.CreatedAt.Format(time.RFC3339),
-	}
+	senderItem := s.newMessageItem(msg, sessSender.Uuid)
+	receiverItem := s.newMessageItem(msg, sessReceiver.Uuid)
 
 	return senderItem, receiverItem, nil
 }
 
 func (s *realtimeServiceImpl) SendGroupMessage(senderID string, req chatRequest.SendMessageRequest) ([]string, *chatRespond.MessageItem, error) {
+	if err := checkClientType(req.Type); err != nil {
+		return nil, nil, err
+	}
+	return s.sendGroup(senderID, req, sendOptions{})
+}
+
+// sendGroup 群消息落库
+func (s *realtimeServiceImpl) sendGroup(senderID string, req chatRequest.SendMessageRequest, opts sendOptions) ([]string, *chatRespond.MessageItem, error) {
 	if senderID == "" || req.ReceiveId == "" {
 		return nil, nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
 	}
+	if err := checkClientMsgID(req.ClientMsgId); err != nil {
+		return nil, nil, err
+	}
+	if err := s.attachFile(senderID, &req, opts.forwardedFile); err != nil {
+		return nil, nil, err
+	}
 
 	// 1. 校验群组
 	group, err := s.groupRepo.GetGroupInfoByUUID(req.ReceiveId)
@@ -235,16 +318,14 @@ func (s *realtimeServiceImpl) SendGroupMessage(senderID string, req chatRequest.
 	}
 
 	// 2. 校验发送者权限
-	rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(senderID, req.ReceiveId, 1)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil, xerr.New(xerr.Forbidden, "非群成员，无法发送消息")
+	if !opts.system {
+		if err := s.checkGroupSender(group, senderID); err != nil {
+			return nil, nil, err
 		}
-		zlog.Error(err.Error())
-		return nil, nil, xerr.ErrServerError
 	}
-	if rel.Status != 0 {
-		return nil, nil, xerr.New(xerr.Forbidden, "无权发送消息")
+	replyTo, err := s.resolveReply(senderID, req.ReceiveId, req.ReplyToUuid)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// 3. 获取所有群成员
@@ -272,37 +353,54 @@ func (s *realtimeServiceImpl) SendGroupMessage(senderID string, req chatRequest.
 		sendName = briefs[0].Username
 	}
 
-	// 5. 消息落库
+	// 5. 消息落库（同一 client_msg_id 的重试直接返回已有消息）
+	if existing, err := s.findByClientMsgID(senderID, req); err != nil {
+		return nil, nil, err
+	} else if existing != nil {
+		return memberIDs, s.newGroupMessageItem(existing), nil
+	}
+
 	now := time.Now()
 	msg := &chatEntity.Message{
-		Uuid:       util.GenerateMessageID(),
-		SessionId:  "", // 群消息不绑定单一 session_id
-		Type:       req.Type,
-		Content:    req.Content,
-		Url:        req.Url,
-		SendId:     senderID,
-		SendName:   sendName,
-		SendAvatar: briefs[0].Avatar,
-		ReceiveId:  req.ReceiveId,
-		FileType:   req.FileType,
-		FileName:   req.FileName,
-		FileSize:   req.FileSize,
-		Status:     1,
-		CreatedAt:  now,
-		SendAt:     sql.NullTime{Time: now, Valid: true},
+		Uuid:        util.GenerateMessageID(),
+		ClientMsgId: sql.NullString{String: req.ClientMsgId, Valid: req.ClientMsgId != ""},
+		SessionId:   "", // 群消息不绑定单一 session_id
+		Type:        req.Type,
+		Content:     req.Content,
+		Url:         req.Url,
+		SendId:      senderID,
+		SendName:    sendName,
+		SendAvatar:  briefs[0].Avatar,
+		ReceiveId:   req.ReceiveId,
+		FileType:    req.FileType,
+		FileName:    req.FileName,
+		FileSize:    req.FileSize,
+		FileId:      req.FileId,
+		AVdata:      opts.avData,
+		ForwardData: opts.forwardData,
+		Status:      1,
+		CreatedAt:   now,
+		SendAt:      sql.NullTime{Time: now, Valid: true},
 	}
+	applyReply(msg, replyTo)
 
 	if err := s.messageRepo.Create(msg); err != nil {
+		if existing, ferr := s.findByClientMsgID(senderID, req); ferr != nil {
+			return nil, nil, ferr
+		} else if existing != nil {
+			return memberIDs, s.newGroupMessageItem(existing), nil
+		}
 		zlog.Error(err.Error())
 		return nil, nil, xerr.ErrServerError
 	}
 
-	// 6. 更新或创建会话
-	lastMessage := msg.Content
-	if msg.Type != 0 {
-		lastMessage = "[多媒体消息]"
+	if s.searcher != nil {
+		s.searcher.Index(msg)
 	}
 
+	// 6. 更新或创建会话
+	lastMessage := lastMessageOf(msg)
+
 	sessUUIDByUser := make(map[string]string, len(memberIDs))
 	for _, uid := range memberIDs {
 		sess, err := s.sessionRepo.GetBySendAndReceive(uid, req.ReceiveId)
@@ -385,23 +483,1158 @@ func (s *realtimeServiceImpl) SendGroupMessage(senderID string, req chatRequest.
 		}
 	}
 
+	item := s.newMessageItem(msg, "")
+	item.MentionedUserIds = req.MentionedUserIds
+	item.MentionAll = req.MentionAll
+
+	return memberIDs, item, nil
+}
+
+func (s *realtimeServiceImpl) SendCallRecord(callerID string, targetID string, content string, avData string) (map[string]*chatRespond.MessageItem, error) {
+	req := chatRequest.SendMessageRequest{ReceiveId: targetID, Type: 3, Content: content}
+	return s.deliver(callerID, req, sendOptions{avData: avData})
+}
+
+func (s *realtimeServiceImpl) SendGroupSystemMessage(operatorID string, groupID string, content string) ([]string, *chatRespond.MessageItem, error) {
+	req := chatRequest.SendMessageRequest{ReceiveId: groupID, Type: 5, Content: content}
+	return s.sendGroup(operatorID, req, sendOptions{system: true})
+}
+
+// checkGroupSender 校验发送者仍在群内且未被禁言；禁言到期的成员在此恢复正常状态。
+// 全员禁言时仅群主与管理员可以发言
+func (s *realtimeServiceImpl) checkGroupSender(group *contactEntity.GroupInfo, senderID string) error {
+	now := time.Now()
+	rel, err := loadGroupMember(s.contactRepo, senderID, group.Uuid, now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return xerr.New(xerr.Forbidden, "非群成员，无法发送消息")
+		}
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	if err := checkNotMuted(rel, now); err != nil {
+		return err
+	}
+	if rel.Status != 0 {
+		return xerr.New(xerr.Forbidden, "无权发送消息")
+	}
+
+	if group.IsMuted(now) && group.RoleOf(rel) == contactEntity.GroupRoleMember {
+		return xerr.New(xerr.Forbidden, "群主已开启全员禁言")
+	}
+	return nil
+}
+
+// checkNotMuted 成员禁言生效期间不能发言、编辑消息或发起群通话
+func checkNotMuted(rel *contactEntity.UserContact, now time.Time) error {
+	if !rel.IsMuted(now) {
+		return nil
+	}
+	if rel.MuteUntil.Valid {
+		return xerr.New(xerr.Forbidden, "您已被禁言，解除时间 "+rel.MuteUntil.Time.Format("2006-01-02 15:04:05"))
+	}
+	return xerr.New(xerr.Forbidden, "您已被禁言")
+}
+
+// deliver 按目标类型发送，返回 接收用户 -> 该用户视角的推送消息
+func (s *realtimeServiceImpl) deliver(senderID string, req chatRequest.SendMessageRequest, opts sendOptions) (map[string]*chatRespond.MessageItem, error) {
+	if strings.HasPrefix(req.ReceiveId, "G") {
+		memberIDs, item, err := s.sendGroup(senderID, req, opts)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]*chatRespond.MessageItem, len(memberIDs))
+		for _, uid := range memberIDs {
+			out[uid] = item
+		}
+		return out, nil
+	}
+	senderItem, receiverItem, err := s.sendPrivate(senderID, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*chatRespond.MessageItem{senderID: senderItem, req.ReceiveId: receiverItem}, nil
+}
+
+const (
+	ForwardModeSingle = "single"
+	ForwardModeMerge  = "merge"
+
+	maxForwardMessages = 100
+)
+
+// forwardRecord 合并转发的聊天记录，序列化后写入 Message.ForwardData
+type forwardRecord struct {
+	Title    string              `json:"title"`
+	SourceId string              `json:"source_id"` // 来源会话：群组 uuid 或私聊双方的 conv_id
+	IsGroup  bool                `json:"is_group"`
+	Items    []forwardRecordItem `json:"items"`
+}
+
+// forwardRecordItem 原消息快照，message_uuid 指向原消息
+type forwardRecordItem struct {
+	MessageUuid string `json:"message_uuid"`
+	SendId      string `json:"send_id"`
+	SendName    string `json:"send_name"`
+	SendAvatar  string `json:"send_avatar,omitempty"`
+	Type        int8   `json:"type"`
+	Content     string `json:"content,omitempty"`
+	FileId      string `json:"file_id,omitempty"`
+	FileType    string `json:"file_type,omitempty"`
+	FileName    string `json:"file_name,omitempty"`
+	FileSize    string `json:"file_size,omitempty"`
+	AVdata      string `json:"av_data,omitempty"`
+	ForwardData string `json:"forward_data,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func (s *realtimeServiceImpl) ForwardMessages(userID string, req chatRequest.ForwardMessagesRequest) ([]map[string]*chatRespond.MessageItem, error) {
+	if userID == "" || req.TargetId == "" || len(req.MessageIds) == 0 {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if len(req.MessageIds) > maxForwardMessages {
+		return nil, xerr.New(xerr.BadRequest, fmt.Sprintf("单次最多转发 %d 条消息", maxForwardMessages))
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = ForwardModeMerge
+		if len(req.MessageIds) == 1 {
+			mode = ForwardModeSingle
+		}
+	}
+	if mode != ForwardModeSingle && mode != ForwardModeMerge {
+		return nil, xerr.New(xerr.BadRequest, "mode 仅支持 single / merge")
+	}
+
+	msgs, err := s.readableForwardSources(userID, req.MessageIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == ForwardModeSingle {
+		// 逐条转发会追加 ":序号" 后缀，带上后缀后仍不能超过列宽
+		if req.ClientMsgId != "" {
+			if err := checkClientMsgID(fmt.Sprintf("%s:%d", req.ClientMsgId, len(msgs)-1)); err != nil {
+				return nil, err
+			}
+		}
+		for _, m := range msgs {
+			if m.Type == 3 {
+				return nil, xerr.New(xerr.BadRequest, "通话记录不支持逐条转发")
+			}
+			if m.Type == 5 {
+				return nil, xerr.New(xerr.BadRequest, "系统消息不支持逐条转发")
+			}
+		}
+		out := make([]map[string]*chatRespond.MessageItem, 0, len(msgs))
+		for i, m := range msgs {
+			fwd := chatRequest.SendMessageRequest{
+				ReceiveId: req.TargetId,
+				Type:      m.Type,
+				Content:   m.Content,
+				FileId:    m.FileId,
+			}
+			if req.ClientMsgId != "" {
+				fwd.ClientMsgId = fmt.Sprintf("%s:%d", req.ClientMsgId, i)
+			}
+			delivered, err := s.deliver(userID, fwd, sendOptions{forwardData: m.ForwardData, forwardedFile: true})
+			if err != nil {
+				// 已发出的部分照常推送
+				return out, err
+			}
+			out = append(out, delivered)
+		}
+		return out, nil
+	}
+
+	record, err := s.buildForwardRecord(msgs)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	delivered, err := s.deliver(userID, chatRequest.SendMessageRequest{
+		ClientMsgId: req.ClientMsgId,
+		ReceiveId:   req.TargetId,
+		Type:        4,
+		Content:     record.Title,
+	}, sendOptions{forwardData: string(b)})
+	if err != nil {
+		return nil, err
+	}
+	return []map[string]*chatRespond.MessageItem{delivered}, nil
+}
+
+// readableForwardSources 校验待转发消息都来自同一会话、未撤回且调用者可查看，按时间升序返回
+func (s *realtimeServiceImpl) readableForwardSources(userID string, ids []string) ([]chatEntity.Message, error) {
+	seen := make(map[string]struct{}, len(ids))
+	uniq := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		uniq = append(uniq, id)
+	}
+	msgs, err := s.messageRepo.ListByUUIDs(uniq)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if len(uniq) == 0 || len(msgs) != len(uniq) {
+		return nil, xerr.New(xerr.NotFound, "消息不存在")
+	}
+
+	convID := chatEntity.ConvIdOf(msgs[0].SendId, msgs[0].ReceiveId)
+	for _, m := range msgs {
+		if chatEntity.ConvIdOf(m.SendId, m.ReceiveId) != convID {
+			return nil, xerr.New(xerr.BadRequest, "只能转发同一会话中的消息")
+		}
+		if m.IsRecalled {
+			return nil, xerr.New(xerr.BadRequest, "不能转发已撤回的消息")
+		}
+	}
+
+	first := msgs[0]
+	if strings.HasPrefix(first.ReceiveId, "G") {
+		rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, first.ReceiveId, 1)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, xerr.New(xerr.Forbidden, "非群成员，无权查看消息")
+			}
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		if rel.Status != 0 && rel.Status != 5 {
+			return nil, xerr.New(xerr.Forbidden, "非群成员，无权查看消息")
+		}
+		return msgs, nil
+	}
+
+	peerID := first.ReceiveId
+	if first.ReceiveId == userID {
+		peerID = first.SendId
+	} else if first.SendId != userID {
+		return nil, xerr.New(xerr.Forbidden, "无权查看聊天记录")
+	}
+	rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, peerID, 0)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.Forbidden, "无权查看聊天记录")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if rel.Status != 0 {
+		return nil, xerr.New(xerr.Forbidden, "无权查看聊天记录")
+	}
+	return msgs, nil
+}
+
+func (s *realtimeServiceImpl) buildForwardRecord(msgs []chatEntity.Message) (*forwardRecord, error) {
+	first := msgs[0]
+	record := &forwardRecord{
+		SourceId: chatEntity.ConvIdOf(first.SendId, first.ReceiveId),
+		IsGroup:  strings.HasPrefix(first.ReceiveId, "G"),
+		Items:    make([]forwardRecordItem, 0, len(msgs)),
+	}
+
+	if record.IsGroup {
+		record.Title = "群聊的聊天记录"
+		if group, err := s.groupRepo.GetGroupInfoByUUID(first.ReceiveId); err == nil && group.Name != "" {
+			record.Title = group.Name + "的聊天记录"
+		}
+	} else {
+		briefs, err := s.userRepo.GetUserBriefByUUIDs([]string{first.SendId, first.ReceiveId})
+		if err != nil {
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		names := make([]string, 0, 2)
+		for _, b := range briefs {
+			name := b.Nickname
+			if name == "" {
+				name = b.Username
+			}
+			names = append(names, name)
+		}
+		record.Title = strings.Join(names, "和") + "的聊天记录"
+	}
+	record.Title = truncateRunes(record.Title, 100)
+
+	for _, m := range msgs {
+		record.Items = append(record.Items, forwardRecordItem{
+			MessageUuid: m.Uuid,
+			SendId:      m.SendId,
+			SendName:    m.SendName,
+			SendAvatar:  m.SendAvatar,
+			Type:        m.Type,
+			Content:     m.Content,
+			FileId:      m.FileId,
+			FileType:    m.FileType,
+			FileName:    m.FileName,
+			FileSize:    m.FileSize,
+			AVdata:      m.AVdata,
+			ForwardData: m.ForwardData,
+			CreatedAt:   m.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return record, nil
+}
+
+// attachFile 语音/文件消息必须引用发送者自己上传的文件，文件元信息以服务端记录为准；
+// forwarded 为 true 时文件来自已校验可见性的转发消息，只校验文件存在
+func (s *realtimeServiceImpl) attachFile(senderID string, req *chatRequest.SendMessageRequest, forwarded bool) error {
+	if req.Type != 1 && req.Type != 2 && req.FileId == "" {
+		return nil
+	}
+	if req.FileId == "" {
+		return xerr.New(xerr.BadRequest, "请先上传文件")
+	}
+	if s.files == nil {
+		return xerr.ErrServerError
+	}
+	var (
+		file *chatEntity.FileObject
+		err  error
+	)
+	if forwarded {
+		file, err = s.files.ResolveFile(req.FileId)
+	} else {
+		file, err = s.files.ResolveOwnedFile(senderID, req.FileId)
+	}
+	if err != nil {
+		return err
+	}
+	req.Url = ""
+	req.FileName = truncateRunes(file.FileName, 50)
+	req.FileType = truncateRunes(file.FileType, 10)
+	req.FileSize = strconv.FormatInt(file.Size, 10)
+	return nil
+}
+
+// resolveReply 校验引用的消息存在、未撤回且属于当前会话
+func (s *realtimeServiceImpl) resolveReply(senderID string, receiveID string, replyToUUID string) (*chatEntity.Message, error) {
+	if replyToUUID == "" {
+		return nil, nil
+	}
+	parent, err := s.messageRepo.GetByUUID(replyToUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "引用的消息不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	inSession := parent.ReceiveId == receiveID
+	if !strings.HasPrefix(receiveID, "G") {
+		inSession = (parent.SendId == senderID && parent.ReceiveId == receiveID) ||
+			(parent.SendId == receiveID && parent.ReceiveId == senderID)
+	}
+	if !inSession {
+		return nil, xerr.New(xerr.BadRequest, "引用的消息不属于该会话")
+	}
+	if parent.IsRecalled {
+		return nil, xerr.New(xerr.BadRequest, "引用的消息已撤回")
+	}
+	return parent, nil
+}
+
+// applyReply 冗余保存被引用消息的摘要；回复链上的消息都归入最顶层消息的话题
+func applyReply(msg *chatEntity.Message, parent *chatEntity.Message) {
+	if parent == nil {
+		return
+	}
+	msg.ReplyToUuid = parent.Uuid
+	msg.ReplySendId = parent.SendId
+	msg.ReplySendName = parent.SendName
+	msg.ReplySnippet = quoteSnippetOf(parent)
+	msg.ThreadRootUuid = parent.ThreadRootUuid
+	if msg.ThreadRootUuid == "" {
+		msg.ThreadRootUuid = parent.Uuid
+	}
+}
+
+// quoteSnippetOf 引用回复展示的原消息摘要
+func quoteSnippetOf(msg *chatEntity.Message) string {
+	switch msg.Type {
+	case 0:
+		return truncateRunes(msg.Content, 100)
+	case 1:
+		return "[语音]"
+	case 2:
+		return truncateRunes("[文件] "+msg.FileName, 100)
+	case 3:
+		return truncateRunes("[通话] "+msg.Content, 100)
+	case 4:
+		return truncateRunes("[聊天记录] "+msg.Content, 100)
+	case 5:
+		return truncateRunes(msg.Content, 100)
+	default:
+		return "[多媒体消息]"
+	}
+}
+
+// lastMessageOf 会话列表展示的消息摘要
+func lastMessageOf(msg *chatEntity.Message) string {
+	switch msg.Type {
+	case 0, 5:
+		return msg.Content
+	case 3:
+		return "[通话] " + msg.Content
+	case 4:
+		return "[聊天记录] " + msg.Content
+	default:
+		return "[多媒体消息]"
+	}
+}
+
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
+
+// findByClientMsgID 按发送者 + client_msg_id 查找已落库的消息，未找到返回 nil
+func (s *realtimeServiceImpl) findByClientMsgID(senderID string, req chatRequest.SendMessageRequest) (*chatEntity.Message, error) {
+	if req.ClientMsgId == "" {
+		return nil, nil
+	}
+	msg, err := s.messageRepo.GetBySendAndClientMsgID(senderID, req.ClientMsgId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	// 只有同一条消息的重试才返回已有消息，复用 client_msg_id 发往其他会话或改变内容时拒绝
+	if msg.ReceiveId != req.ReceiveId || msg.Type != req.Type || msg.Content != req.Content {
+		return nil, xerr.New(xerr.BadRequest, "client_msg_id 已被其他消息使用")
+	}
+	return msg, nil
+}
+
+// checkClientMsgID client_msg_id 长度不能超过 message.client_msg_id 列宽
+func checkClientMsgID(clientMsgID string) error {
+	if len(clientMsgID) > maxClientMsgIDLen {
+		return xerr.New(xerr.BadRequest, fmt.Sprintf("client_msg_id 不能超过 %d 个字符", maxClientMsgIDLen))
+	}
+	return nil
+}
+
+// newGroupMessageItem 由已落库的群消息构造推送项，并补齐 @ 信息
+func (s *realtimeServiceImpl) newGroupMessageItem(msg *chatEntity.Message) *chatRespond.MessageItem {
+	item := s.newMessageItem(msg, "")
+	mentions, err := s.mentionRepo.GetMentionsByMessageUUID(msg.Uuid)
+	if err != nil {
+		zlog.Error(err.Error())
+		return item
+	}
+	for _, m := range mentions {
+		if m.MentionType == 1 {
+			item.MentionAll = true
+		} else {
+			item.MentionedUserIds = append(item.MentionedUserIds, m.MentionedUserId)
+		}
+	}
+	return item
+}
+
+func (s *realtimeServiceImpl) newMessageItem(msg *chatEntity.Message, sessionID string) *chatRespond.MessageItem {
 	item := &chatRespond.MessageItem{
-		Uuid:             msg.Uuid,
-		SessionId:        "",
-		SendId:           msg.SendId,
-		SendName:         msg.SendName,
-		SendAvatar:       msg.SendAvatar,
-		ReceiveId:        msg.ReceiveId,
-		Type:             msg.Type,
-		Content:          msg.Content,
-		Url:              msg.Url,
-		FileType:         msg.FileType,
-		FileName:         msg.FileName,
-		FileSize:         msg.FileSize,
-		CreatedAt:        msg.CreatedAt.Format(time.RFC3339),
-		MentionedUserIds: req.MentionedUserIds,
-		MentionAll:       req.MentionAll,
+		Uuid:        msg.Uuid,
+		ClientMsgId: msg.ClientMsgId.String,
+		SessionId:   sessionID,
+		SendId:      msg.SendId,
+		SendName:    msg.SendName,
+		SendAvatar:  msg.SendAvatar,
+		ReceiveId:   msg.ReceiveId,
+		Type:        msg.Type,
+		Content:     msg.Content,
+		Url:         msg.Url,
+		FileType:    msg.FileType,
+		FileName:    msg.FileName,
+		FileSize:    msg.FileSize,
+		FileId:      msg.FileId,
+		AVdata:      msg.AVdata,
+		ForwardData: msg.ForwardData,
+		IsEdited:    msg.IsEdited,
+		CreatedAt:   msg.CreatedAt.Format(time.RFC3339),
+		ConvId:      msg.ConvId,
+		Seq:         msg.Seq,
+	}
+	if msg.EditedAt.Valid {
+		item.EditedAt = msg.EditedAt.Time.Format(time.RFC3339)
 	}
+	fillReply(item, msg)
+	if msg.FileId != "" && s.files != nil {
+		item.Url = s.files.FileURL(msg.FileId)
+	}
+	return item
+}
 
-	return memberIDs, item, nil
+func (s *realtimeServiceImpl) RecallMessage(operatorID string, req chatRequest.RecallMessageRequest) ([]string, *chatRespond.RecallMessageRespond, error) {
+	if operatorID == "" || req.MessageId == "" {
+		return nil, nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	msg, err := s.messageRepo.GetByUUID(req.MessageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, xerr.New(xerr.NotFound, "消息不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	if msg.SendId != operatorID {
+		return nil, nil, xerr.New(xerr.Forbidden, "只能撤回自己发送的消息")
+	}
+	if msg.IsRecalled {
+		return nil, nil, xerr.New(xerr.BadRequest, "消息已撤回")
+	}
+	if msg.Type == 3 {
+		return nil, nil, xerr.New(xerr.BadRequest, "通话记录不支持撤回")
+	}
+	if msg.Type == 5 {
+		return nil, nil, xerr.New(xerr.BadRequest, "系统消息不支持撤回")
+	}
+
+	window := time.Duration(config.GetConfig().ChatConfig.RecallWindowSeconds) * time.Second
+	if window <= 0 {
+		window = 2 * time.Minute
+	}
+	now := time.Now()
+	if now.Sub(msg.CreatedAt) > window {
+		return nil, nil, xerr.New(xerr.Forbidden, "已超过可撤回时间")
+	}
+
+	isGroup := strings.HasPrefix(msg.ReceiveId, "G")
+	var recipients []string
+	if isGroup {
+		rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(operatorID, msg.ReceiveId, 1)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, xerr.New(xerr.Forbidden, "非群成员，无法撤回消息")
+			}
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+		if rel.Status != 0 && rel.Status != 5 {
+			return nil, nil, xerr.New(xerr.Forbidden, "非群成员，无法撤回消息")
+		}
+		members, err := s.contactRepo.GetGroupMembers(msg.ReceiveId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+		recipients = make([]string, 0, len(members))
+		for _, m := range members {
+			recipients = append(recipients, m.UserId)
+		}
+	} else {
+		recipients = []string{msg.SendId, msg.ReceiveId}
+	}
+
+	recalled, err := s.messageRepo.MarkRecalled(msg.Uuid, now)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	if !recalled {
+		// 并发的重复撤回：已由先到的请求完成推送与清理
+		return nil, nil, xerr.New(xerr.BadRequest, "消息已撤回")
+	}
+	if err := s.messageRepo.UpdateReplySnippet(msg.Uuid, "[该消息已撤回]"); err != nil {
+		zlog.Error(err.Error())
+	}
+	if s.searcher != nil {
+		s.searcher.Remove(msg.Uuid)
+	}
+
+	// 被撤回的是会话最新一条时，刷新双方（或全体群成员）会话的 LastMessage
+	notice := fmt.Sprintf("%s撤回了一条消息", msg.SendName)
+	var latest []chatEntity.Message
+	if isGroup {
+		latest, err = s.messageRepo.ListGroupMessages(msg.ReceiveId, 1, 1)
+	} else {
+		latest, err = s.messageRepo.ListPrivateMessages(msg.SendId, msg.ReceiveId, 1, 1)
+	}
+	lastMessage := ""
+	if err != nil {
+		zlog.Error(err.Error())
+	} else if len(latest) > 0 && latest[0].Uuid == msg.Uuid {
+		lastMessage = notice
+		if isGroup {
+			for _, uid := range recipients {
+				_ = s.sessionRepo.UpdateLastMessageBySendAndReceive(uid, msg.ReceiveId, notice, msg.CreatedAt)
+			}
+		} else {
+			_ = s.sessionRepo.UpdateLastMessageBySendAndReceive(msg.SendId, msg.ReceiveId, notice, msg.CreatedAt)
+			_ = s.sessionRepo.UpdateLastMessageBySendAndReceive(msg.ReceiveId, msg.SendId, notice, msg.CreatedAt)
+		}
+	}
+
+	// 从 AI 知识库中移除该消息，避免助手继续引用
+	if msg.Type == 0 {
+		s.reindexMessage(msg, recipients, msg.Uuid+":recall")
+	}
+
+	return recipients, &chatRespond.RecallMessageRespond{
+		Type:        "message.recall",
+		MessageId:   msg.Uuid,
+		SendId:      msg.SendId,
+		ReceiveId:   msg.ReceiveId,
+		RecalledBy:  operatorID,
+		RecalledAt:  now.Format(time.RFC3339),
+		LastMessage: lastMessage,
+	}, nil
+}
+
+func (s *realtimeServiceImpl) EditMessage(operatorID string, req chatRequest.EditMessageRequest) ([]string, *chatRespond.EditMessageRespond, error) {
+	if operatorID == "" || req.MessageId == "" {
+		return nil, nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, nil, xerr.New(xerr.BadRequest, "消息内容不能为空")
+	}
+
+	msg, err := s.messageRepo.GetByUUID(req.MessageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, xerr.New(xerr.NotFound, "消息不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	if msg.SendId != operatorID {
+		return nil, nil, xerr.New(xerr.Forbidden, "只能编辑自己发送的消息")
+	}
+	if msg.IsRecalled {
+		return nil, nil, xerr.New(xerr.BadRequest, "消息已撤回")
+	}
+	if msg.Type != 0 {
+		return nil, nil, xerr.New(xerr.BadRequest, "仅支持编辑文本消息")
+	}
+	if msg.Content == req.Content {
+		return nil, nil, xerr.New(xerr.BadRequest, "消息内容未变化")
+	}
+
+	window := time.Duration(config.GetConfig().ChatConfig.EditWindowSeconds) * time.Second
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+	now := time.Now()
+	if now.Sub(msg.CreatedAt) > window {
+		return nil, nil, xerr.New(xerr.Forbidden, "已超过可编辑时间")
+	}
+
+	isGroup := strings.HasPrefix(msg.ReceiveId, "G")
+	var recipients []string
+	if isGroup {
+		rel, err := loadGroupMember(s.contactRepo, operatorID, msg.ReceiveId, now)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, xerr.New(xerr.Forbidden, "非群成员，无法编辑消息")
+			}
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+		if err := checkNotMuted(rel, now); err != nil {
+			return nil, nil, err
+		}
+		if rel.Status != 0 {
+			return nil, nil, xerr.New(xerr.Forbidden, "无权编辑群消息")
+		}
+		members, err := s.contactRepo.GetGroupMembers(msg.ReceiveId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+		recipients = make([]string, 0, len(members))
+		for _, m := range members {
+			recipients = append(recipients, m.UserId)
+		}
+	} else {
+		recipients = []string{msg.SendId, msg.ReceiveId}
+	}
+
+	version, err := s.messageRepo.EditContent(msg.Uuid, req.Content, operatorID, now.Add(-window), now)
+	if err != nil {
+		switch {
+		case errors.Is(err, chatRepository.ErrMessageRecalled):
+			return nil, nil, xerr.New(xerr.BadRequest, "消息已撤回")
+		case errors.Is(err, chatRepository.ErrMessageNotAuthor):
+			return nil, nil, xerr.New(xerr.Forbidden, "只能编辑自己发送的消息")
+		case errors.Is(err, chatRepository.ErrMessageEditExpired):
+			return nil, nil, xerr.New(xerr.Forbidden, "已超过可编辑时间")
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, nil, xerr.New(xerr.NotFound, "消息不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	msg.Content = req.Content
+	if err := s.messageRepo.UpdateReplySnippet(msg.Uuid, quoteSnippetOf(msg)); err != nil {
+		zlog.Error(err.Error())
+	}
+	msg.IsEdited = true
+	msg.EditedAt = sql.NullTime{Time: now, Valid: true}
+	if s.searcher != nil {
+		s.searcher.Index(msg)
+	}
+
+	// 被编辑的是会话最新一条时，刷新双方（或全体群成员）会话的 LastMessage
+	var latest []chatEntity.Message
+	if isGroup {
+		latest, err = s.messageRepo.ListGroupMessages(msg.ReceiveId, 1, 1)
+	} else {
+		latest, err = s.messageRepo.ListPrivateMessages(msg.SendId, msg.ReceiveId, 1, 1)
+	}
+	lastMessage := ""
+	if err != nil {
+		zlog.Error(err.Error())
+	} else if len(latest) > 0 && latest[0].Uuid == msg.Uuid {
+		lastMessage = lastMessageOf(msg)
+		if isGroup {
+			for _, uid := range recipients {
+				_ = s.sessionRepo.UpdateLastMessageBySendAndReceive(uid, msg.ReceiveId, lastMessage, msg.CreatedAt)
+			}
+		} else {
+			_ = s.sessionRepo.UpdateLastMessageBySendAndReceive(msg.SendId, msg.ReceiveId, lastMessage, msg.CreatedAt)
+			_ = s.sessionRepo.UpdateLastMessageBySendAndReceive(msg.ReceiveId, msg.SendId, lastMessage, msg.CreatedAt)
+		}
+	}
+
+	s.reindexMessage(msg, recipients, fmt.Sprintf("%s:v%d", msg.Uuid, version))
+
+	return recipients, &chatRespond.EditMessageRespond{
+		Type:        "message.edit",
+		MessageId:   msg.Uuid,
+		SendId:      msg.SendId,
+		ReceiveId:   msg.ReceiveId,
+		Content:     msg.Content,
+		Version:     version,
+		EditedAt:    now.Format(time.RFC3339),
+		LastMessage: lastMessage,
+	}, nil
+}
+
+// reindexMessage 消息撤回或编辑后重建 AI 知识库：先清除覆盖该消息的分片，再从该消息所在位置重新拉取，
+// 撤回的消息在重新入库时被跳过，编辑的消息以新内容入库。
+// 凡是可能收录过该消息的用户都要清理：私聊为双方，群聊为所有当前与曾经的成员；没有会话的用户只清除不重新入库
+func (s *realtimeServiceImpl) reindexMessage(msg *chatEntity.Message, recipients []string, dedup string) {
+	if s.aiIngest == nil {
+		return
+	}
+	since := msg.CreatedAt.Add(-5 * time.Second)
+	enqueue := func(req aiRequest.ChatMessagesPageRequest) {
+		req.Page = 1
+		req.PageSize = 50
+		req.Since = &since
+		req.DedupExtra = dedup
+		req.ReplaceMessageUUID = msg.Uuid
+		if err := s.aiIngest.EnqueueChatMessagesPage(context.Background(), req); err != nil {
+			zlog.Error(fmt.Sprintf("enqueue ai reindex failed, tenant=%s message=%s: %s", req.TenantUserID, msg.Uuid, err.Error()))
+		}
+	}
+
+	if !strings.HasPrefix(msg.ReceiveId, "G") {
+		sides := [][2]string{{msg.SendId, msg.ReceiveId}, {msg.ReceiveId, msg.SendId}}
+		for _, side := range sides {
+			req := aiRequest.ChatMessagesPageRequest{
+				TenantUserID: side[0],
+				SessionType:  1,
+				TargetID:     side[1],
+				SourceType:   "chat_private",
+				SourceKey:    side[1],
+			}
+			if sess, err := s.sessionRepo.GetBySendAndReceive(side[0], side[1]); err == nil && sess != nil {
+				req.SessionUUID = sess.Uuid
+				req.SessionName = sess.ReceiveName
+			}
+			enqueue(req)
+		}
+		return
+	}
+
+	groupName := ""
+	if group, err := s.groupRepo.GetGroupInfoByUUID(msg.ReceiveId); err == nil && group != nil {
+		groupName = group.Name
+	}
+	tenants := recipients
+	if all, err := s.contactRepo.ListGroupUserIDs(msg.ReceiveId); err != nil {
+		zlog.Error(err.Error())
+	} else {
+		seen := make(map[string]struct{}, len(recipients)+len(all))
+		tenants = make([]string, 0, len(recipients)+len(all))
+		for _, uid := range append(append([]string{}, recipients...), all...) {
+			if _, dup := seen[uid]; dup {
+				continue
+			}
+			seen[uid] = struct{}{}
+			tenants = append(tenants, uid)
+		}
+	}
+	for _, uid := range tenants {
+		req := aiRequest.ChatMessagesPageRequest{
+			TenantUserID: uid,
+			SessionType:  2,
+			SessionName:  groupName,
+			TargetID:     msg.ReceiveId,
+			SourceType:   "chat_group",
+			SourceKey:    msg.ReceiveId,
+		}
+		if sess, err := s.sessionRepo.GetBySendAndReceive(uid, msg.ReceiveId); err == nil && sess != nil {
+			req.SessionUUID = sess.Uuid
+		}
+		enqueue(req)
+	}
+}
+
+const (
+	ReactionActionAdd    = "add"
+	ReactionActionRemove = "remove"
+
+	maxReactionsPerUser = 20 // 单个用户在一条消息上最多回应的表情数
+)
+
+func (s *realtimeServiceImpl) ReactMessage(userID string, req chatRequest.ReactMessageRequest) ([]string, *chatRespond.ReactionEventRespond, error) {
+	emoji := strings.TrimSpace(req.Emoji)
+	if userID == "" || req.MessageId == "" || emoji == "" {
+		return nil, nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if len(emoji) > 32 || len([]rune(emoji)) > 8 || strings.ContainsAny(emoji, " \t\r\n") {
+		return nil, nil, xerr.New(xerr.BadRequest, "不支持的表情")
+	}
+	if req.Action != ReactionActionAdd && req.Action != ReactionActionRemove {
+		return nil, nil, xerr.New(xerr.BadRequest, "action 仅支持 add / remove")
+	}
+
+	msg, err := s.messageRepo.GetByUUID(req.MessageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, xerr.New(xerr.NotFound, "消息不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	if msg.IsRecalled && req.Action == ReactionActionAdd {
+		return nil, nil, xerr.New(xerr.BadRequest, "消息已撤回")
+	}
+	recipients, err := s.conversationRecipients(userID, msg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	changed := false
+	if req.Action == ReactionActionAdd {
+		cnt, err := s.reactionRepo.CountByUser(msg.Uuid, userID)
+		if err != nil {
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+		if cnt >= maxReactionsPerUser {
+			return nil, nil, xerr.New(xerr.BadRequest, fmt.Sprintf("每条消息最多回应 %d 个表情", maxReactionsPerUser))
+		}
+		changed, err = s.reactionRepo.Add(&chatEntity.MessageReaction{
+			MessageUuid: msg.Uuid,
+			UserId:      userID,
+			Emoji:       emoji,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+	} else {
+		changed, err = s.reactionRepo.Remove(msg.Uuid, userID, emoji)
+		if err != nil {
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+	}
+
+	count, err := s.reactionRepo.CountByEmoji(msg.Uuid, emoji)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	// 重复添加或取消不存在的回应视为成功，但无需广播
+	if !changed {
+		recipients = nil
+	}
+	return recipients, &chatRespond.ReactionEventRespond{
+		Type:      "message.reaction",
+		Action:    req.Action,
+		MessageId: msg.Uuid,
+		SendId:    msg.SendId,
+		ReceiveId: msg.ReceiveId,
+		UserId:    userID,
+		Emoji:     emoji,
+		Count:     count,
+		CreatedAt: now.Format(time.RFC3339),
+	}, nil
+}
+
+// conversationRecipients 按发送消息的规则校验用户在消息所在会话中的权限，返回会话内的全部用户
+func (s *realtimeServiceImpl) conversationRecipients(userID string, msg *chatEntity.Message) ([]string, error) {
+	if !strings.HasPrefix(msg.ReceiveId, "G") {
+		peerID := msg.ReceiveId
+		if msg.ReceiveId == userID {
+			peerID = msg.SendId
+		} else if msg.SendId != userID {
+			return nil, xerr.New(xerr.Forbidden, "无权操作该消息")
+		}
+		rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, peerID, 0)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, xerr.New(xerr.Forbidden, "无权操作该消息")
+			}
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		switch rel.Status {
+		case 0:
+			return []string{msg.SendId, msg.ReceiveId}, nil
+		case 1:
+			return nil, xerr.New(xerr.Forbidden, "已拉黑对方，无法操作")
+		case 2:
+			return nil, xerr.New(xerr.Forbidden, "已被对方拉黑，无法操作")
+		default:
+			return nil, xerr.New(xerr.Forbidden, "无权操作该消息")
+		}
+	}
+
+	group, err := s.groupRepo.GetGroupInfoByUUID(msg.ReceiveId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "群组不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if group.Status != 0 {
+		return nil, xerr.New(xerr.Forbidden, "群组状态异常")
+	}
+	rel, err := loadGroupMember(s.contactRepo, userID, msg.ReceiveId, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.Forbidden, "非群成员，无法操作")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if !isGroupReader(rel) {
+		return nil, xerr.New(xerr.Forbidden, "无权操作该消息")
+	}
+	members, err := s.contactRepo.GetGroupMembers(msg.ReceiveId)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	recipients := make([]string, 0, len(members))
+	for _, m := range members {
+		recipients = append(recipients, m.UserId)
+	}
+	return recipients, nil
+}
+
+func (s *realtimeServiceImpl) MarkRead(userID string, req chatRequest.MarkReadRequest) ([]string, *chatRespond.ReadReceiptRespond, error) {
+	if userID == "" || req.TargetId == "" {
+		return nil, nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if userID == req.TargetId {
+		return nil, nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	isGroup := strings.HasPrefix(req.TargetId, "G")
+	if isGroup {
+		rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, req.TargetId, 1)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, xerr.New(xerr.Forbidden, "非群成员")
+			}
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+		if rel.Status != 0 && rel.Status != 5 {
+			return nil, nil, xerr.New(xerr.Forbidden, "非群成员")
+		}
+	} else {
+		if _, err := s.sessionRepo.GetBySendAndReceive(userID, req.TargetId); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, xerr.New(xerr.NotFound, "会话不存在")
+			}
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+	}
+
+	// 指定了消息时读到该消息为止，否则读到当前时刻
+	readAt := time.Now()
+	lastReadUUID := req.MessageId
+	if req.MessageId != "" {
+		msg, err := s.messageRepo.GetByUUID(req.MessageId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, xerr.New(xerr.NotFound, "消息不存在")
+			}
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+		inSession := msg.ReceiveId == req.TargetId
+		if !isGroup {
+			inSession = (msg.SendId == userID && msg.ReceiveId == req.TargetId) ||
+				(msg.SendId == req.TargetId && msg.ReceiveId == userID)
+		}
+		if !inSession {
+			return nil, nil, xerr.New(xerr.BadRequest, "消息不属于该会话")
+		}
+		readAt = msg.CreatedAt
+	}
+
+	if err := s.readCursorRepo.Advance(&chatEntity.SessionReadCursor{
+		UserId:              userID,
+		TargetId:            req.TargetId,
+		LastReadMessageUuid: lastReadUUID,
+		LastReadAt:          readAt,
+		UpdatedAt:           time.Now(),
+	}); err != nil {
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+
+	item := &chatRespond.ReadReceiptRespond{
+		Type:              "message.read",
+		ReaderId:          userID,
+		TargetId:          req.TargetId,
+		LastReadMessageId: lastReadUUID,
+		ReadAt:            readAt.Format(time.RFC3339),
+	}
+
+	// 群聊只同步给自己的其他端；私聊额外把回执推给对方，并推进对方消息的状态
+	if isGroup {
+		return []string{userID}, item, nil
+	}
+	if _, err := s.messageRepo.MarkPrivateRead(req.TargetId, userID, readAt); err != nil {
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	return []string{userID, req.TargetId}, item, nil
+}
+
+// 正在输入状态
+const (
+	TypingStateStart = "start"
+	TypingStateStop  = "stop"
+)
+
+const (
+	// typingThrottleInterval 同一会话内 start 的最小转发间隔，客户端持续输入时重复上报不会刷屏
+	typingThrottleInterval = 3 * time.Second
+	// typingSweepThreshold 记录数超过该值时清理过期记录（只发 start 不发 stop 的客户端）
+	typingSweepThreshold = 4096
+)
+
+// typingThrottle 按 (用户, 会话) 节流正在输入事件，仅在本节点内生效
+type typingThrottle struct {
+	mu   sync.Mutex
+	last map[string]time.Time // userID + "|" + targetID -> 上次转发 start 的时间
+}
+
+func newTypingThrottle() *typingThrottle {
+	return &typingThrottle{last: make(map[string]time.Time)}
+}
+
+// allow 返回本次是否需要转发：start 在间隔内只转发一次；stop 只在转发过 start 后转发一次
+func (t *typingThrottle) allow(userID string, targetID string, state string) bool {
+	key := userID + "|" + targetID
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, ok := t.last[key]
+	if state == TypingStateStop {
+		delete(t.last, key)
+		return ok
+	}
+	if ok && now.Sub(last) < typingThrottleInterval {
+		return false
+	}
+	if len(t.last) >= typingSweepThreshold {
+		for k, at := range t.last {
+			if now.Sub(at) >= typingThrottleInterval {
+				delete(t.last, k)
+			}
+		}
+	}
+	t.last[key] = now
+	return true
+}
+
+func (s *realtimeServiceImpl) Typing(userID string, req chatRequest.TypingRequest) ([]string, *chatRespond.TypingRespond, error) {
+	if userID == "" || req.TargetId == "" || userID == req.TargetId {
+		return nil, nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	state := req.State
+	if state == "" {
+		state = TypingStateStart
+	}
+	if state != TypingStateStart && state != TypingStateStop {
+		return nil, nil, xerr.New(xerr.BadRequest, "state 仅支持 start / stop")
+	}
+	// 被节流时不转发也不报错
+	if !s.typing.allow(userID, req.TargetId, state) {
+		return nil, nil, nil
+	}
+
+	item := &chatRespond.TypingRespond{FromId: userID, TargetId: req.TargetId, State: state}
+	if !strings.HasPrefix(req.TargetId, "G") {
+		rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, req.TargetId, 0)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, xerr.New(xerr.Forbidden, "非好友关系")
+			}
+			zlog.Error(err.Error())
+			return nil, nil, xerr.ErrServerError
+		}
+		if rel.Status != 0 {
+			return nil, nil, xerr.New(xerr.Forbidden, "非好友关系")
+		}
+		return []string{req.TargetId}, item, nil
+	}
+
+	rel, err := loadGroupMember(s.contactRepo, userID, req.TargetId, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, xerr.New(xerr.Forbidden, "非群成员")
+		}
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	if !isGroupReader(rel) {
+		return nil, nil, xerr.New(xerr.Forbidden, "无权发送消息")
+	}
+	members, err := s.contactRepo.GetGroupMembers(req.TargetId)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	recipients := make([]string, 0, len(members))
+	for _, m := range members {
+		if m.UserId != userID {
+			recipients = append(recipients, m.UserId)
+		}
+	}
+	return recipients, item, nil
 }
@@ -5,6 +5,7 @@ import (
 	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
 	chatEntity "OmniLink/internal/modules/chat/domain/entity"
 	chatRepository "OmniLink/internal/modules/chat/domain/repository"
+	contactEntity "OmniLink/internal/modules/contact/domain/entity"
 	contactRepository "OmniLink/internal/modules/contact/domain/repository"
 	userRepository "OmniLink/internal/modules/user/domain/repository"
 	"OmniLink/pkg/util"
@@ -25,18 +26,32 @@ type SessionService interface {
 }
 
 type sessionServiceImpl struct {
-	sessionRepo chatRepository.SessionRepository
-	contactRepo contactRepository.UserContactRepository
-	userRepo    userRepository.UserInfoRepository
-	groupRepo   contactRepository.GroupInfoRepository
+	sessionRepo    chatRepository.SessionRepository
+	contactRepo    contactRepository.UserContactRepository
+	userRepo       userRepository.UserInfoRepository
+	groupRepo      contactRepository.GroupInfoRepository
+	messageRepo    chatRepository.MessageRepository
+	mentionRepo    chatRepository.MessageMentionRepository
+	readCursorRepo chatRepository.SessionReadCursorRepository
 }
 
-func NewSessionService(sessionRepo chatRepository.SessionRepository, contactRepo contactRepository.UserContactRepository, userRepo userRepository.UserInfoRepository, groupRepo contactRepository.GroupInfoRepository) SessionService {
+func NewSessionService(
+	sessionRepo chatRepository.SessionRepository,
+	contactRepo contactRepository.UserContactRepository,
+	userRepo userRepository.UserInfoRepository,
+	groupRepo contactRepository.GroupInfoRepository,
+	messageRepo chatRepository.MessageRepository,
+	mentionRepo chatRepository.MessageMentionRepository,
+	readCursorRepo chatRepository.SessionReadCursorRepository,
+) SessionService {
 	return &sessionServiceImpl{
-		sessionRepo: sessionRepo,
-		contactRepo: contactRepo,
-		userRepo:    userRepo,
-		groupRepo:   groupRepo,
+		sessionRepo:    sessionRepo,
+		contactRepo:    contactRepo,
+		userRepo:       userRepo,
+		groupRepo:      groupRepo,
+		messageRepo:    messageRepo,
+		mentionRepo:    mentionRepo,
+		readCursorRepo: readCursorRepo,
 	}
 }
 
@@ -70,6 +85,7 @@ func (s *sessionServiceImpl) GetUserSessionList(ownerID string) ([]chatRespond.S
 		})
 	}
 
+	s.fillBadges(ownerID, out)
 	return out, nil
 }
 
@@ -130,9 +146,93 @@ func (s *sessionServiceImpl) GetGroupSessionList(ownerID string) ([]chatRespond.
 			UpdatedAt:   updatedAt.Format(time.RFC3339),
 		})
 	}
+
+	s.fillBadges(ownerID, out)
 	return out, nil
 }
 
+// fillBadges 根据已读游标填充未读数与 @ 提及数，统计失败只记录日志，不影响会话列表返回。
+// 起点取已读游标与成为好友 / 最近一次入群时间中较晚的一个，不统计入群之前（含退群期间）的消息；
+// 已退群、被踢出或群已解散的会话无权查看消息，不显示未读
+func (s *sessionServiceImpl) fillBadges(ownerID string, items []chatRespond.SessionItem) {
+	if len(items) == 0 || s.readCursorRepo == nil || s.messageRepo == nil {
+		return
+	}
+
+	cursors, err := s.readCursorRepo.ListByUserID(ownerID)
+	if err != nil {
+		zlog.Error(err.Error())
+		return
+	}
+	lastReadAt := make(map[string]time.Time, len(cursors))
+	for _, c := range cursors {
+		lastReadAt[c.TargetId] = c.LastReadAt
+	}
+	contacts, err := s.contactRepo.GetUserContactsByUserID(ownerID)
+	if err != nil {
+		zlog.Error(err.Error())
+		return
+	}
+	rels := make(map[string]*contactEntity.UserContact, len(contacts))
+	for i := range contacts {
+		rels[contacts[i].ContactId] = &contacts[i]
+	}
+
+	groupSince := make(map[string]time.Time)
+	privateSince := make(map[string]time.Time)
+	for i := range items {
+		item := &items[i]
+		since, ok := lastReadAt[item.PeerId]
+		if ok {
+			item.LastReadAt = since.Format(time.RFC3339)
+		}
+		rel := rels[item.PeerId]
+		if rel != nil && rel.JoinTime().After(since) {
+			since = rel.JoinTime()
+		}
+		if item.PeerType == "G" {
+			if rel == nil || (rel.Status != 0 && rel.Status != 5) {
+				continue
+			}
+			groupSince[item.PeerId] = since
+		} else {
+			privateSince[item.PeerId] = since
+		}
+	}
+
+	var unread, mentions map[string]int64
+	if len(groupSince) > 0 {
+		if unread, err = s.messageRepo.CountUnreadGroups(ownerID, groupSince); err != nil {
+			zlog.Error(err.Error())
+		}
+		if s.mentionRepo != nil {
+			if mentions, err = s.mentionRepo.CountUnreadMentions(ownerID, groupSince); err != nil {
+				zlog.Error(err.Error())
+			}
+		}
+	}
+	if len(privateSince) > 0 {
+		private, err := s.messageRepo.CountUnreadPrivates(ownerID, privateSince)
+		if err != nil {
+			zlog.Error(err.Error())
+		}
+		if unread == nil {
+			unread = private
+		} else {
+			for peerID, cnt := range private {
+				unread[peerID] = cnt
+			}
+		}
+	}
+	for i := range items {
+		item := &items[i]
+		item.UnreadCount = int(unread[item.PeerId])
+		if item.PeerType == "G" && item.UnreadCount > 0 {
+			item.MentionCount = int(mentions[item.PeerId])
+		}
+	}
+}
+
 func (s *sessionServiceImpl) CheckOpenSessionAllowed(req chatRequest.OpenSessionRequest) (bool, error) {
 	return s.checkAllowed(req.SendId, req.ReceiveId)
 }
@@ -0,0 +1,787 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"OmniLink/internal/config"
+	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
+	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
+	chatEntity "OmniLink/internal/modules/chat/domain/entity"
+	chatRepository "OmniLink/internal/modules/chat/domain/repository"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	"OmniLink/pkg/storage"
+	"OmniLink/pkg/util"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"gorm.io/gorm"
+)
+
+// 文件分类
+const (
+	FileCategoryImage  = "image"
+	FileCategoryVoice  = "voice"
+	FileCategoryFile   = "file"
+	FileCategoryAvatar = "avatar"
+)
+
+const (
+	defaultChunkSize     int64 = 5 << 20
+	defaultMaxFileSize   int64 = 100 << 20
+	defaultMaxImageSize  int64 = 10 << 20
+	defaultMaxVoiceSize  int64 = 10 << 20
+	defaultMaxAvatarSize int64 = 2 << 20
+	defaultURLExpire           = time.Hour
+	// uploadSessionTTL 分片上传会话有效期，过期后需重新初始化
+	uploadSessionTTL = 24 * time.Hour
+	storageTimeout   = 5 * time.Minute
+)
+
+var (
+	imageExts = map[string]struct{}{"jpg": {}, "jpeg": {}, "png": {}, "gif": {}, "webp": {}, "bmp": {}, "heic": {}}
+	voiceExts = map[string]struct{}{"mp3": {}, "amr": {}, "wav": {}, "m4a": {}, "aac": {}, "ogg": {}, "opus": {}, "webm": {}, "silk": {}}
+)
+
+// FileResolver 聊天消息引用文件时的归属校验与下载地址签发，由 UploadService 实现
+type FileResolver interface {
+	// ResolveOwnedFile 返回属于 ownerID 的文件，不存在或不属于该用户时返回错误
+	ResolveOwnedFile(ownerID string, fileID string) (*chatEntity.FileObject, error)
+	// ResolveFile 返回文件记录，不校验归属；仅用于调用方已校验过来源消息可见性的场景（如转发）
+	ResolveFile(fileID string) (*chatEntity.FileObject, error)
+	// FileURL 签发文件的限时下载地址，失败时返回空串
+	FileURL(fileID string) string
+}
+
+type UploadService interface {
+	FileResolver
+
+	// UploadFile 单次上传小文件
+	UploadFile(ownerID string, category string, header *multipart.FileHeader) (*chatRespond.FileItem, error)
+	// UploadAvatar 上传头像，返回长期有效的访问地址
+	UploadAvatar(ownerID string, header *multipart.FileHeader) (*chatRespond.FileItem, error)
+
+	InitUpload(ownerID string, req chatRequest.InitUploadRequest) (*chatRespond.InitUploadRespond, error)
+	UploadChunk(ownerID string, uploadID string, index int, header *multipart.FileHeader) error
+	GetUploadStatus(ownerID string, req chatRequest.UploadStatusRequest) (*chatRespond.UploadStatusRespond, error)
+	CompleteUpload(ownerID string, req chatRequest.CompleteUploadRequest) (*chatRespond.FileItem, error)
+
+	// GetFileUrl 为有权访问的用户签发下载地址：上传者本人，或能看到引用该文件消息的会话成员
+	GetFileUrl(callerID string, req chatRequest.GetFileUrlRequest) (*chatRespond.FileItem, error)
+	// AvatarURL 返回头像文件的签名地址，仅对头像分类生效
+	AvatarURL(fileID string) (string, error)
+}
+
+type uploadServiceImpl struct {
+	fileRepo    chatRepository.FileRepository
+	messageRepo chatRepository.MessageRepository
+	contactRepo contactRepository.UserContactRepository
+	store       storage.Storage
+}
+
+func NewUploadService(
+	fileRepo chatRepository.FileRepository,
+	messageRepo chatRepository.MessageRepository,
+	contactRepo contactRepository.UserContactRepository,
+	store storage.Storage,
+) UploadService {
+	return &uploadServiceImpl{
+		fileRepo:    fileRepo,
+		messageRepo: messageRepo,
+		contactRepo: contactRepo,
+		store:       store,
+	}
+}
+
+func (s *uploadServiceImpl) UploadFile(ownerID string, category string, header *multipart.FileHeader) (*chatRespond.FileItem, error) {
+	if category == "" {
+		category = FileCategoryFile
+	}
+	if category == FileCategoryAvatar {
+		return nil, xerr.New(xerr.BadRequest, "头像请使用头像上传接口")
+	}
+	return s.uploadWhole(ownerID, category, header)
+}
+
+func (s *uploadServiceImpl) UploadAvatar(ownerID string, header *multipart.FileHeader) (*chatRespond.FileItem, error) {
+	item, err := s.uploadWhole(ownerID, FileCategoryAvatar, header)
+	if err != nil {
+		return nil, err
+	}
+	// 头像地址会写入用户资料，使用不过期的跳转地址，由其在访问时再签发
+	item.Url = avatarPath(item.FileId)
+	item.ExpireAt = ""
+	return item, nil
+}
+
+func (s *uploadServiceImpl) uploadWhole(ownerID string, category string, header *multipart.FileHeader) (*chatRespond.FileItem, error) {
+	if ownerID == "" || header == nil {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	ext, err := checkFileLimit(category, header.Filename, header.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	// 第一遍计算摘要并嗅探类型，命中已有内容时直接秒传
+	f, err := header.Open()
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	sniff := make([]byte, 512)
+	n, _ := io.ReadFull(f, sniff)
+	mimeType := http.DetectContentType(sniff[:n])
+	if err := checkMime(category, mimeType); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(sniff[:n])
+	if _, err := io.Copy(h, f); err != nil {
+		_ = f.Close()
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	_ = f.Close()
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	key, err := s.ensureObject(sum, header.Size, mimeType, func() (io.ReadCloser, error) { return header.Open() })
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.createFile(ownerID, category, header.Filename, ext, mimeType, header.Size, sum, key)
+	if err != nil {
+		return nil, err
+	}
+	return s.fileItem(file), nil
+}
+
+func (s *uploadServiceImpl) InitUpload(ownerID string, req chatRequest.InitUploadRequest) (*chatRespond.InitUploadRespond, error) {
+	if ownerID == "" || req.FileName == "" || req.Size <= 0 {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if req.Category == "" {
+		req.Category = FileCategoryFile
+	}
+	if req.Category == FileCategoryAvatar {
+		return nil, xerr.New(xerr.BadRequest, "头像请使用头像上传接口")
+	}
+	ext, err := checkFileLimit(req.Category, req.FileName, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	req.Sha256 = strings.ToLower(strings.TrimSpace(req.Sha256))
+	if req.Sha256 != "" && !isSha256Hex(req.Sha256) {
+		return nil, xerr.New(xerr.BadRequest, "sha256 格式错误")
+	}
+
+	now := time.Now()
+	if req.Sha256 != "" {
+		// 秒传：仅当前用户自己上传过相同内容时直接生成文件记录；客户端声明的摘要不可信，
+		// 其他用户的同内容文件需上传完整内容，由 CompleteUpload 校验摘要后在服务端去重
+		existing, err := s.fileRepo.FindOwnedBySha256(ownerID, req.Sha256, req.Size)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		if existing != nil && s.objectExists(existing.StorageKey) {
+			if err := checkMime(req.Category, existing.MimeType); err != nil {
+				return nil, err
+			}
+			file, err := s.createFile(ownerID, req.Category, req.FileName, ext, existing.MimeType, req.Size, req.Sha256, existing.StorageKey)
+			if err != nil {
+				return nil, err
+			}
+			return &chatRespond.InitUploadRespond{Instant: true, File: s.fileItem(file)}, nil
+		}
+
+		// 断点续传：同一文件仍有未完成的上传会话时继续使用
+		session, err := s.fileRepo.FindActiveUploadSession(ownerID, req.Sha256, req.Size, now)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		if session != nil {
+			uploaded, err := s.uploadedChunks(session.UploadId)
+			if err != nil {
+				return nil, err
+			}
+			return &chatRespond.InitUploadRespond{
+				UploadId:       session.UploadId,
+				ChunkSize:      session.ChunkSize,
+				TotalChunks:    session.TotalChunks,
+				UploadedChunks: uploaded,
+				ExpireAt:       session.ExpireAt.Format(time.RFC3339),
+			}, nil
+		}
+	}
+
+	chunkSize := config.GetConfig().UploadConfig.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	session := &chatEntity.UploadSession{
+		UploadId:    util.GenerateID("UP"),
+		OwnerId:     ownerID,
+		Category:    req.Category,
+		FileName:    req.FileName,
+		MimeType:    req.MimeType,
+		Size:        req.Size,
+		Sha256:      req.Sha256,
+		ChunkSize:   chunkSize,
+		TotalChunks: int((req.Size + chunkSize - 1) / chunkSize),
+		Status:      0,
+		ExpireAt:    now.Add(uploadSessionTTL),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.fileRepo.CreateUploadSession(session); err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	return &chatRespond.InitUploadRespond{
+		UploadId:       session.UploadId,
+		ChunkSize:      session.ChunkSize,
+		TotalChunks:    session.TotalChunks,
+		UploadedChunks: []int{},
+		ExpireAt:       session.ExpireAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *uploadServiceImpl) UploadChunk(ownerID string, uploadID string, index int, header *multipart.FileHeader) error {
+	if ownerID == "" || uploadID == "" || header == nil {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	session, err := s.activeSession(ownerID, uploadID)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= session.TotalChunks {
+		return xerr.New(xerr.BadRequest, "分片序号越界")
+	}
+	// 除最后一片外，每片大小必须等于 chunk_size
+	expected := session.ChunkSize
+	if index == session.TotalChunks-1 {
+		expected = session.Size - session.ChunkSize*int64(session.TotalChunks-1)
+	}
+	if header.Size != expected {
+		return xerr.New(xerr.BadRequest, fmt.Sprintf("分片大小错误，期望 %d 字节", expected))
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	defer f.Close()
+
+	var body io.Reader = f
+	if index == 0 && session.Category == FileCategoryImage {
+		sniff := make([]byte, 512)
+		n, _ := io.ReadFull(f, sniff)
+		if err := checkMime(session.Category, http.DetectContentType(sniff[:n])); err != nil {
+			return err
+		}
+		body = io.MultiReader(bytes.NewReader(sniff[:n]), f)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	if err := s.store.Put(ctx, chunkKey(uploadID, index), body, header.Size, "application/octet-stream"); err != nil {
+		zlog.Error("upload chunk put failed: " + err.Error())
+		return xerr.ErrServerError
+	}
+	if err := s.fileRepo.SaveChunk(&chatEntity.UploadChunk{
+		UploadId:   uploadID,
+		ChunkIndex: index,
+		Size:       header.Size,
+		CreatedAt:  time.Now(),
+	}); err != nil {
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	return nil
+}
+
+func (s *uploadServiceImpl) GetUploadStatus(ownerID string, req chatRequest.UploadStatusRequest) (*chatRespond.UploadStatusRespond, error) {
+	if ownerID == "" || req.UploadId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	session, err := s.ownedSession(ownerID, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := s.uploadedChunks(session.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	return &chatRespond.UploadStatusRespond{
+		UploadId:       session.UploadId,
+		Status:         session.Status,
+		TotalChunks:    session.TotalChunks,
+		UploadedChunks: uploaded,
+		FileId:         session.FileId,
+	}, nil
+}
+
+func (s *uploadServiceImpl) CompleteUpload(ownerID string, req chatRequest.CompleteUploadRequest) (*chatRespond.FileItem, error) {
+	if ownerID == "" || req.UploadId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	session, err := s.ownedSession(ownerID, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	// 重复调用 complete 时返回已生成的文件
+	if session.Status == 1 && session.FileId != "" {
+		file, err := s.fileRepo.GetFileByUUID(session.FileId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+		return s.fileItem(file), nil
+	}
+	if session.Status != 0 || time.Now().After(session.ExpireAt) {
+		return nil, xerr.New(xerr.BadRequest, "上传会话已失效，请重新上传")
+	}
+
+	uploaded, err := s.uploadedChunks(session.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	if len(uploaded) != session.TotalChunks {
+		return nil, xerr.New(xerr.BadRequest, fmt.Sprintf("分片未上传完整（%d/%d）", len(uploaded), session.TotalChunks))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	// 计算摘要的同时按实际内容嗅探类型，不使用客户端声明的 mime
+	h := sha256.New()
+	rc := s.openChunks(ctx, session)
+	sniff := make([]byte, 512)
+	n, _ := io.ReadFull(rc, sniff)
+	h.Write(sniff[:n])
+	if _, err := io.Copy(h, rc); err != nil {
+		_ = rc.Close()
+		zlog.Error("read upload chunks failed: " + err.Error())
+		return nil, xerr.ErrServerError
+	}
+	_ = rc.Close()
+	sum := hex.EncodeToString(h.Sum(nil))
+	if session.Sha256 != "" && session.Sha256 != sum {
+		_ = s.fileRepo.UpdateUploadSession(session.UploadId, map[string]interface{}{"status": 2, "updated_at": time.Now()})
+		s.cleanupChunks(session)
+		return nil, xerr.New(xerr.BadRequest, "文件校验失败，sha256 不匹配")
+	}
+	mimeType := http.DetectContentType(sniff[:n])
+	if err := checkMime(session.Category, mimeType); err != nil {
+		_ = s.fileRepo.UpdateUploadSession(session.UploadId, map[string]interface{}{"status": 2, "updated_at": time.Now()})
+		s.cleanupChunks(session)
+		return nil, err
+	}
+
+	key, err := s.ensureObject(sum, session.Size, mimeType, func() (io.ReadCloser, error) {
+		return s.openChunks(ctx, session), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ext := fileExt(session.FileName)
+	file, err := s.createFile(ownerID, session.Category, session.FileName, ext, mimeType, session.Size, sum, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fileRepo.UpdateUploadSession(session.UploadId, map[string]interface{}{
+		"status":     1,
+		"file_id":    file.Uuid,
+		"updated_at": time.Now(),
+	}); err != nil {
+		zlog.Error(err.Error())
+	}
+	s.cleanupChunks(session)
+	return s.fileItem(file), nil
+}
+
+func (s *uploadServiceImpl) GetFileUrl(callerID string, req chatRequest.GetFileUrlRequest) (*chatRespond.FileItem, error) {
+	if callerID == "" || req.FileId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	file, err := s.fileRepo.GetFileByUUID(req.FileId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "文件不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if file.OwnerId != callerID && file.Category != FileCategoryAvatar {
+		ok, err := s.canAccessViaMessage(callerID, file.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, xerr.New(xerr.Forbidden, "无权访问该文件")
+		}
+	}
+	item := s.fileItem(file)
+	if item.Url == "" {
+		return nil, xerr.ErrServerError
+	}
+	return item, nil
+}
+
+func (s *uploadServiceImpl) AvatarURL(fileID string) (string, error) {
+	file, err := s.fileRepo.GetFileByUUID(fileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", xerr.New(xerr.NotFound, "文件不存在")
+		}
+		zlog.Error(err.Error())
+		return "", xerr.ErrServerError
+	}
+	if file.Category != FileCategoryAvatar {
+		return "", xerr.New(xerr.NotFound, "文件不存在")
+	}
+	url := s.signURL(file)
+	if url == "" {
+		return "", xerr.ErrServerError
+	}
+	return url, nil
+}
+
+func (s *uploadServiceImpl) ResolveOwnedFile(ownerID string, fileID string) (*chatEntity.FileObject, error) {
+	file, err := s.fileRepo.GetFileByUUID(fileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "文件不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if file.OwnerId != ownerID {
+		return nil, xerr.New(xerr.Forbidden, "无权引用该文件")
+	}
+	return file, nil
+}
+
+func (s *uploadServiceImpl) ResolveFile(fileID string) (*chatEntity.FileObject, error) {
+	file, err := s.fileRepo.GetFileByUUID(fileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "文件不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	return file, nil
+}
+
+func (s *uploadServiceImpl) FileURL(fileID string) string {
+	if fileID == "" {
+		return ""
+	}
+	file, err := s.fileRepo.GetFileByUUID(fileID)
+	if err != nil {
+		zlog.Error("get file failed: " + err.Error())
+		return ""
+	}
+	return s.signURL(file)
+}
+
+// canAccessViaMessage 调用者能看到任一引用该文件的消息即可下载
+func (s *uploadServiceImpl) canAccessViaMessage(callerID string, fileID string) (bool, error) {
+	msgs, err := s.messageRepo.ListByFileID(fileID, 50)
+	if err != nil {
+		zlog.Error(err.Error())
+		return false, xerr.ErrServerError
+	}
+	checkedGroups := make(map[string]struct{})
+	for _, m := range msgs {
+		if m.IsRecalled {
+			continue
+		}
+		if !strings.HasPrefix(m.ReceiveId, "G") {
+			if m.SendId == callerID || m.ReceiveId == callerID {
+				return true, nil
+			}
+			continue
+		}
+		if _, ok := checkedGroups[m.ReceiveId]; ok {
+			continue
+		}
+		checkedGroups[m.ReceiveId] = struct{}{}
+		rel, err := s.contactRepo.GetUserContactByUserIDAndContactIDAndType(callerID, m.ReceiveId, 1)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			zlog.Error(err.Error())
+			return false, xerr.ErrServerError
+		}
+		if rel.Status == 0 || rel.Status == 5 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ensureObject 保证内容为 sum 的对象已存在于存储中，返回对象 key；已存在时不再重复写入
+func (s *uploadServiceImpl) ensureObject(sum string, size int64, mimeType string, open func() (io.ReadCloser, error)) (string, error) {
+	existing, err := s.fileRepo.FindBySha256(sum, size)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		zlog.Error(err.Error())
+		return "", xerr.ErrServerError
+	}
+	if existing != nil && s.objectExists(existing.StorageKey) {
+		return existing.StorageKey, nil
+	}
+
+	key := objectKey(sum)
+	rc, err := open()
+	if err != nil {
+		zlog.Error(err.Error())
+		return "", xerr.ErrServerError
+	}
+	defer rc.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	if err := s.store.Put(ctx, key, rc, size, mimeType); err != nil {
+		zlog.Error("storage put failed: " + err.Error())
+		return "", xerr.ErrServerError
+	}
+	return key, nil
+}
+
+func (s *uploadServiceImpl) objectExists(key string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ok, err := s.store.Exists(ctx, key)
+	if err != nil {
+		zlog.Error("storage stat failed: " + err.Error())
+		return false
+	}
+	return ok
+}
+
+func (s *uploadServiceImpl) createFile(ownerID, category, fileName, ext, mimeType string, size int64, sum string, key string) (*chatEntity.FileObject, error) {
+	file := &chatEntity.FileObject{
+		Uuid:       util.GenerateID("F"),
+		OwnerId:    ownerID,
+		Category:   category,
+		FileName:   fileName,
+		FileType:   ext,
+		MimeType:   mimeType,
+		Size:       size,
+		Sha256:     sum,
+		StorageKey: key,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.fileRepo.CreateFile(file); err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	return file, nil
+}
+
+func (s *uploadServiceImpl) fileItem(file *chatEntity.FileObject) *chatRespond.FileItem {
+	item := &chatRespond.FileItem{
+		FileId:    file.Uuid,
+		FileName:  file.FileName,
+		FileType:  file.FileType,
+		MimeType:  file.MimeType,
+		Size:      file.Size,
+		Sha256:    file.Sha256,
+		Category:  file.Category,
+		CreatedAt: file.CreatedAt.Format(time.RFC3339),
+	}
+	item.Url = s.signURL(file)
+	if item.Url != "" {
+		item.ExpireAt = time.Now().Add(urlExpire()).Format(time.RFC3339)
+	}
+	return item
+}
+
+func (s *uploadServiceImpl) signURL(file *chatEntity.FileObject) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	url, err := s.store.SignedURL(ctx, file.StorageKey, urlExpire(), file.FileName)
+	if err != nil {
+		zlog.Error("sign file url failed: " + err.Error())
+		return ""
+	}
+	return url
+}
+
+func (s *uploadServiceImpl) ownedSession(ownerID string, uploadID string) (*chatEntity.UploadSession, error) {
+	session, err := s.fileRepo.GetUploadSession(uploadID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "上传会话不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if session.OwnerId != ownerID {
+		return nil, xerr.New(xerr.NotFound, "上传会话不存在")
+	}
+	return session, nil
+}
+
+func (s *uploadServiceImpl) activeSession(ownerID string, uploadID string) (*chatEntity.UploadSession, error) {
+	session, err := s.ownedSession(ownerID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != 0 || time.Now().After(session.ExpireAt) {
+		return nil, xerr.New(xerr.BadRequest, "上传会话已失效，请重新上传")
+	}
+	return session, nil
+}
+
+func (s *uploadServiceImpl) uploadedChunks(uploadID string) ([]int, error) {
+	chunks, err := s.fileRepo.ListChunks(uploadID)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	out := make([]int, 0, len(chunks))
+	for _, c := range chunks {
+		out = append(out, c.ChunkIndex)
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// openChunks 按序号顺序拼接所有分片为一个流
+func (s *uploadServiceImpl) openChunks(ctx context.Context, session *chatEntity.UploadSession) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < session.TotalChunks; i++ {
+			rc, err := s.store.Get(ctx, chunkKey(session.UploadId, i))
+			if err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+			_, err = io.Copy(pw, rc)
+			_ = rc.Close()
+			if err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+		}
+		_ = pw.Close()
+	}()
+	return pr
+}
+
+func (s *uploadServiceImpl) cleanupChunks(session *chatEntity.UploadSession) {
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	for i := 0; i < session.TotalChunks; i++ {
+		if err := s.store.Delete(ctx, chunkKey(session.UploadId, i)); err != nil {
+			zlog.Warn("delete upload chunk failed: " + err.Error())
+		}
+	}
+	if err := s.fileRepo.DeleteChunks(session.UploadId); err != nil {
+		zlog.Warn("delete upload chunk records failed: " + err.Error())
+	}
+}
+
+// checkFileLimit 按分类校验扩展名与大小，返回小写扩展名
+func checkFileLimit(category string, fileName string, size int64) (string, error) {
+	conf := config.GetConfig().UploadConfig
+	ext := fileExt(fileName)
+	for _, blocked := range conf.BlockedExts {
+		if strings.EqualFold(ext, strings.TrimPrefix(blocked, ".")) {
+			return "", xerr.New(xerr.BadRequest, "不支持上传该类型的文件")
+		}
+	}
+
+	var limit int64
+	switch category {
+	case FileCategoryImage, FileCategoryAvatar:
+		if _, ok := imageExts[ext]; !ok {
+			return "", xerr.New(xerr.BadRequest, "仅支持 jpg/png/gif/webp/bmp/heic 图片")
+		}
+		limit = orDefault(conf.MaxImageSize, defaultMaxImageSize)
+		if category == FileCategoryAvatar {
+			limit = orDefault(conf.MaxAvatarSize, defaultMaxAvatarSize)
+		}
+	case FileCategoryVoice:
+		if _, ok := voiceExts[ext]; !ok {
+			return "", xerr.New(xerr.BadRequest, "不支持的语音格式")
+		}
+		limit = orDefault(conf.MaxVoiceSize, defaultMaxVoiceSize)
+	case FileCategoryFile:
+		limit = orDefault(conf.MaxFileSize, defaultMaxFileSize)
+	default:
+		return "", xerr.New(xerr.BadRequest, "不支持的文件分类")
+	}
+	if size <= 0 {
+		return "", xerr.New(xerr.BadRequest, "文件不能为空")
+	}
+	if size > limit {
+		return "", xerr.New(xerr.BadRequest, "文件大小超过限制（最大 "+strconv.FormatInt(limit>>20, 10)+"MB）")
+	}
+	return ext, nil
+}
+
+// checkMime 图片类文件按内容嗅探结果校验，防止伪造扩展名
+func checkMime(category string, mimeType string) error {
+	if (category == FileCategoryImage || category == FileCategoryAvatar) && !strings.HasPrefix(mimeType, "image/") {
+		return xerr.New(xerr.BadRequest, "文件内容不是有效的图片")
+	}
+	return nil
+}
+
+func fileExt(fileName string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), "."))
+}
+
+func isSha256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func orDefault(v int64, def int64) int64 {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func urlExpire() time.Duration {
+	if sec := config.GetConfig().UploadConfig.UrlExpireSeconds; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultURLExpire
+}
+
+func objectKey(sum string) string {
+	return "objects/" + sum[:2] + "/" + sum
+}
+
+func chunkKey(uploadID string, index int) string {
+	return "chunks/" + uploadID + "/" + strconv.Itoa(index)
+}
+
+// avatarPath 头像的长期访问地址，访问时重定向到签名地址
+func avatarPath(fileID string) string {
+	return strings.TrimRight(config.GetConfig().UploadConfig.PublicBaseUrl, "/") + "/file/avatar/" + fileID
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// FileObject 用户名下的文件记录。同一内容（Sha256）只存储一份对象，
+// 不同用户上传相同内容时各自拥有一条记录、共享 StorageKey（服务端校验摘要后去重）
+type FileObject struct {
+	Id         int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	Uuid       string    `gorm:"column:uuid;uniqueIndex;type:char(20);not null;comment:文件uuid"`
+	OwnerId    string    `gorm:"column:owner_id;index;type:char(20);not null;comment:上传者uuid"`
+	Category   string    `gorm:"column:category;type:varchar(10);not null;comment:文件分类，image/voice/file/avatar"`
+	FileName   string    `gorm:"column:file_name;type:varchar(255);not null;comment:文件名"`
+	FileType   string    `gorm:"column:file_type;type:varchar(10);comment:文件扩展名"`
+	MimeType   string    `gorm:"column:mime_type;type:varchar(100);comment:MIME 类型"`
+	Size       int64     `gorm:"column:size;index:idx_sha_size,priority:2;not null;comment:文件大小（字节）"`
+	Sha256     string    `gorm:"column:sha256;index:idx_sha_size,priority:1;type:char(64);not null;comment:内容摘要"`
+	StorageKey string    `gorm:"column:storage_key;type:varchar(255);not null;comment:存储对象key"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;comment:创建时间"`
+}
+
+func (FileObject) TableName() string {
+	return "file_object"
+}
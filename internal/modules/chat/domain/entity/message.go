@@ -2,29 +2,58 @@ package entity
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 )
 
+// Message 消息表。ConvId + Seq 构成会话内单调递增的序列号，用于断线重连后的增量同步；
+// SendId + ClientMsgId 唯一，用于客户端重试去重（老数据 ClientMsgId 为 NULL）；
+// 引用回复时 ReplyTo* 冗余保存被引用消息的摘要，ThreadRootUuid 指向回复链最顶层的消息，用于群话题
 type Message struct {
-	Id         int64        `gorm:"column:id;primaryKey;comment:自增id"`
-	Uuid       string       `gorm:"column:uuid;uniqueIndex;type:char(20);not null;comment:消息uuid"`
-	SessionId  string       `gorm:"column:session_id;index;type:char(20);not null;comment:会话uuid"`
-	Type       int8         `gorm:"column:type;not null;comment:消息类型，0.文本，1.语音，2.文件，3.通话"` // 通话不用存消息内容或者url
-	Content    string       `gorm:"column:content;type:TEXT;comment:消息内容"`
-	Url        string       `gorm:"column:url;type:char(255);comment:消息url"`
-	SendId     string       `gorm:"column:send_id;index;type:char(20);not null;comment:发送者uuid"`
-	SendName   string       `gorm:"column:send_name;type:varchar(20);not null;comment:发送者昵称"`
-	SendAvatar string       `gorm:"column:send_avatar;type:varchar(255);not null;comment:发送者头像"`
-	ReceiveId  string       `gorm:"column:receive_id;index;type:char(20);not null;comment:接受者uuid"`
-	FileType   string       `gorm:"column:file_type;type:char(10);comment:文件类型"`
-	FileName   string       `gorm:"column:file_name;type:varchar(50);comment:文件名"`
-	FileSize   string       `gorm:"column:file_size;type:char(20);comment:文件大小"`
-	Status     int8         `gorm:"column:status;not null;comment:状态，0.未发送，1.已发送"`
-	CreatedAt  time.Time    `gorm:"column:created_at;not null;comment:创建时间"`
-	SendAt     sql.NullTime `gorm:"column:send_at;comment:发送时间"`
-	AVdata     string       `gorm:"column:av_data;comment:通话传递数据"`
+	Id             int64          `gorm:"column:id;primaryKey;comment:自增id"`
+	Uuid           string         `gorm:"column:uuid;uniqueIndex;type:char(20);not null;comment:消息uuid"`
+	SessionId      string         `gorm:"column:session_id;index;type:char(20);not null;comment:会话uuid"`
+	Type           int8           `gorm:"column:type;not null;comment:消息类型，0.文本，1.语音，2.文件，3.通话，4.合并转发的聊天记录，5.群系统消息"` // 通话不用存消息内容或者url
+	Content        string         `gorm:"column:content;type:TEXT;comment:消息内容"`
+	Url            string         `gorm:"column:url;type:char(255);comment:消息url"`
+	SendId         string         `gorm:"column:send_id;index;uniqueIndex:uk_send_client_msg,priority:1;type:char(20);not null;comment:发送者uuid"`
+	SendName       string         `gorm:"column:send_name;type:varchar(20);not null;comment:发送者昵称"`
+	SendAvatar     string         `gorm:"column:send_avatar;type:varchar(255);not null;comment:发送者头像"`
+	ReceiveId      string         `gorm:"column:receive_id;index;type:char(20);not null;comment:接受者uuid"`
+	FileType       string         `gorm:"column:file_type;type:char(10);comment:文件类型"`
+	FileName       string         `gorm:"column:file_name;type:varchar(50);comment:文件名"`
+	FileSize       string         `gorm:"column:file_size;type:char(20);comment:文件大小"`
+	FileId         string         `gorm:"column:file_id;index;type:char(20);not null;default:'';comment:引用的文件uuid（file_object）"`
+	Status         int8           `gorm:"column:status;not null;comment:状态，0.未发送，1.已发送，2.已读"`
+	CreatedAt      time.Time      `gorm:"column:created_at;not null;comment:创建时间"`
+	SendAt         sql.NullTime   `gorm:"column:send_at;comment:发送时间"`
+	AVdata         string         `gorm:"column:av_data;comment:通话传递数据"`
+	ForwardData    string         `gorm:"column:forward_data;type:MEDIUMTEXT;comment:合并转发的聊天记录（JSON），仅 type=4"`
+	IsRecalled     bool           `gorm:"column:is_recalled;not null;default:false;comment:是否已撤回"`
+	RecalledAt     sql.NullTime   `gorm:"column:recalled_at;comment:撤回时间"`
+	IsEdited       bool           `gorm:"column:is_edited;not null;default:false;comment:是否被编辑过"`
+	EditedAt       sql.NullTime   `gorm:"column:edited_at;comment:最后编辑时间"`
+	ReplyToUuid    string         `gorm:"column:reply_to_uuid;index;type:char(20);not null;default:'';comment:引用回复的消息uuid"`
+	ReplySendId    string         `gorm:"column:reply_send_id;type:char(20);not null;default:'';comment:被引用消息的发送者uuid"`
+	ReplySendName  string         `gorm:"column:reply_send_name;type:varchar(20);not null;default:'';comment:被引用消息的发送者昵称"`
+	ReplySnippet   string         `gorm:"column:reply_snippet;type:varchar(255);not null;default:'';comment:被引用消息摘要（冗余存储，原消息撤回/编辑时同步）"`
+	ThreadRootUuid string         `gorm:"column:thread_root_uuid;index;type:char(20);not null;default:'';comment:所属话题的根消息uuid"`
+	ConvId         string         `gorm:"column:conv_id;index:idx_conv_seq,priority:1;type:varchar(64);not null;default:'';comment:会话序列号归属（群组uuid 或 私聊双方uuid排序拼接）"`
+	Seq            int64          `gorm:"column:seq;index:idx_conv_seq,priority:2;not null;default:0;comment:会话内序列号"`
+	ClientMsgId    sql.NullString `gorm:"column:client_msg_id;uniqueIndex:uk_send_client_msg,priority:2;type:varchar(64);comment:客户端消息ID"`
 }
 
 func (Message) TableName() string {
 	return "message"
 }
+
+// ConvIdOf 计算消息所属的序列号会话：群聊为群组uuid，私聊为双方uuid按字典序拼接，保证双方看到的是同一序列
+func ConvIdOf(sendID string, receiveID string) string {
+	if strings.HasPrefix(receiveID, "G") {
+		return receiveID
+	}
+	if sendID > receiveID {
+		sendID, receiveID = receiveID, sendID
+	}
+	return sendID + "_" + receiveID
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// MessageEditHistory 消息编辑历史，每次编辑前的内容存为一个版本，Version 从1开始递增
+type MessageEditHistory struct {
+	Id          int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	MessageUuid string    `gorm:"column:message_uuid;uniqueIndex:uk_message_version,priority:1;type:char(20);not null;comment:消息uuid"`
+	Version     int       `gorm:"column:version;uniqueIndex:uk_message_version,priority:2;not null;comment:版本号"`
+	Content     string    `gorm:"column:content;type:TEXT;comment:该版本的内容"`
+	EditorId    string    `gorm:"column:editor_id;type:char(20);not null;comment:编辑者uuid"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;comment:该版本被替换的时间"`
+}
+
+func (MessageEditHistory) TableName() string {
+	return "message_edit_history"
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+)
+
+// MessageReaction 消息表情回应表，同一用户对同一消息可回应多个不同表情
+type MessageReaction struct {
+	Id          int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	MessageUuid string    `gorm:"column:message_uuid;uniqueIndex:uk_message_user_emoji,priority:1;type:char(20);not null;comment:消息uuid"`
+	UserId      string    `gorm:"column:user_id;uniqueIndex:uk_message_user_emoji,priority:2;index;type:char(20);not null;comment:回应用户uuid"`
+	Emoji       string    `gorm:"column:emoji;uniqueIndex:uk_message_user_emoji,priority:3;type:varchar(32);not null;comment:表情"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;comment:创建时间"`
+}
+
+func (MessageReaction) TableName() string {
+	return "message_reaction"
+}
@@ -0,0 +1,11 @@
+package entity
+
+// MessageSeq 会话序列号计数器，每个 ConvId 一行，写消息时在同一事务内自增
+type MessageSeq struct {
+	ConvId string `gorm:"column:conv_id;primaryKey;type:varchar(64);comment:会话序列号归属"`
+	Seq    int64  `gorm:"column:seq;not null;default:0;comment:当前最大序列号"`
+}
+
+func (MessageSeq) TableName() string {
+	return "message_seq"
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+)
+
+// SessionReadCursor 会话已读游标，每个 (用户, 会话对端) 一条
+type SessionReadCursor struct {
+	Id                  int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	UserId              string    `gorm:"column:user_id;uniqueIndex:uk_user_target;type:char(20);not null;comment:用户uuid"`
+	TargetId            string    `gorm:"column:target_id;uniqueIndex:uk_user_target;type:char(20);not null;comment:会话对端uuid（用户或群组）"`
+	LastReadMessageUuid string    `gorm:"column:last_read_message_uuid;type:char(20);comment:最后已读消息uuid"`
+	LastReadAt          time.Time `gorm:"column:last_read_at;not null;comment:最后已读时间"`
+	UpdatedAt           time.Time `gorm:"column:updated_at;not null;comment:更新时间"`
+}
+
+func (SessionReadCursor) TableName() string {
+	return "session_read_cursor"
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// UploadSession 分片上传会话，Status：0.上传中，1.已完成，2.已取消
+type UploadSession struct {
+	Id          int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	UploadId    string    `gorm:"column:upload_id;uniqueIndex;type:char(20);not null;comment:上传会话id"`
+	OwnerId     string    `gorm:"column:owner_id;index:idx_owner_sha,priority:1;type:char(20);not null;comment:上传者uuid"`
+	Category    string    `gorm:"column:category;type:varchar(10);not null;comment:文件分类"`
+	FileName    string    `gorm:"column:file_name;type:varchar(255);not null;comment:文件名"`
+	MimeType    string    `gorm:"column:mime_type;type:varchar(100);comment:MIME 类型"`
+	Size        int64     `gorm:"column:size;not null;comment:文件总大小（字节）"`
+	Sha256      string    `gorm:"column:sha256;index:idx_owner_sha,priority:2;type:varchar(64);not null;default:'';comment:客户端声明的内容摘要，完成时校验"`
+	ChunkSize   int64     `gorm:"column:chunk_size;not null;comment:分片大小"`
+	TotalChunks int       `gorm:"column:total_chunks;not null;comment:分片总数"`
+	FileId      string    `gorm:"column:file_id;type:char(20);not null;default:'';comment:完成后生成的文件uuid"`
+	Status      int8      `gorm:"column:status;not null;default:0;comment:状态，0.上传中，1.已完成，2.已取消"`
+	ExpireAt    time.Time `gorm:"column:expire_at;not null;comment:过期时间"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;comment:创建时间"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;not null;comment:更新时间"`
+}
+
+func (UploadSession) TableName() string {
+	return "upload_session"
+}
+
+// UploadChunk 已接收的分片，(UploadId, ChunkIndex) 唯一，重复上传同一分片幂等
+type UploadChunk struct {
+	Id         int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	UploadId   string    `gorm:"column:upload_id;uniqueIndex:uk_upload_chunk,priority:1;type:char(20);not null;comment:上传会话id"`
+	ChunkIndex int       `gorm:"column:chunk_index;uniqueIndex:uk_upload_chunk,priority:2;not null;comment:分片序号，从0开始"`
+	Size       int64     `gorm:"column:size;not null;comment:分片大小"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;comment:创建时间"`
+}
+
+func (UploadChunk) TableName() string {
+	return "upload_chunk"
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"time"
+
+	"OmniLink/internal/modules/chat/domain/entity"
+)
+
+type FileRepository interface {
+	CreateFile(file *entity.FileObject) error
+	GetFileByUUID(uuid string) (*entity.FileObject, error)
+	// FindBySha256 查找任意用户已上传的相同内容，仅用于服务端校验摘要后的存储去重
+	FindBySha256(sha256 string, size int64) (*entity.FileObject, error)
+	// FindOwnedBySha256 查找该用户自己上传过的相同内容，客户端声明的摘要只能用于秒传自己的文件
+	FindOwnedBySha256(ownerID string, sha256 string, size int64) (*entity.FileObject, error)
+
+	CreateUploadSession(session *entity.UploadSession) error
+	GetUploadSession(uploadID string) (*entity.UploadSession, error)
+	// FindActiveUploadSession 查找同一用户同一文件未完成且未过期的上传会话，用于断点续传
+	FindActiveUploadSession(ownerID string, sha256 string, size int64, now time.Time) (*entity.UploadSession, error)
+	UpdateUploadSession(uploadID string, updates map[string]interface{}) error
+
+	// SaveChunk 记录已接收的分片，重复分片忽略
+	SaveChunk(chunk *entity.UploadChunk) error
+	ListChunks(uploadID string) ([]entity.UploadChunk, error)
+	DeleteChunks(uploadID string) error
+}
@@ -1,9 +1,15 @@
 package repository
 
-import "OmniLink/internal/modules/chat/domain/entity"
+import (
+	"time"
+
+	"OmniLink/internal/modules/chat/domain/entity"
+)
 
 type MessageMentionRepository interface {
 	CreateBatch(mentions []*entity.MessageMention) error
 	GetMentionsByMessageUUID(messageUUID string) ([]entity.MessageMention, error)
 	GetMentionsByMessageUUIDs(messageUUIDs []string) (map[string][]entity.MessageMention, error)
+	// CountUnreadMentions 按群分组统计晚于各自 since 且 @ 了该用户（含 @全体）的未读消息数，since 为群组uuid -> 起始时间，没有未读的不出现在结果中
+	CountUnreadMentions(userID string, since map[string]time.Time) (map[string]int64, error)
 }
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"OmniLink/internal/modules/chat/domain/entity"
+)
+
+// ReactionCount 某条消息上一个表情的聚合结果
+type ReactionCount struct {
+	MessageUuid string
+	Emoji       string
+	Count       int64
+}
+
+type MessageReactionRepository interface {
+	// Add 添加回应，已存在时不重复写入，返回是否新增
+	Add(reaction *entity.MessageReaction) (bool, error)
+	// Remove 取消回应，返回是否实际删除
+	Remove(messageUUID string, userID string, emoji string) (bool, error)
+	// CountByUser 统计用户在某条消息上的回应数
+	CountByUser(messageUUID string, userID string) (int64, error)
+	// CountByEmoji 统计某条消息上指定表情的回应人数
+	CountByEmoji(messageUUID string, emoji string) (int64, error)
+	// Aggregate 按消息、表情聚合回应人数，按首次回应时间排序
+	Aggregate(messageUUIDs []string) ([]ReactionCount, error)
+	// ListByUser 返回用户在这些消息上的回应，用于标记“我已回应”
+	ListByUser(messageUUIDs []string, userID string) ([]entity.MessageReaction, error)
+}
@@ -2,15 +2,55 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"OmniLink/internal/modules/chat/domain/entity"
 )
 
+// EditContent 加锁后复核的失败原因
+var (
+	ErrMessageRecalled    = errors.New("message recalled")
+	ErrMessageNotAuthor   = errors.New("message editor is not the author")
+	ErrMessageEditExpired = errors.New("message edit window expired")
+)
+
 type MessageRepository interface {
 	ListPrivateMessages(userOneID string, userTwoID string, page int, pageSize int) ([]entity.Message, error)
 	ListGroupMessages(groupID string, page int, pageSize int) ([]entity.Message, error)
+	// Create 写入消息，并在同一事务内为其分配会话内序列号（回填 ConvId/Seq）
 	Create(message *entity.Message) error
+	// ListAfterSeq 按会话游标拉取序列号之后的消息，跨会话按写入顺序返回
+	ListAfterSeq(cursors map[string]int64, limit int) ([]entity.Message, error)
+	// BackfillSeq 为引入序列号之前写入的消息（conv_id 为空）按写入顺序补分配 ConvId/Seq，返回本批处理的条数，少于 batch 表示已全部回填
+	BackfillSeq(batch int) (int, error)
+	GetByUUID(uuid string) (*entity.Message, error)
+	// ListByUUIDs 批量查询消息，按时间升序返回，不存在的直接忽略
+	ListByUUIDs(uuids []string) ([]entity.Message, error)
+	// ListTextAfterID 按自增 id 顺序分批拉取未撤回的文本消息，用于重建检索索引
+	ListTextAfterID(afterID int64, limit int) ([]entity.Message, error)
+	// ListByFileID 查询引用了指定文件的消息，用于校验文件下载权限
+	ListByFileID(fileID string, limit int) ([]entity.Message, error)
+	GetBySendAndClientMsgID(sendID string, clientMsgID string) (*entity.Message, error)
+	// MarkRecalled 将消息标记为已撤回（保留原记录，不物理删除），消息已被撤回时返回 false
+	MarkRecalled(uuid string, recalledAt time.Time) (bool, error)
+	// EditContent 在同一事务内把当前内容存为历史版本并替换为新内容，返回新的历史版本号。
+	// 加锁后复核撤回状态、作者与编辑时限（创建时间早于 notBefore 视为超时），不满足时返回对应的 Err*
+	EditContent(uuid string, content string, editorID string, notBefore time.Time, editedAt time.Time) (int, error)
+	// ListEditHistory 按版本号升序返回消息的历史版本
+	ListEditHistory(uuid string) ([]entity.MessageEditHistory, error)
+	// UpdateReplySnippet 被引用消息撤回或编辑后，同步更新引用它的消息中冗余的摘要
+	UpdateReplySnippet(replyToUUID string, snippet string) error
+	// ListThreadReplies 按时间升序分页返回话题根消息下的全部回复
+	ListThreadReplies(rootUUID string, page int, pageSize int) ([]entity.Message, error)
+	// CountThreadReplies 统计群内各话题根消息下未撤回的回复数，没有回复的不出现在结果中
+	CountThreadReplies(groupID string, rootUUIDs []string) (map[string]int64, error)
+	// CountUnreadPrivates 按好友分组统计其发给 userID、晚于各自 since 的未读消息数，since 为好友uuid -> 起始时间，没有未读的不出现在结果中
+	CountUnreadPrivates(userID string, since map[string]time.Time) (map[string]int64, error)
+	// CountUnreadGroups 按群分组统计群内他人发送、晚于各自 since 的未读消息数，since 为群组uuid -> 起始时间
+	CountUnreadGroups(userID string, since map[string]time.Time) (map[string]int64, error)
+	// MarkPrivateRead 将 sendID 发给 receiveID、不晚于 until 的消息状态推进为已读，返回受影响条数
+	MarkPrivateRead(sendID string, receiveID string, until time.Time) (int64, error)
 	// GetMessagesForUserAfter 获取指定时间后，用户接收到的所有消息（私聊+群聊）
 	GetMessagesForUserAfter(ctx context.Context, userID string, groupIDs []string, since time.Time, limit int) ([]entity.Message, error)
 }
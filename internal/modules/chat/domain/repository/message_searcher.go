@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"OmniLink/internal/modules/chat/domain/entity"
+)
+
+// MessageSearchQuery 消息检索条件。可见范围由调用方按成员关系算好后传入，实现只负责按范围过滤
+type MessageSearchQuery struct {
+	UserId   string               // 私聊：只检索该用户收发的消息
+	Groups   map[string]time.Time // 群聊：可检索的群 -> 入群时间，只检索入群之后的消息
+	Terms    []string             // 关键词，按空白切分，需全部命中
+	SenderId string               // 限定发送者
+	TargetId string               // 限定会话：私聊对端 uuid 或群组 uuid
+	Since    *time.Time
+	Until    *time.Time
+	Offset   int
+	Limit    int
+}
+
+// MessageSearcher 文本消息全文检索，按时间倒序返回命中的消息与总数
+type MessageSearcher interface {
+	Search(ctx context.Context, q MessageSearchQuery) ([]entity.Message, int64, error)
+	// Index 新消息或编辑后写入索引；基于数据库索引的实现为空操作
+	Index(msg *entity.Message)
+	// Remove 撤回后移出索引；基于数据库索引的实现为空操作
+	Remove(uuid string)
+}
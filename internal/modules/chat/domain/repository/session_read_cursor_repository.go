@@ -0,0 +1,10 @@
+package repository
+
+import "OmniLink/internal/modules/chat/domain/entity"
+
+type SessionReadCursorRepository interface {
+	Get(userID string, targetID string) (*entity.SessionReadCursor, error)
+	ListByUserID(userID string) ([]entity.SessionReadCursor, error)
+	// Advance 推进已读游标，只会向后移动，不会回退
+	Advance(cursor *entity.SessionReadCursor) error
+}
@@ -12,4 +12,8 @@ type SessionRepository interface {
 	Create(session *entity.Session) error
 	CreateMany(sessions []*entity.Session) error
 	UpdateLastMessageBySendAndReceive(sendID string, receiveID string, lastMessage string, lastMessageAt time.Time) error
+	// DeleteBetween 软删除两个用户之间双方的私聊会话（删除好友时调用）
+	DeleteBetween(userA string, userB string) error
+	// UpdateReceiverProfile 同步所有指向该接收方的会话名称与头像（群资料修改时调用）
+	UpdateReceiverProfile(receiveID string, name string, avatar string) error
 }
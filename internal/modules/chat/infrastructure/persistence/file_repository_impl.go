@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"time"
+
+	"OmniLink/internal/modules/chat/domain/entity"
+	"OmniLink/internal/modules/chat/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type fileRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewFileRepository(db *gorm.DB) repository.FileRepository {
+	return &fileRepositoryImpl{db: db}
+}
+
+func (r *fileRepositoryImpl) CreateFile(file *entity.FileObject) error {
+	return r.db.Create(file).Error
+}
+
+func (r *fileRepositoryImpl) GetFileByUUID(uuid string) (*entity.FileObject, error) {
+	var file entity.FileObject
+	if err := r.db.Where("uuid = ?", uuid).First(&file).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *fileRepositoryImpl) FindBySha256(sha256 string, size int64) (*entity.FileObject, error) {
+	var file entity.FileObject
+	if err := r.db.Where("sha256 = ? AND size = ?", sha256, size).Order("id ASC").First(&file).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *fileRepositoryImpl) FindOwnedBySha256(ownerID string, sha256 string, size int64) (*entity.FileObject, error) {
+	var file entity.FileObject
+	if err := r.db.Where("owner_id = ? AND sha256 = ? AND size = ?", ownerID, sha256, size).Order("id ASC").First(&file).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *fileRepositoryImpl) CreateUploadSession(session *entity.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *fileRepositoryImpl) GetUploadSession(uploadID string) (*entity.UploadSession, error) {
+	var session entity.UploadSession
+	if err := r.db.Where("upload_id = ?", uploadID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *fileRepositoryImpl) FindActiveUploadSession(ownerID string, sha256 string, size int64, now time.Time) (*entity.UploadSession, error) {
+	var session entity.UploadSession
+	err := r.db.Where("owner_id = ? AND sha256 = ? AND size = ? AND status = 0 AND expire_at > ?", ownerID, sha256, size, now).
+		Order("id DESC").
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *fileRepositoryImpl) UpdateUploadSession(uploadID string, updates map[string]interface{}) error {
+	return r.db.Model(&entity.UploadSession{}).Where("upload_id = ?", uploadID).Updates(updates).Error
+}
+
+func (r *fileRepositoryImpl) SaveChunk(chunk *entity.UploadChunk) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(chunk).Error
+}
+
+func (r *fileRepositoryImpl) ListChunks(uploadID string) ([]entity.UploadChunk, error) {
+	var chunks []entity.UploadChunk
+	err := r.db.Where("upload_id = ?", uploadID).Order("chunk_index ASC").Find(&chunks).Error
+	return chunks, err
+}
+
+func (r *fileRepositoryImpl) DeleteChunks(uploadID string) error {
+	return r.db.Where("upload_id = ?", uploadID).Delete(&entity.UploadChunk{}).Error
+}
@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	"time"
+
 	"OmniLink/internal/modules/chat/domain/entity"
 	"OmniLink/internal/modules/chat/domain/repository"
 
@@ -44,3 +46,14 @@ func (r *messageMentionRepositoryImpl) GetMentionsByMessageUUIDs(messageUUIDs []
 	}
 	return result, nil
 }
+
+func (r *messageMentionRepositoryImpl) CountUnreadMentions(userID string, since map[string]time.Time) (map[string]int64, error) {
+	return countSince(since, func(table string, args []interface{}) (map[string]int64, error) {
+		return scanPeerCounts(r.db.Table("message_mention AS mm").
+			Select("c.peer_id, COUNT(DISTINCT mm.message_uuid) AS cnt").
+			Joins("JOIN message AS m ON m.uuid = mm.message_uuid").
+			Joins("JOIN "+table+" ON mm.session_id = c.peer_id AND m.created_at > c.since", args...).
+			Where("(mm.mentioned_user_id = ? OR mm.mention_type = ?)", userID, 1).
+			Where("m.send_id <> ? AND m.is_recalled = ?", userID, false))
+	})
+}
@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"OmniLink/internal/modules/chat/domain/entity"
+	"OmniLink/internal/modules/chat/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type messageReactionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMessageReactionRepository(db *gorm.DB) repository.MessageReactionRepository {
+	return &messageReactionRepositoryImpl{db: db}
+}
+
+func (r *messageReactionRepositoryImpl) Add(reaction *entity.MessageReaction) (bool, error) {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *messageReactionRepositoryImpl) Remove(messageUUID string, userID string, emoji string) (bool, error) {
+	res := r.db.Where("message_uuid = ? AND user_id = ? AND emoji = ?", messageUUID, userID, emoji).
+		Delete(&entity.MessageReaction{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *messageReactionRepositoryImpl) CountByUser(messageUUID string, userID string) (int64, error) {
+	var cnt int64
+	err := r.db.Model(&entity.MessageReaction{}).
+		Where("message_uuid = ? AND user_id = ?", messageUUID, userID).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+func (r *messageReactionRepositoryImpl) CountByEmoji(messageUUID string, emoji string) (int64, error) {
+	var cnt int64
+	err := r.db.Model(&entity.MessageReaction{}).
+		Where("message_uuid = ? AND emoji = ?", messageUUID, emoji).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+func (r *messageReactionRepositoryImpl) Aggregate(messageUUIDs []string) ([]repository.ReactionCount, error) {
+	if len(messageUUIDs) == 0 {
+		return nil, nil
+	}
+	var rows []repository.ReactionCount
+	err := r.db.Model(&entity.MessageReaction{}).
+		Select("message_uuid, emoji, COUNT(*) AS count").
+		Where("message_uuid IN ?", messageUUIDs).
+		Group("message_uuid, emoji").
+		Order("MIN(id) ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *messageReactionRepositoryImpl) ListByUser(messageUUIDs []string, userID string) ([]entity.MessageReaction, error) {
+	if len(messageUUIDs) == 0 {
+		return nil, nil
+	}
+	var reactions []entity.MessageReaction
+	err := r.db.Where("message_uuid IN ? AND user_id = ?", messageUUIDs, userID).Find(&reactions).Error
+	return reactions, err
+}
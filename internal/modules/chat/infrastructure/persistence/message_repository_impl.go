@@ -2,12 +2,15 @@ package persistence
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"time"
 
 	chatEntity "OmniLink/internal/modules/chat/domain/entity"
 	chatRepository "OmniLink/internal/modules/chat/domain/repository"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type messageRepositoryImpl struct {
@@ -63,7 +66,365 @@ func (r *messageRepositoryImpl) ListGroupMessages(groupID string, page int, page
 }
 
 func (r *messageRepositoryImpl) Create(message *chatEntity.Message) error {
-	return r.db.Create(message).Error
+	if message.ConvId == "" {
+		message.ConvId = chatEntity.ConvIdOf(message.SendId, message.ReceiveId)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 计数器行在事务提交前保持行锁，同一会话的并发写入会按序拿到连续的序列号
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "conv_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"seq": gorm.Expr("seq + 1")}),
+		}).Create(&chatEntity.MessageSeq{ConvId: message.ConvId, Seq: 1}).Error
+		if err != nil {
+			return err
+		}
+		var counter chatEntity.MessageSeq
+		if err := tx.Where("conv_id = ?", message.ConvId).First(&counter).Error; err != nil {
+			return err
+		}
+		message.Seq = counter.Seq
+		return tx.Create(message).Error
+	})
+}
+
+// seqCursorChunk 单条查询最多携带的会话游标数，避免会话很多时拼出超长的 OR 条件
+const seqCursorChunk = 200
+
+func (r *messageRepositoryImpl) ListAfterSeq(cursors map[string]int64, limit int) ([]chatEntity.Message, error) {
+	if len(cursors) == 0 {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+
+	// 从未同步过的会话合并成一个 IN 条件，其余按游标分批查询，每批各取前 limit 条后再按写入顺序归并
+	var fresh []string
+	conds := make([]string, 0, len(cursors))
+	args := make([]interface{}, 0, len(cursors)*2)
+	for convID, seq := range cursors {
+		if seq <= 0 {
+			fresh = append(fresh, convID)
+			continue
+		}
+		conds = append(conds, "(conv_id = ? AND seq > ?)")
+		args = append(args, convID, seq)
+	}
+
+	var msgs []chatEntity.Message
+	query := func(where string, whereArgs ...interface{}) error {
+		var batch []chatEntity.Message
+		if err := r.db.Where(where, whereArgs...).Order("id ASC").Limit(limit).Find(&batch).Error; err != nil {
+			return err
+		}
+		msgs = append(msgs, batch...)
+		return nil
+	}
+	for start := 0; start < len(fresh); start += seqCursorChunk {
+		end := min(start+seqCursorChunk, len(fresh))
+		if err := query("conv_id IN ? AND seq > 0", fresh[start:end]); err != nil {
+			return nil, err
+		}
+	}
+	for start := 0; start < len(conds); start += seqCursorChunk {
+		end := min(start+seqCursorChunk, len(conds))
+		if err := query(strings.Join(conds[start:end], " OR "), args[start*2:end*2]...); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].Id < msgs[j].Id })
+	if len(msgs) > limit {
+		msgs = msgs[:limit]
+	}
+	return msgs, nil
+}
+
+func (r *messageRepositoryImpl) BackfillSeq(batch int) (int, error) {
+	if batch <= 0 {
+		batch = 500
+	}
+	n := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 锁住本批消息，多个节点同时回填时不会重复分配
+		var msgs []chatEntity.Message
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "send_id", "receive_id").
+			Where("conv_id = ?", "").
+			Order("id ASC").
+			Limit(batch).
+			Find(&msgs).Error; err != nil {
+			return err
+		}
+		n = len(msgs)
+
+		byConv := make(map[string][]int64)
+		for _, m := range msgs {
+			convID := chatEntity.ConvIdOf(m.SendId, m.ReceiveId)
+			byConv[convID] = append(byConv[convID], m.Id)
+		}
+		for convID, ids := range byConv {
+			// 一次为整批预留序列号，与 Create 共用计数器
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "conv_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"seq": gorm.Expr("seq + ?", len(ids))}),
+			}).Create(&chatEntity.MessageSeq{ConvId: convID, Seq: int64(len(ids))}).Error
+			if err != nil {
+				return err
+			}
+			var counter chatEntity.MessageSeq
+			if err := tx.Where("conv_id = ?", convID).First(&counter).Error; err != nil {
+				return err
+			}
+			first := counter.Seq - int64(len(ids)) + 1
+			for i, id := range ids {
+				if err := tx.Model(&chatEntity.Message{}).
+					Where("id = ?", id).
+					Updates(map[string]interface{}{"conv_id": convID, "seq": first + int64(i)}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r *messageRepositoryImpl) GetByUUID(uuid string) (*chatEntity.Message, error) {
+	var msg chatEntity.Message
+	if err := r.db.Where("uuid = ?", uuid).First(&msg).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *messageRepositoryImpl) ListByUUIDs(uuids []string) ([]chatEntity.Message, error) {
+	if len(uuids) == 0 {
+		return nil, nil
+	}
+	var msgs []chatEntity.Message
+	err := r.db.Where("uuid IN ?", uuids).Order("created_at ASC").Order("id ASC").Find(&msgs).Error
+	return msgs, err
+}
+
+func (r *messageRepositoryImpl) ListTextAfterID(afterID int64, limit int) ([]chatEntity.Message, error) {
+	var msgs []chatEntity.Message
+	err := r.db.
+		Where("id > ? AND type = ? AND is_recalled = ?", afterID, 0, false).
+		Order("id ASC").
+		Limit(limit).
+		Find(&msgs).Error
+	return msgs, err
+}
+
+func (r *messageRepositoryImpl) ListByFileID(fileID string, limit int) ([]chatEntity.Message, error) {
+	var msgs []chatEntity.Message
+	err := r.db.Where("file_id = ?", fileID).Order("id DESC").Limit(limit).Find(&msgs).Error
+	return msgs, err
+}
+
+func (r *messageRepositoryImpl) GetBySendAndClientMsgID(sendID string, clientMsgID string) (*chatEntity.Message, error) {
+	var msg chatEntity.Message
+	if err := r.db.Where("send_id = ? AND client_msg_id = ?", sendID, clientMsgID).First(&msg).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *messageRepositoryImpl) MarkRecalled(uuid string, recalledAt time.Time) (bool, error) {
+	res := r.db.Model(&chatEntity.Message{}).
+		Where("uuid = ? AND is_recalled = ?", uuid, false).
+		Updates(map[string]interface{}{
+			"is_recalled": true,
+			"recalled_at": recalledAt,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *messageRepositoryImpl) EditContent(uuid string, content string, editorID string, notBefore time.Time, editedAt time.Time) (int, error) {
+	version := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 锁住消息行，同一消息的并发编辑按序生成版本；校验在加锁前读取，期间可能已被撤回
+		var msg chatEntity.Message
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uuid = ?", uuid).First(&msg).Error; err != nil {
+			return err
+		}
+		if msg.IsRecalled {
+			return chatRepository.ErrMessageRecalled
+		}
+		if msg.SendId != editorID {
+			return chatRepository.ErrMessageNotAuthor
+		}
+		if msg.CreatedAt.Before(notBefore) {
+			return chatRepository.ErrMessageEditExpired
+		}
+		var maxVersion int
+		if err := tx.Model(&chatEntity.MessageEditHistory{}).
+			Where("message_uuid = ?", uuid).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&maxVersion).Error; err != nil {
+			return err
+		}
+		version = maxVersion + 1
+		if err := tx.Create(&chatEntity.MessageEditHistory{
+			MessageUuid: uuid,
+			Version:     version,
+			Content:     msg.Content,
+			EditorId:    editorID,
+			CreatedAt:   editedAt,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&chatEntity.Message{}).
+			Where("uuid = ?", uuid).
+			Updates(map[string]interface{}{
+				"content":   content,
+				"is_edited": true,
+				"edited_at": editedAt,
+			}).Error
+	})
+	return version, err
+}
+
+func (r *messageRepositoryImpl) ListEditHistory(uuid string) ([]chatEntity.MessageEditHistory, error) {
+	var history []chatEntity.MessageEditHistory
+	err := r.db.Where("message_uuid = ?", uuid).Order("version ASC").Find(&history).Error
+	return history, err
+}
+
+func (r *messageRepositoryImpl) UpdateReplySnippet(replyToUUID string, snippet string) error {
+	return r.db.Model(&chatEntity.Message{}).
+		Where("reply_to_uuid = ?", replyToUUID).
+		Update("reply_snippet", snippet).Error
+}
+
+func (r *messageRepositoryImpl) ListThreadReplies(rootUUID string, page int, pageSize int) ([]chatEntity.Message, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	var msgs []chatEntity.Message
+	err := r.db.
+		Where("thread_root_uuid = ?", rootUUID).
+		Order("created_at ASC").
+		Order("id ASC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&msgs).Error
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (r *messageRepositoryImpl) CountThreadReplies(groupID string, rootUUIDs []string) (map[string]int64, error) {
+	out := make(map[string]int64)
+	if len(rootUUIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		ThreadRootUuid string
+		Cnt            int64
+	}
+	err := r.db.Model(&chatEntity.Message{}).
+		Select("thread_root_uuid, COUNT(*) AS cnt").
+		Where("receive_id = ? AND thread_root_uuid IN ? AND is_recalled = ?", groupID, rootUUIDs, false).
+		Group("thread_root_uuid").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.ThreadRootUuid] = row.Cnt
+	}
+	return out, nil
+}
+
+// sinceChunk 单条未读统计查询最多携带的会话数
+const sinceChunk = 200
+
+// countSince 把各会话的起始时间拼成派生表 c(peer_id, since)，分批交给 query 做一次分组统计并汇总结果
+func countSince(since map[string]time.Time, query func(table string, args []interface{}) (map[string]int64, error)) (map[string]int64, error) {
+	out := make(map[string]int64, len(since))
+	parts := make([]string, 0, sinceChunk)
+	args := make([]interface{}, 0, sinceChunk*2)
+	flush := func() error {
+		if len(parts) == 0 {
+			return nil
+		}
+		counts, err := query("("+strings.Join(parts, " UNION ALL ")+") AS c", args)
+		if err != nil {
+			return err
+		}
+		for peerID, cnt := range counts {
+			out[peerID] = cnt
+		}
+		parts, args = parts[:0], args[:0]
+		return nil
+	}
+	for peerID, t := range since {
+		if len(parts) == 0 {
+			parts = append(parts, "SELECT ? AS peer_id, ? AS since")
+		} else {
+			parts = append(parts, "SELECT ?, ?")
+		}
+		args = append(args, peerID, t)
+		if len(parts) == sinceChunk {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type peerCount struct {
+	PeerId string
+	Cnt    int64
+}
+
+func scanPeerCounts(q *gorm.DB) (map[string]int64, error) {
+	var rows []peerCount
+	if err := q.Group("c.peer_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		out[row.PeerId] = row.Cnt
+	}
+	return out, nil
+}
+
+func (r *messageRepositoryImpl) CountUnreadPrivates(userID string, since map[string]time.Time) (map[string]int64, error) {
+	return countSince(since, func(table string, args []interface{}) (map[string]int64, error) {
+		return scanPeerCounts(r.db.Table("message AS m").
+			Select("c.peer_id, COUNT(*) AS cnt").
+			Joins("JOIN "+table+" ON m.send_id = c.peer_id AND m.created_at > c.since", args...).
+			Where("m.receive_id = ? AND m.is_recalled = ?", userID, false))
+	})
+}
+
+func (r *messageRepositoryImpl) CountUnreadGroups(userID string, since map[string]time.Time) (map[string]int64, error) {
+	return countSince(since, func(table string, args []interface{}) (map[string]int64, error) {
+		return scanPeerCounts(r.db.Table("message AS m").
+			Select("c.peer_id, COUNT(*) AS cnt").
+			Joins("JOIN "+table+" ON m.receive_id = c.peer_id AND m.created_at > c.since", args...).
+			Where("m.send_id <> ? AND m.is_recalled = ?", userID, false))
+	})
+}
+
+func (r *messageRepositoryImpl) MarkPrivateRead(sendID string, receiveID string, until time.Time) (int64, error) {
+	res := r.db.Model(&chatEntity.Message{}).
+		Where("send_id = ? AND receive_id = ? AND status = ? AND created_at <= ?", sendID, receiveID, 1, until).
+		Update("status", 2)
+	return res.RowsAffected, res.Error
 }
 
 func (r *messageRepositoryImpl) GetMessagesForUserAfter(ctx context.Context, userID string, groupIDs []string, since time.Time, limit int) ([]chatEntity.Message, error) {
@@ -72,7 +433,7 @@ func (r *messageRepositoryImpl) GetMessagesForUserAfter(ctx context.Context, use
 	}
 
 	var msgs []chatEntity.Message
-	query := r.db.WithContext(ctx).Where("created_at > ?", since)
+	query := r.db.WithContext(ctx).Where("created_at > ? AND is_recalled = ?", since, false)
 
 	if len(groupIDs) > 0 {
 		query = query.Where("receive_id = ? OR receive_id IN ?", userID, groupIDs)
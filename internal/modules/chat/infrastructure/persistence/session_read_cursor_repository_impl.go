@@ -0,0 +1,42 @@
+package persistence
+
+import (
+	"OmniLink/internal/modules/chat/domain/entity"
+	"OmniLink/internal/modules/chat/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sessionReadCursorRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSessionReadCursorRepository(db *gorm.DB) repository.SessionReadCursorRepository {
+	return &sessionReadCursorRepositoryImpl{db: db}
+}
+
+func (r *sessionReadCursorRepositoryImpl) Get(userID string, targetID string) (*entity.SessionReadCursor, error) {
+	var cursor entity.SessionReadCursor
+	if err := r.db.Where("user_id = ? AND target_id = ?", userID, targetID).First(&cursor).Error; err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func (r *sessionReadCursorRepositoryImpl) ListByUserID(userID string) ([]entity.SessionReadCursor, error) {
+	var cursors []entity.SessionReadCursor
+	err := r.db.Where("user_id = ?", userID).Find(&cursors).Error
+	return cursors, err
+}
+
+func (r *sessionReadCursorRepositoryImpl) Advance(cursor *entity.SessionReadCursor) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "target_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_message_uuid": gorm.Expr("IF(VALUES(last_read_at) >= last_read_at, VALUES(last_read_message_uuid), last_read_message_uuid)"),
+			"last_read_at":           gorm.Expr("GREATEST(last_read_at, VALUES(last_read_at))"),
+			"updated_at":             gorm.Expr("VALUES(updated_at)"),
+		}),
+	}).Create(cursor).Error
+}
@@ -68,3 +68,18 @@ func (r *sessionRepositoryImpl) UpdateLastMessageBySendAndReceive(sendID string,
 			"last_message_at": lastMessageAt,
 		}).Error
 }
+
+func (r *sessionRepositoryImpl) DeleteBetween(userA string, userB string) error {
+	return r.db.
+		Where("(send_id = ? AND receive_id = ?) OR (send_id = ? AND receive_id = ?)", userA, userB, userB, userA).
+		Delete(&chatEntity.Session{}).Error
+}
+
+func (r *sessionRepositoryImpl) UpdateReceiverProfile(receiveID string, name string, avatar string) error {
+	return r.db.Model(&chatEntity.Session{}).
+		Where("receive_id = ? AND deleted_at IS NULL", receiveID).
+		Updates(map[string]interface{}{
+			"receive_name": name,
+			"avatar":       avatar,
+		}).Error
+}
@@ -0,0 +1,324 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	chatEntity "OmniLink/internal/modules/chat/domain/entity"
+	chatRepository "OmniLink/internal/modules/chat/domain/repository"
+	"OmniLink/pkg/zlog"
+)
+
+const warmUpBatchSize = 1000
+
+// indexedMessage 索引中保存的消息快照，只保留检索与展示需要的字段
+type indexedMessage struct {
+	msg   chatEntity.Message
+	lower []rune
+}
+
+// memoryMessageSearcher 进程内倒排索引：按二元组（bigram）建倒排表，查询时取交集后再做子串校验。
+// 索引只存在于单个进程内，适用于单节点或开发环境；启动时从数据库全量预热
+type memoryMessageSearcher struct {
+	mu       sync.RWMutex
+	docs     map[string]*indexedMessage
+	postings map[string]map[string]struct{} // bigram -> message uuid
+	warming  bool
+	removed  map[string]struct{} // 预热期间被撤回的消息，避免预热把旧数据写回
+}
+
+// NewMemoryMessageSearcher 创建进程内倒排索引，并在后台从 messageRepo 预热已有的文本消息
+func NewMemoryMessageSearcher(messageRepo chatRepository.MessageRepository) chatRepository.MessageSearcher {
+	s := &memoryMessageSearcher{
+		docs:     make(map[string]*indexedMessage),
+		postings: make(map[string]map[string]struct{}),
+		warming:  messageRepo != nil,
+		removed:  make(map[string]struct{}),
+	}
+	if messageRepo != nil {
+		go s.warmUp(messageRepo)
+	}
+	return s
+}
+
+func (s *memoryMessageSearcher) warmUp(messageRepo chatRepository.MessageRepository) {
+	var (
+		afterID int64
+		total   int
+	)
+	for {
+		msgs, err := messageRepo.ListTextAfterID(afterID, warmUpBatchSize)
+		if err != nil {
+			zlog.Error("message search warm up failed: " + err.Error())
+			break
+		}
+		s.mu.Lock()
+		for i := range msgs {
+			if _, gone := s.removed[msgs[i].Uuid]; gone {
+				continue
+			}
+			// 预热期间已由实时写入更新过的消息以内存中的为准
+			if _, ok := s.docs[msgs[i].Uuid]; ok {
+				continue
+			}
+			s.indexLocked(&msgs[i])
+		}
+		s.mu.Unlock()
+		total += len(msgs)
+		if len(msgs) < warmUpBatchSize {
+			break
+		}
+		afterID = msgs[len(msgs)-1].Id
+	}
+
+	s.mu.Lock()
+	s.warming = false
+	s.removed = make(map[string]struct{})
+	s.mu.Unlock()
+	zlog.Info(fmt.Sprintf("message search index warmed up: %d messages", total))
+}
+
+func (s *memoryMessageSearcher) Index(msg *chatEntity.Message) {
+	if msg == nil || msg.Type != 0 || msg.IsRecalled || msg.Uuid == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(msg.Uuid)
+	s.indexLocked(msg)
+}
+
+func (s *memoryMessageSearcher) Remove(uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(uuid)
+	if s.warming {
+		s.removed[uuid] = struct{}{}
+	}
+}
+
+func (s *memoryMessageSearcher) Search(_ context.Context, q chatRepository.MessageSearchQuery) ([]chatEntity.Message, int64, error) {
+	terms := make([][]rune, 0, len(q.Terms))
+	for _, t := range q.Terms {
+		if r := lowerRunes(t); len(r) > 0 {
+			terms = append(terms, r)
+		}
+	}
+
+	s.mu.RLock()
+	var hits []*indexedMessage
+	for _, doc := range s.candidatesLocked(terms) {
+		if matchTerms(doc.lower, terms) && inScope(&doc.msg, q) {
+			hits = append(hits, doc)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if !hits[i].msg.CreatedAt.Equal(hits[j].msg.CreatedAt) {
+			return hits[i].msg.CreatedAt.After(hits[j].msg.CreatedAt)
+		}
+		return hits[i].msg.Id > hits[j].msg.Id
+	})
+
+	total := int64(len(hits))
+	if q.Offset >= len(hits) {
+		return []chatEntity.Message{}, total, nil
+	}
+	end := len(hits)
+	if q.Limit > 0 && q.Offset+q.Limit < end {
+		end = q.Offset + q.Limit
+	}
+	out := make([]chatEntity.Message, 0, end-q.Offset)
+	for _, h := range hits[q.Offset:end] {
+		out = append(out, h.msg)
+	}
+	return out, total, nil
+}
+
+// candidatesLocked 用最长关键词的二元组倒排表求交集；关键词都只有一个字时只能全量扫描
+func (s *memoryMessageSearcher) candidatesLocked(terms [][]rune) []*indexedMessage {
+	var longest []rune
+	for _, t := range terms {
+		if len(t) > len(longest) {
+			longest = t
+		}
+	}
+	if len(longest) < 2 {
+		out := make([]*indexedMessage, 0, len(s.docs))
+		for _, doc := range s.docs {
+			out = append(out, doc)
+		}
+		return out
+	}
+
+	var ids map[string]struct{}
+	for _, gram := range bigrams(longest) {
+		posting := s.postings[gram]
+		if len(posting) == 0 {
+			return nil
+		}
+		if ids == nil {
+			ids = make(map[string]struct{}, len(posting))
+			for id := range posting {
+				ids[id] = struct{}{}
+			}
+			continue
+		}
+		for id := range ids {
+			if _, ok := posting[id]; !ok {
+				delete(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+	}
+	out := make([]*indexedMessage, 0, len(ids))
+	for id := range ids {
+		out = append(out, s.docs[id])
+	}
+	return out
+}
+
+func (s *memoryMessageSearcher) indexLocked(msg *chatEntity.Message) {
+	doc := &indexedMessage{
+		msg: chatEntity.Message{
+			Id:         msg.Id,
+			Uuid:       msg.Uuid,
+			SessionId:  msg.SessionId,
+			Type:       msg.Type,
+			Content:    msg.Content,
+			SendId:     msg.SendId,
+			SendName:   msg.SendName,
+			SendAvatar: msg.SendAvatar,
+			ReceiveId:  msg.ReceiveId,
+			CreatedAt:  msg.CreatedAt,
+			IsEdited:   msg.IsEdited,
+			EditedAt:   msg.EditedAt,
+			ConvId:     msg.ConvId,
+			Seq:        msg.Seq,
+		},
+		lower: lowerRunes(msg.Content),
+	}
+	s.docs[msg.Uuid] = doc
+	for _, gram := range bigrams(doc.lower) {
+		posting := s.postings[gram]
+		if posting == nil {
+			posting = make(map[string]struct{})
+			s.postings[gram] = posting
+		}
+		posting[msg.Uuid] = struct{}{}
+	}
+}
+
+func (s *memoryMessageSearcher) removeLocked(uuid string) {
+	doc := s.docs[uuid]
+	if doc == nil {
+		return
+	}
+	delete(s.docs, uuid)
+	for _, gram := range bigrams(doc.lower) {
+		if posting := s.postings[gram]; posting != nil {
+			delete(posting, uuid)
+			if len(posting) == 0 {
+				delete(s.postings, gram)
+			}
+		}
+	}
+}
+
+func inScope(msg *chatEntity.Message, q chatRepository.MessageSearchQuery) bool {
+	if q.SenderId != "" && msg.SendId != q.SenderId {
+		return false
+	}
+	if q.Since != nil && msg.CreatedAt.Before(*q.Since) {
+		return false
+	}
+	if q.Until != nil && msg.CreatedAt.After(*q.Until) {
+		return false
+	}
+	if strings.HasPrefix(msg.ReceiveId, "G") {
+		if q.TargetId != "" && q.TargetId != msg.ReceiveId {
+			return false
+		}
+		joinedAt, ok := q.Groups[msg.ReceiveId]
+		return ok && !msg.CreatedAt.Before(joinedAt)
+	}
+	if msg.SendId != q.UserId && msg.ReceiveId != q.UserId {
+		return false
+	}
+	if q.TargetId != "" {
+		return msg.SendId == q.TargetId || msg.ReceiveId == q.TargetId
+	}
+	return true
+}
+
+func matchTerms(text []rune, terms [][]rune) bool {
+	for _, t := range terms {
+		if indexRunes(text, t) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bigrams 切分相邻二元组并去重；空白处断开，不跨词组合
+func bigrams(r []rune) []string {
+	seen := make(map[string]struct{}, len(r))
+	out := make([]string, 0, len(r))
+	for i := 0; i+1 < len(r); i++ {
+		if unicode.IsSpace(r[i]) || unicode.IsSpace(r[i+1]) {
+			continue
+		}
+		g := string(r[i : i+2])
+		if _, ok := seen[g]; ok {
+			continue
+		}
+		seen[g] = struct{}{}
+		out = append(out, g)
+	}
+	return out
+}
+
+// lowerRunes 逐字符转小写，保持与原文相同的字符下标
+func lowerRunes(s string) []rune {
+	r := []rune(s)
+	for i := range r {
+		r[i] = unicode.ToLower(r[i])
+	}
+	return r
+}
+
+func indexRunes(text []rune, sub []rune) int {
+	if len(sub) == 0 {
+		return 0
+	}
+	for i := 0; i+len(sub) <= len(text); i++ {
+		match := true
+		for j := range sub {
+			if text[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedGroupIDs(groups map[string]time.Time) []string {
+	ids := make([]string, 0, len(groups))
+	for id := range groups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
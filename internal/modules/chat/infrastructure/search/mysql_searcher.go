@@ -0,0 +1,137 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	chatEntity "OmniLink/internal/modules/chat/domain/entity"
+	chatRepository "OmniLink/internal/modules/chat/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+const fulltextIndexName = "idx_message_content_ft"
+
+// ngramTokenSize 与 MySQL 默认的 ngram_token_size 一致，短于该长度的词无法走全文索引
+const ngramTokenSize = 2
+
+type mysqlMessageSearcher struct {
+	db *gorm.DB
+}
+
+// NewMySQLMessageSearcher 基于 MySQL FULLTEXT（ngram 分词，支持中文）的检索实现，首次使用时创建全文索引
+func NewMySQLMessageSearcher(db *gorm.DB) (chatRepository.MessageSearcher, error) {
+	if !db.Migrator().HasIndex(&chatEntity.Message{}, fulltextIndexName) {
+		err := db.Exec("CREATE FULLTEXT INDEX " + fulltextIndexName + " ON message (content) WITH PARSER ngram").Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &mysqlMessageSearcher{db: db}, nil
+}
+
+func (s *mysqlMessageSearcher) Search(ctx context.Context, q chatRepository.MessageSearchQuery) ([]chatEntity.Message, int64, error) {
+	scope, args := scopeCondition(q)
+	if scope == "" {
+		return []chatEntity.Message{}, 0, nil
+	}
+
+	tx := s.db.WithContext(ctx).Model(&chatEntity.Message{}).
+		Where("type = ? AND is_recalled = ?", 0, false).
+		Where(scope, args...)
+	if cond, cargs := keywordCondition(q.Terms); cond != "" {
+		tx = tx.Where(cond, cargs...)
+	}
+	if q.SenderId != "" {
+		tx = tx.Where("send_id = ?", q.SenderId)
+	}
+	if q.Since != nil {
+		tx = tx.Where("created_at >= ?", *q.Since)
+	}
+	if q.Until != nil {
+		tx = tx.Where("created_at <= ?", *q.Until)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var msgs []chatEntity.Message
+	err := tx.Order("created_at DESC").Order("id DESC").
+		Offset(q.Offset).
+		Limit(q.Limit).
+		Find(&msgs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return msgs, total, nil
+}
+
+func (s *mysqlMessageSearcher) Index(*chatEntity.Message) {}
+
+func (s *mysqlMessageSearcher) Remove(string) {}
+
+// scopeCondition 可见范围：本人收发的私聊 + 入群之后的群消息；指定了会话时只保留该会话
+func scopeCondition(q chatRepository.MessageSearchQuery) (string, []interface{}) {
+	var (
+		parts []string
+		args  []interface{}
+	)
+	target := q.TargetId
+	if target == "" || !strings.HasPrefix(target, "G") {
+		if target == "" {
+			parts = append(parts, "(receive_id NOT LIKE 'G%' AND (send_id = ? OR receive_id = ?))")
+			args = append(args, q.UserId, q.UserId)
+		} else {
+			parts = append(parts, "((send_id = ? AND receive_id = ?) OR (send_id = ? AND receive_id = ?))")
+			args = append(args, q.UserId, target, target, q.UserId)
+		}
+	}
+	for _, gid := range sortedGroupIDs(q.Groups) {
+		if target != "" && gid != target {
+			continue
+		}
+		parts = append(parts, "(receive_id = ? AND created_at >= ?)")
+		args = append(args, gid, q.Groups[gid])
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// keywordCondition 关键词都不短于 ngram 长度时走全文索引（布尔模式短语匹配），否则退化为 LIKE
+func keywordCondition(terms []string) (string, []interface{}) {
+	if len(terms) == 0 {
+		return "", nil
+	}
+	useFulltext := true
+	for _, t := range terms {
+		if utf8.RuneCountInString(t) < ngramTokenSize {
+			useFulltext = false
+			break
+		}
+	}
+	if useFulltext {
+		var b strings.Builder
+		for i, t := range terms {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(`+"`)
+			b.WriteString(strings.ReplaceAll(t, `"`, ""))
+			b.WriteByte('"')
+		}
+		return "MATCH(content) AGAINST (? IN BOOLEAN MODE)", []interface{}{b.String()}
+	}
+
+	parts := make([]string, 0, len(terms))
+	args := make([]interface{}, 0, len(terms))
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	for _, t := range terms {
+		parts = append(parts, "content LIKE ?")
+		args = append(args, "%"+replacer.Replace(t)+"%")
+	}
+	return strings.Join(parts, " AND "), args
+}
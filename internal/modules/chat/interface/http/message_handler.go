@@ -2,8 +2,10 @@ package handler
 
 import (
 	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
+	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
 	"OmniLink/internal/modules/chat/application/service"
 	"OmniLink/pkg/back"
+	"OmniLink/pkg/ws"
 	"OmniLink/pkg/xerr"
 	"OmniLink/pkg/zlog"
 
@@ -11,11 +13,14 @@ import (
 )
 
 type MessageHandler struct {
-	svc service.MessageService
+	svc         service.MessageService
+	realtimeSvc service.RealtimeService
+	searchSvc   service.MessageSearchService
+	hub         *ws.Hub
 }
 
-func NewMessageHandler(svc service.MessageService) *MessageHandler {
-	return &MessageHandler{svc: svc}
+func NewMessageHandler(svc service.MessageService, realtimeSvc service.RealtimeService, searchSvc service.MessageSearchService, hub *ws.Hub) *MessageHandler {
+	return &MessageHandler{svc: svc, realtimeSvc: realtimeSvc, searchSvc: searchSvc, hub: hub}
 }
 
 func (h *MessageHandler) GetMessageList(c *gin.Context) {
@@ -55,3 +60,256 @@ func (h *MessageHandler) GetGroupMessageList(c *gin.Context) {
 	data, err := h.svc.GetGroupMessageList(req, uuid)
 	back.Result(c, data, err)
 }
+
+func (h *MessageHandler) SyncMessages(c *gin.Context) {
+	var req chatRequest.SyncMessagesRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	data, err := h.svc.SyncMessages(uuid, req)
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) RecallMessage(c *gin.Context) {
+	var req chatRequest.RecallMessageRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	recipients, data, err := h.realtimeSvc.RecallMessage(uuid, req)
+	if err == nil && h.hub != nil {
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameRecall, "", data)
+		}
+	}
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) EditMessage(c *gin.Context) {
+	var req chatRequest.EditMessageRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	recipients, data, err := h.realtimeSvc.EditMessage(uuid, req)
+	if err == nil && h.hub != nil {
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameEdit, "", data)
+		}
+	}
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) GetEditHistory(c *gin.Context) {
+	var req chatRequest.GetEditHistoryRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	data, err := h.svc.GetEditHistory(uuid, req)
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) GetThreadMessages(c *gin.Context) {
+	var req chatRequest.GetThreadMessagesRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	data, err := h.svc.GetThreadMessages(uuid, req)
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) GetThreadCount(c *gin.Context) {
+	var req chatRequest.GetThreadCountRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	data, err := h.svc.GetThreadCount(uuid, req)
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) AddReaction(c *gin.Context) {
+	var req chatRequest.ReactMessageRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	req.Action = service.ReactionActionAdd
+	recipients, data, err := h.realtimeSvc.ReactMessage(uuid, req)
+	if err == nil && h.hub != nil {
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameReaction, "", data)
+		}
+	}
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) RemoveReaction(c *gin.Context) {
+	var req chatRequest.ReactMessageRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	req.Action = service.ReactionActionRemove
+	recipients, data, err := h.realtimeSvc.ReactMessage(uuid, req)
+	if err == nil && h.hub != nil {
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameReaction, "", data)
+		}
+	}
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) ForwardMessages(c *gin.Context) {
+	var req chatRequest.ForwardMessagesRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	deliveries, err := h.realtimeSvc.ForwardMessages(uuid, req)
+	// 部分转发失败时，已发出的消息照常推送
+	mine := make([]*chatRespond.MessageItem, 0, len(deliveries))
+	for _, d := range deliveries {
+		for uid, item := range d {
+			if h.hub != nil {
+				_ = h.hub.SendFrame(uid, ws.FrameMessage, "", item)
+			}
+		}
+		mine = append(mine, d[uuid])
+	}
+	back.Result(c, mine, err)
+}
+
+func (h *MessageHandler) GetForwardRecord(c *gin.Context) {
+	var req chatRequest.GetForwardRecordRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	data, err := h.svc.GetForwardRecord(uuid, req)
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) SearchMessages(c *gin.Context) {
+	var req chatRequest.SearchMessagesRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	data, err := h.searchSvc.SearchMessages(uuid, req)
+	back.Result(c, data, err)
+}
+
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	var req chatRequest.MarkReadRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	recipients, data, err := h.realtimeSvc.MarkRead(uuid, req)
+	if err == nil && h.hub != nil {
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameRead, "", data)
+		}
+	}
+	back.Result(c, data, err)
+}
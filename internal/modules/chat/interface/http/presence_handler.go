@@ -0,0 +1,38 @@
+package handler
+
+import (
+	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
+	"OmniLink/internal/modules/chat/application/service"
+	"OmniLink/pkg/back"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PresenceHandler struct {
+	svc service.PresenceService
+}
+
+func NewPresenceHandler(svc service.PresenceService) *PresenceHandler {
+	return &PresenceHandler{svc: svc}
+}
+
+// GetOnlineStatus 批量查询好友在线状态（online / away / offline），user_ids 为空时返回整个好友列表
+func (h *PresenceHandler) GetOnlineStatus(c *gin.Context) {
+	var req chatRequest.GetOnlineStatusRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+
+	data, err := h.svc.GetOnlineStatus(uuid, req)
+	back.Result(c, data, err)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
+	"OmniLink/internal/modules/chat/application/service"
+	"OmniLink/pkg/back"
+	"OmniLink/pkg/storage"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UploadHandler struct {
+	svc   service.UploadService
+	local *storage.LocalStorage // 仅本地存储时非空，用于校验并响应签名下载地址
+}
+
+func NewUploadHandler(svc service.UploadService, local *storage.LocalStorage) *UploadHandler {
+	return &UploadHandler{svc: svc, local: local}
+}
+
+// UploadFile multipart 表单：file 为文件，category 为 image/voice/file
+func (h *UploadHandler) UploadFile(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		back.Error(c, xerr.BadRequest, "缺少文件")
+		return
+	}
+	data, err := h.svc.UploadFile(c.GetString("uuid"), c.PostForm("category"), header)
+	back.Result(c, data, err)
+}
+
+// UploadAvatar multipart 表单：file 为头像图片
+func (h *UploadHandler) UploadAvatar(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		back.Error(c, xerr.BadRequest, "缺少文件")
+		return
+	}
+	data, err := h.svc.UploadAvatar(c.GetString("uuid"), header)
+	back.Result(c, data, err)
+}
+
+func (h *UploadHandler) InitUpload(c *gin.Context) {
+	var req chatRequest.InitUploadRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.InitUpload(c.GetString("uuid"), req)
+	back.Result(c, data, err)
+}
+
+// UploadChunk multipart 表单：upload_id、index（从0开始）、chunk 为分片内容
+func (h *UploadHandler) UploadChunk(c *gin.Context) {
+	index, err := strconv.Atoi(c.PostForm("index"))
+	if err != nil {
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	header, err := c.FormFile("chunk")
+	if err != nil {
+		back.Error(c, xerr.BadRequest, "缺少分片")
+		return
+	}
+	err = h.svc.UploadChunk(c.GetString("uuid"), c.PostForm("upload_id"), index, header)
+	back.Result(c, nil, err)
+}
+
+func (h *UploadHandler) GetUploadStatus(c *gin.Context) {
+	var req chatRequest.UploadStatusRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.GetUploadStatus(c.GetString("uuid"), req)
+	back.Result(c, data, err)
+}
+
+func (h *UploadHandler) CompleteUpload(c *gin.Context) {
+	var req chatRequest.CompleteUploadRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.CompleteUpload(c.GetString("uuid"), req)
+	back.Result(c, data, err)
+}
+
+func (h *UploadHandler) GetFileUrl(c *gin.Context) {
+	var req chatRequest.GetFileUrlRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	data, err := h.svc.GetFileUrl(c.GetString("uuid"), req)
+	back.Result(c, data, err)
+}
+
+// Avatar 头像的长期地址，无需登录，重定向到新签发的下载地址
+func (h *UploadHandler) Avatar(c *gin.Context) {
+	target, err := h.svc.AvatarURL(c.Param("file_id"))
+	if err != nil {
+		var codeErr *xerr.CodeError
+		if errors.As(err, &codeErr) && codeErr.Code == xerr.NotFound {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Redirect(http.StatusFound, target)
+}
+
+// Download 本地存储的签名下载地址，签名本身即授权，无需登录
+func (h *UploadHandler) Download(c *gin.Context) {
+	if h.local == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	key := c.Query("key")
+	name := c.Query("name")
+	if key == "" || !h.local.Verify(key, c.Query("exp"), name, c.Query("sig")) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	rc, err := h.local.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		zlog.Error(err.Error())
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
+	if name != "" {
+		c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(name))
+	}
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, rc); err != nil {
+		zlog.Warn("file download interrupted: " + err.Error())
+	}
+}
@@ -2,15 +2,24 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"OmniLink/internal/config"
 	chatRequest "OmniLink/internal/modules/chat/application/dto/request"
+	chatRespond "OmniLink/internal/modules/chat/application/dto/respond"
 	chatService "OmniLink/internal/modules/chat/application/service"
+	userService "OmniLink/internal/modules/user/application/service"
 	userRepository "OmniLink/internal/modules/user/domain/repository"
+	"OmniLink/pkg/back"
+	"OmniLink/pkg/ratelimit"
 	"OmniLink/pkg/util/myjwt"
 	"OmniLink/pkg/ws"
+	"OmniLink/pkg/xerr"
 	"OmniLink/pkg/zlog"
 
 	"github.com/gin-gonic/gin"
@@ -18,47 +27,122 @@ import (
 )
 
 type WsHandler struct {
-	hub      *ws.Hub
-	svc      chatService.RealtimeService
-	userRepo userRepository.UserInfoRepository
+	hub         *ws.Hub
+	svc         chatService.RealtimeService
+	messageSvc  chatService.MessageService
+	callSvc     chatService.CallService
+	presenceSvc chatService.PresenceService
+	deviceSvc   userService.DeviceService
+	userRepo    userRepository.UserInfoRepository
 }
 
-func NewWsHandler(hub *ws.Hub, svc chatService.RealtimeService, userRepo userRepository.UserInfoRepository) *WsHandler {
+func NewWsHandler(hub *ws.Hub, svc chatService.RealtimeService, messageSvc chatService.MessageService, callSvc chatService.CallService, presenceSvc chatService.PresenceService, deviceSvc userService.DeviceService, userRepo userRepository.UserInfoRepository) *WsHandler {
 	return &WsHandler{
-		hub:      hub,
-		svc:      svc,
-		userRepo: userRepo,
+		hub:         hub,
+		svc:         svc,
+		messageSvc:  messageSvc,
+		callSvc:     callSvc,
+		presenceSvc: presenceSvc,
+		deviceSvc:   deviceSvc,
+		userRepo:    userRepo,
 	}
 }
 
+const (
+	// authCheckInterval 连接内令牌过期检查周期
+	authCheckInterval = 15 * time.Second
+	// authRenewWindow 令牌剩余有效期小于该值时提醒客户端刷新并通过 auth 帧续期
+	authRenewWindow = time.Minute
+)
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin 浏览器握手按白名单校验 Origin，防止跨站 WebSocket 劫持；
+// 非浏览器客户端不带 Origin，直接放行（仍需通过令牌鉴权）
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
 		return true
-	},
+	}
+	allowed := config.GetConfig().WsConfig.AllowedOrigins
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(strings.TrimRight(o, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// authenticate 握手鉴权。优先使用一次性票据（浏览器原生 WebSocket 无法设置请求头，
+// 票据可避免长期令牌出现在 URL 与访问日志中），其次是 Authorization 头与 token 参数
+func authenticate(c *gin.Context) (*myjwt.CustomClaims, error) {
+	if ticket := c.Query("ticket"); ticket != "" {
+		claims, err := myjwt.ConsumeTicket(ticket)
+		if err != nil {
+			return nil, err
+		}
+		// 票据有效期内签发它的令牌可能已被注销或踢下线
+		if claims.Uuid == "" || myjwt.IsRevoked(claims.ID) {
+			return nil, errors.New("token revoked")
+		}
+		return claims, nil
+	}
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" {
+		token = c.Query("token")
+	}
+	return parseWsToken(token)
+}
+
+func parseWsToken(token string) (*myjwt.CustomClaims, error) {
+	if token == "" {
+		return nil, errors.New("token is empty")
+	}
+	claims, err := myjwt.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil || claims.Uuid == "" || myjwt.IsRevoked(claims.ID) {
+		return nil, errors.New("token revoked")
+	}
+	return claims, nil
 }
 
 func (h *WsHandler) Connect(c *gin.Context) {
-	clientID := c.Query("client_id")
-	token := c.Query("token")
+	// device_id 存在时启用可靠投递：可靠帧需 ack，断线期间的帧在重连后补发
+	deviceID := c.Query("device_id")
+	// sync_cursor 存在（可为空）时，连接建立后回放离线期间错过的消息
+	syncCursor, needSync := c.GetQuery("sync_cursor")
 
-	if clientID == "" {
-		c.AbortWithStatus(http.StatusBadRequest)
+	claims, err := authenticate(c)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	// client_id 兼容旧客户端，可省略；携带时必须与令牌一致
+	clientID := claims.Uuid
+	if q := c.Query("client_id"); q != "" && q != clientID {
+		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 
-	if token != "" {
-		claims, err := myjwt.ParseToken(token)
-		if err != nil || claims == nil || claims.Uuid != clientID {
+	// reliable 只由客户端显式上报 device_id 开启；令牌中的设备ID仅用于定向投递与踢下线
+	reliable := deviceID != ""
+	if claims.DeviceId != "" {
+		if deviceID != "" && deviceID != claims.DeviceId {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
+		deviceID = claims.DeviceId
 	}
-	// 	- 事实 ： GE.GET("/wss", ...) 这一行代码是写在 authed := GE.Group("/") 外面 的（或者说它没有使用 Use(jwtMiddleware.Auth()) ）。
-	// - 原因 ：WebSocket 的握手请求有时候没法像普通 API 那样把 Token 放在 Header 里（特别是浏览器原生 WebSocket API 不支持自定义 Header）。
-	// - 解决 ：所以我们通常把 Token 放在 URL 参数里传过来（ ?token=xxx ）。
-	// - 结论 ：因为没走中间件，所以 Gin 框架不会自动帮我们校验 Token。因此，我们在 ws_handler.go 的第 49-55 行 手动写了一段校验 Token 的代码 。这就解释了为什么那里要去拿 Token。
 
 	briefs, err := h.userRepo.GetUserBriefByUUIDs([]string{clientID})
 	if err != nil || len(briefs) == 0 || briefs[0].Status != 0 {
@@ -72,8 +156,11 @@ func (h *WsHandler) Connect(c *gin.Context) {
 		return
 	}
 
-	client := ws.NewClient(clientID, conn)
+	client := ws.NewClient(clientID, deviceID, reliable, conn)
+	client.SetExpiresAt(claims.ExpiresAtTime())
 	h.hub.Register(client)
+	h.presenceSvc.Connected(clientID, client.ConnID())
+	h.deviceSvc.Connected(clientID, deviceID, c.ClientIP())
 	// 上线：更新 LastOnlineAt
 	go func() {
 		if err := h.userRepo.UpdateLastOnlineAt(c.Request.Context(), clientID, time.Now()); err != nil {
@@ -83,6 +170,10 @@ func (h *WsHandler) Connect(c *gin.Context) {
 
 	defer func() {
 		h.hub.Unregister(client)
+		// 接通所在的连接断开视为挂断
+		h.callSvc.Disconnected(clientID, deviceID, h.hub.LocalConnections(clientID) > 0)
+		h.presenceSvc.Disconnected(clientID, client.ConnID())
+		h.deviceSvc.Disconnected(clientID, deviceID)
 		// 离线：更新 LastOfflineAt
 		go func() {
 			// 这里不能用 c.Request.Context() 因为请求可能已经结束，用 Background
@@ -101,41 +192,344 @@ func (h *WsHandler) Connect(c *gin.Context) {
 	})
 
 	go client.WritePump()
+	go h.watchExpiry(client)
+
+	if needSync {
+		h.replay(client, syncCursor)
+	}
 
 	for {
-		var req chatRequest.SendMessageRequest
-		if err := conn.ReadJSON(&req); err != nil {
-			// 84行：最关键的一步！这里会阻塞（停住），等待前端发消息过来。
-			// 一旦前端发了数据，conn.ReadJSON 就会读出来，解析到 req 变量里。
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			// 最关键的一步！这里会阻塞（停住），等待前端发消息过来。
+			// 一旦前端发了数据，就会读出来，再按帧类型分发。
 			// 如果出错（比如前端断网了），就 return 退出循环，连接结束。
 			return
 		}
+		h.dispatch(client, raw)
+	}
+}
 
-		if strings.HasPrefix(req.ReceiveId, "G") {
-			memberIDs, item, err := h.svc.SendGroupMessage(clientID, req)
-			if err != nil {
-				_ = h.hub.SendJSON(clientID, map[string]interface{}{
-					"type":    "error",
-					"message": err.Error(),
-				})
+// dispatch 按帧类型分发。
+// 兼容旧协议：顶层 type 不是字符串（旧版 SendMessageRequest 的 type 为消息类型数字）时按发送消息处理
+func (h *WsHandler) dispatch(client *ws.Client, raw []byte) {
+	clientID, deviceID := client.UserID(), client.DeviceID()
+	var probe struct {
+		Type json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		h.replyError(client, "", xerr.BadRequest, "消息格式错误")
+		return
+	}
+
+	var frameType string
+	if len(probe.Type) == 0 || json.Unmarshal(probe.Type, &frameType) != nil {
+		var req chatRequest.SendMessageRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			h.replyError(client, "", xerr.BadRequest, "消息格式错误")
+			return
+		}
+		if !h.allowFrame(client, ws.FrameSend, req.ClientMsgId) {
+			return
+		}
+		h.handleSend(client, req)
+		return
+	}
+
+	var env ws.Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		h.replyError(client, "", xerr.BadRequest, "消息格式错误")
+		return
+	}
+	if !h.allowFrame(client, env.Type, env.ClientMsgId) {
+		return
+	}
+
+	switch env.Type {
+	case ws.FrameAuth:
+		var req chatRequest.WsAuthRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		claims, err := parseWsToken(req.Token)
+		if err != nil || claims.Uuid != clientID || (claims.DeviceId != "" && claims.DeviceId != deviceID) {
+			h.replyError(client, env.ClientMsgId, xerr.Unauthorized, "令牌无效")
+			return
+		}
+		client.SetExpiresAt(claims.ExpiresAtTime())
+		h.hub.SendFrameToClient(client, ws.FrameAuth, env.ClientMsgId, chatRespond.WsAuthRespond{ExpiresAt: claims.ExpiresAtTime().Unix()})
+
+	case ws.FrameAck:
+		var req ws.DeliveryAckPayload
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		h.hub.Ack(clientID, deviceID, req.DeliveryIds...)
+
+	case ws.FrameSend:
+		var req chatRequest.SendMessageRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		// 外层 client_msg_id 优先，payload 内的作为兜底
+		if env.ClientMsgId != "" {
+			req.ClientMsgId = env.ClientMsgId
+		}
+		h.handleSend(client, req)
+
+	case ws.FrameRecall:
+		var req chatRequest.RecallMessageRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		recipients, item, err := h.svc.RecallMessage(clientID, req)
+		if err != nil {
+			h.sendError(client, env.ClientMsgId, err)
+			return
+		}
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameRecall, "", item)
+		}
+		h.hub.SendFrameToClient(client, ws.FrameAck, env.ClientMsgId, ws.AckPayload{MessageId: item.MessageId})
+
+	case ws.FrameEdit:
+		var req chatRequest.EditMessageRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		recipients, item, err := h.svc.EditMessage(clientID, req)
+		if err != nil {
+			h.sendError(client, env.ClientMsgId, err)
+			return
+		}
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameEdit, "", item)
+		}
+		h.hub.SendFrameToClient(client, ws.FrameAck, env.ClientMsgId, ws.AckPayload{MessageId: item.MessageId})
+
+	case ws.FrameReaction:
+		var req chatRequest.ReactMessageRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		recipients, item, err := h.svc.ReactMessage(clientID, req)
+		if err != nil {
+			h.sendError(client, env.ClientMsgId, err)
+			return
+		}
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameReaction, "", item)
+		}
+		h.hub.SendFrameToClient(client, ws.FrameAck, env.ClientMsgId, ws.AckPayload{MessageId: item.MessageId})
+
+	case ws.FrameRead:
+		var req chatRequest.MarkReadRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		recipients, item, err := h.svc.MarkRead(clientID, req)
+		if err != nil {
+			h.sendError(client, env.ClientMsgId, err)
+			return
+		}
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameRead, "", item)
+		}
+
+	case ws.FrameTyping:
+		var req chatRequest.TypingRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		recipients, item, err := h.svc.Typing(clientID, req)
+		if err != nil {
+			h.sendError(client, env.ClientMsgId, err)
+			return
+		}
+		for _, uid := range recipients {
+			_ = h.hub.SendFrame(uid, ws.FrameTyping, "", item)
+		}
+
+	case ws.FramePresence:
+		var req chatRequest.PresenceRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		data, err := h.presenceSvc.Handle(clientID, client.ConnID(), req)
+		if err != nil {
+			h.sendError(client, env.ClientMsgId, err)
+			return
+		}
+		if data != nil {
+			h.hub.SendFrameToClient(client, ws.FramePresence, env.ClientMsgId, data)
+		}
+
+	case ws.FrameSync:
+		var req chatRequest.SyncMessagesRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		h.replay(client, req.Cursor)
+
+	case ws.FrameCall:
+		var req chatRequest.CallSignalRequest
+		if !h.decodePayload(client, env, &req) {
+			return
+		}
+		if err := h.callSvc.Handle(clientID, deviceID, env.ClientMsgId, req); err != nil {
+			h.sendError(client, env.ClientMsgId, err)
+		}
+
+	default:
+		h.replyError(client, env.ClientMsgId, xerr.BadRequest, "不支持的帧类型: "+env.Type)
+	}
+}
+
+// watchExpiry 连接建立后令牌仍会过期：临近过期时提醒一次客户端续期，过期未续期则断开连接
+func (h *WsHandler) watchExpiry(client *ws.Client) {
+	ticker := time.NewTicker(authCheckInterval)
+	defer ticker.Stop()
+
+	var warned time.Time
+	for {
+		select {
+		case <-client.Done():
+			return
+		case now := <-ticker.C:
+			exp := client.ExpiresAt()
+			if exp.IsZero() {
 				continue
 			}
-			for _, mid := range memberIDs {
-				_ = h.hub.SendJSON(mid, item)
+			if !now.Before(exp) {
+				h.hub.CloseClient(client, ws.NotificationAuthExpired, nil)
+				return
+			}
+			if exp.Sub(now) <= authRenewWindow && !exp.Equal(warned) {
+				warned = exp
+				h.hub.SendFrameToClient(client, ws.FrameNotification, "", ws.NotificationPayload{
+					Kind: ws.NotificationAuthExpiring,
+					Data: chatRespond.WsAuthRespond{ExpiresAt: exp.Unix()},
+				})
 			}
-			continue
 		}
+	}
+}
+
+// allowFrame 按帧类型限流，同一用户的所有连接共享计数；被限流时回 error 帧并带上建议等待秒数
+func (h *WsHandler) allowFrame(client *ws.Client, frameType string, clientMsgID string) bool {
+	res := ratelimit.Check(ratelimit.ScopeWsFrame, frameType, client.UserID())
+	if res.Allowed {
+		return true
+	}
+	h.hub.SendFrameToClient(client, ws.FrameError, clientMsgID, ws.ErrorPayload{
+		Code:       xerr.TooManyRequests,
+		Message:    "操作过于频繁，请稍后再试",
+		RetryAfter: res.RetryAfterSeconds(),
+	})
+	return false
+}
 
-		senderItem, receiverItem, err := h.svc.SendPrivateMessage(clientID, req)
+// handleSend ack 只回给发起发送的连接，消息本身仍推送给发送方的所有设备以保持多端同步
+func (h *WsHandler) handleSend(client *ws.Client, req chatRequest.SendMessageRequest) {
+	clientID := client.UserID()
+	if strings.HasPrefix(req.ReceiveId, "G") {
+		memberIDs, item, err := h.svc.SendGroupMessage(clientID, req)
 		if err != nil {
-			_ = h.hub.SendJSON(clientID, map[string]interface{}{
-				"type":    "error",
-				"message": err.Error(),
-			})
-			continue
+			h.sendError(client, req.ClientMsgId, err)
+			return
+		}
+		h.hub.SendFrameToClient(client, ws.FrameAck, req.ClientMsgId, ackOf(item))
+		for _, mid := range memberIDs {
+			_ = h.hub.SendFrame(mid, ws.FrameMessage, "", item)
 		}
+		return
+	}
 
-		_ = h.hub.SendJSON(clientID, senderItem)
-		_ = h.hub.SendJSON(req.ReceiveId, receiverItem)
+	senderItem, receiverItem, err := h.svc.SendPrivateMessage(clientID, req)
+	if err != nil {
+		h.sendError(client, req.ClientMsgId, err)
+		return
+	}
+
+	// 重试命中去重时同样回 ack 并重新推送，接收端按 uuid 去重
+	h.hub.SendFrameToClient(client, ws.FrameAck, req.ClientMsgId, ackOf(senderItem))
+	_ = h.hub.SendFrame(clientID, ws.FrameMessage, "", senderItem)
+	_ = h.hub.SendFrame(req.ReceiveId, ws.FrameMessage, "", receiverItem)
+}
+
+func (h *WsHandler) decodePayload(client *ws.Client, env ws.Envelope, v interface{}) bool {
+	if len(env.Payload) == 0 {
+		h.replyError(client, env.ClientMsgId, xerr.BadRequest, "payload 不能为空")
+		return false
+	}
+	if err := json.Unmarshal(env.Payload, v); err != nil {
+		h.replyError(client, env.ClientMsgId, xerr.BadRequest, "消息格式错误")
+		return false
+	}
+	return true
+}
+
+func (h *WsHandler) sendError(client *ws.Client, clientMsgID string, err error) {
+	var ce *xerr.CodeError
+	if errors.As(err, &ce) {
+		h.replyError(client, clientMsgID, ce.Code, ce.Message)
+		return
+	}
+	h.replyError(client, clientMsgID, xerr.InternalServerError, err.Error())
+}
+
+// replyError 错误帧只回给出错的连接，不打扰同一用户的其他设备
+func (h *WsHandler) replyError(client *ws.Client, clientMsgID string, code int, message string) {
+	h.hub.SendFrameToClient(client, ws.FrameError, clientMsgID, ws.ErrorPayload{Code: code, Message: message})
+}
+
+func ackOf(item *chatRespond.MessageItem) ws.AckPayload {
+	return ws.AckPayload{
+		MessageId: item.Uuid,
+		ConvId:    item.ConvId,
+		Seq:       item.Seq,
+		CreatedAt: item.CreatedAt,
+	}
+}
+
+// replay 下发一批增量消息；has_more 为 true 时客户端携带返回的 cursor 继续发送 sync 帧拉取下一批，
+// 避免一次性回放撑满发送缓冲
+func (h *WsHandler) replay(client *ws.Client, cursor string) {
+	data, err := h.messageSvc.SyncMessages(client.UserID(), chatRequest.SyncMessagesRequest{Cursor: cursor})
+	if err != nil {
+		h.sendError(client, "", err)
+		return
+	}
+	h.hub.SendFrameToClient(client, ws.FrameSync, "", data)
+}
+
+// IssueTicket 签发一次性 WS 连接票据
+func (h *WsHandler) IssueTicket(c *gin.Context) {
+	v, ok := c.Get("claims")
+	claims, _ := v.(*myjwt.CustomClaims)
+	if !ok || claims == nil {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+	ttl := config.GetConfig().WsConfig.TicketTTLSeconds
+	if ttl <= 0 {
+		ttl = 30
+	}
+	ticket, err := myjwt.IssueTicket(claims, time.Duration(ttl)*time.Second)
+	if err != nil {
+		zlog.Error("issue ws ticket failed: " + err.Error())
+		back.Result(c, nil, xerr.ErrServerError)
+		return
+	}
+	back.Result(c, chatRespond.WsTicketRespond{Ticket: ticket, ExpiresIn: ttl}, nil)
+}
+
+// DeliveryStats 查询当前用户在本节点上的投递统计（排查丢消息用）
+func (h *WsHandler) DeliveryStats(c *gin.Context) {
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
 	}
+	back.Result(c, h.hub.DeliveryStats(uuid), nil)
 }
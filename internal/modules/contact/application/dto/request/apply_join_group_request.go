@@ -0,0 +1,7 @@
+package request
+
+type ApplyJoinGroupRequest struct {
+	GroupId string `json:"group_id" binding:"required"`
+	Message string `json:"message"`
+	OwnerId string `json:"-"`
+}
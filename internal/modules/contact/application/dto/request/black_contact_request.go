@@ -0,0 +1,11 @@
+package request
+
+type BlackContactRequest struct {
+	ContactId string `json:"contact_id"`
+	OwnerId   string `json:"-"`
+}
+
+type CancelBlackContactRequest struct {
+	ContactId string `json:"contact_id"`
+	OwnerId   string `json:"-"`
+}
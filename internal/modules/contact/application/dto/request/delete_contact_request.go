@@ -0,0 +1,6 @@
+package request
+
+type DeleteContactRequest struct {
+	ContactId string `json:"contact_id"`
+	OwnerId   string `json:"-"`
+}
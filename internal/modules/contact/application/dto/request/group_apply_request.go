@@ -0,0 +1,22 @@
+package request
+
+// GetGroupApplyListRequest GroupId 为空时返回自己可审批的所有群的待处理申请
+type GetGroupApplyListRequest struct {
+	GroupId string `json:"group_id"`
+	OwnerId string `json:"-"`
+}
+
+type PassGroupApplyRequest struct {
+	ApplyId string `json:"apply_id" binding:"required"`
+	OwnerId string `json:"-"`
+}
+
+type RefuseGroupApplyRequest struct {
+	ApplyId string `json:"apply_id" binding:"required"`
+	OwnerId string `json:"-"`
+}
+
+type BatchPassGroupApplyRequest struct {
+	ApplyIds []string `json:"apply_ids" binding:"required,min=1"`
+	OwnerId  string   `json:"-"`
+}
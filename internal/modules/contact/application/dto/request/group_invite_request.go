@@ -0,0 +1,25 @@
+package request
+
+// CreateGroupInviteRequest ExpireSeconds 为 0 时默认 7 天；MaxUses 为 0 表示不限次数
+type CreateGroupInviteRequest struct {
+	GroupId       string `json:"group_id" binding:"required"`
+	ExpireSeconds int64  `json:"expire_seconds"`
+	MaxUses       int    `json:"max_uses"`
+	OwnerId       string `json:"-"`
+}
+
+type GetGroupInviteListRequest struct {
+	GroupId string `json:"group_id" binding:"required"`
+	OwnerId string `json:"-"`
+}
+
+type GroupInviteTokenRequest struct {
+	Token   string `json:"token" binding:"required"`
+	OwnerId string `json:"-"`
+}
+
+type RedeemGroupInviteRequest struct {
+	Token   string `json:"token" binding:"required"`
+	Message string `json:"message"`
+	OwnerId string `json:"-"`
+}
@@ -0,0 +1,35 @@
+package request
+
+type TransferGroupOwnerRequest struct {
+	GroupId    string `json:"group_id" binding:"required"`
+	NewOwnerId string `json:"new_owner_id" binding:"required"`
+	OwnerId    string `json:"-"`
+}
+
+type SetGroupAdminRequest struct {
+	GroupId string `json:"group_id" binding:"required"`
+	UserId  string `json:"user_id" binding:"required"`
+	IsAdmin bool   `json:"is_admin"`
+	OwnerId string `json:"-"`
+}
+
+type RemoveGroupMembersRequest struct {
+	GroupId   string   `json:"group_id" binding:"required"`
+	MemberIds []string `json:"member_ids" binding:"required,min=1"`
+	OwnerId   string   `json:"-"`
+}
+
+// MuteGroupMemberRequest Duration 为禁言秒数，0 表示解除禁言
+type MuteGroupMemberRequest struct {
+	GroupId  string `json:"group_id" binding:"required"`
+	UserId   string `json:"user_id" binding:"required"`
+	Duration int64  `json:"duration"`
+	OwnerId  string `json:"-"`
+}
+
+// MuteGroupRequest Duration 为全员禁言秒数，0 表示关闭全员禁言
+type MuteGroupRequest struct {
+	GroupId  string `json:"group_id" binding:"required"`
+	Duration int64  `json:"duration"`
+	OwnerId  string `json:"-"`
+}
@@ -0,0 +1,19 @@
+package request
+
+// UpdateGroupInfoRequest 资料字段未传或为 null 时不修改
+type UpdateGroupInfoRequest struct {
+	GroupId    string  `json:"group_id" binding:"required"`
+	Name       *string `json:"name"`
+	Notice     *string `json:"notice"`
+	Avatar     *string `json:"avatar"`
+	AddMode    *int8   `json:"add_mode"`
+	InviteMode *int8   `json:"invite_mode"`
+	OwnerId    string  `json:"-"`
+}
+
+type GetGroupInfoHistoryRequest struct {
+	GroupId  string `json:"group_id" binding:"required"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	OwnerId  string `json:"-"`
+}
@@ -0,0 +1,7 @@
+package respond
+
+// ApplyJoinGroupRespond Joined 为 true 表示已直接入群，否则 ApplyId 为待审核的申请
+type ApplyJoinGroupRespond struct {
+	ApplyId string `json:"apply_id,omitempty"`
+	Joined  bool   `json:"joined"`
+}
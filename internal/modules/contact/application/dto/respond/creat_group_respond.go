@@ -1,14 +1,17 @@
 package respond
 
 type CreateGroupRespond struct {
-	Uuid      string `json:"uuid"`
-	GroupId   string `json:"group_id"`
-	Name      string `json:"name"`
-	Notice    string `json:"notice"`
-	OwnerId   string `json:"owner_id"`
-	MemberCnt int    `json:"member_cnt"`
-	Avatar    string `json:"avatar"`
-	Status    int8   `json:"status"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	Uuid       string `json:"uuid"`
+	GroupId    string `json:"group_id"`
+	Name       string `json:"name"`
+	Notice     string `json:"notice"`
+	OwnerId    string `json:"owner_id"`
+	MemberCnt  int    `json:"member_cnt"`
+	Avatar     string `json:"avatar"`
+	Status     int8   `json:"status"`
+	AddMode    int8   `json:"add_mode"`
+	InviteMode int8   `json:"invite_mode"`          // 邀请链接创建权限，0.所有成员，1.仅群主和管理员
+	MuteUntil  string `json:"mute_until,omitempty"` // 全员禁言截止时间，未禁言时为空
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
 }
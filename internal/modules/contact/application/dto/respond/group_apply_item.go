@@ -0,0 +1,24 @@
+package respond
+
+type GroupApplyItem struct {
+	ApplyId     string `json:"apply_id"`
+	GroupId     string `json:"group_id"`
+	GroupName   string `json:"group_name"`
+	UserId      string `json:"user_id"`
+	Username    string `json:"username"`
+	Nickname    string `json:"nickname"`
+	Avatar      string `json:"avatar"`
+	InviterId   string `json:"inviter_id,omitempty"`
+	Message     string `json:"message"`
+	LastApplyAt string `json:"last_apply_at"`
+}
+
+type GroupApplyFailure struct {
+	ApplyId string `json:"apply_id"`
+	Message string `json:"message"`
+}
+
+type BatchPassGroupApplyRespond struct {
+	Passed []string            `json:"passed"`
+	Failed []GroupApplyFailure `json:"failed"`
+}
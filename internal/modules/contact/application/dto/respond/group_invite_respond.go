@@ -0,0 +1,28 @@
+package respond
+
+type GroupInviteRespond struct {
+	Token       string `json:"token"`
+	GroupId     string `json:"group_id"`
+	CreatorId   string `json:"creator_id"`
+	CreatorName string `json:"creator_name"`
+	ExpireAt    string `json:"expire_at"`
+	MaxUses     int    `json:"max_uses"` // 0 表示不限次数
+	UsedCount   int    `json:"used_count"`
+	Status      int8   `json:"status"` // 0.有效，1.已撤销，2.已过期，3.次数已用完
+	CreatedAt   string `json:"created_at"`
+	Link        string `json:"link,omitempty"` // 未配置邀请链接前缀时为空
+	QrPayload   string `json:"qr_payload"`     // 二维码内容，客户端直接编码即可
+}
+
+// GroupInvitePreviewRespond 兑换前展示的群信息
+type GroupInvitePreviewRespond struct {
+	GroupId     string `json:"group_id"`
+	GroupName   string `json:"group_name"`
+	Avatar      string `json:"avatar"`
+	MemberCnt   int    `json:"member_cnt"`
+	AddMode     int8   `json:"add_mode"`
+	CreatorId   string `json:"creator_id"`
+	CreatorName string `json:"creator_name"`
+	ExpireAt    string `json:"expire_at"`
+	Joined      bool   `json:"joined"` // 当前用户已在群内
+}
@@ -1,10 +1,12 @@
 package respond
 
 type GroupMemberRespond struct {
-	UserId   string `json:"user_id"`
-	Username string `json:"username"`
-	Nickname string `json:"nickname"`
-	Avatar   string `json:"avatar"`
-	Gender   int8   `json:"gender"`
-	Role     int8   `json:"role"` // 0: member, 1: owner
-}
\ No newline at end of file
+	UserId    string `json:"user_id"`
+	Username  string `json:"username"`
+	Nickname  string `json:"nickname"`
+	Avatar    string `json:"avatar"`
+	Gender    int8   `json:"gender"`
+	Role      int8   `json:"role"` // 0: member, 1: owner, 2: admin
+	Muted     bool   `json:"muted"`
+	MuteUntil string `json:"mute_until,omitempty"` // 为空且 muted 为 true 表示永久禁言
+}
@@ -0,0 +1,17 @@
+package respond
+
+type GroupProfileHistoryItem struct {
+	Field        string `json:"field"` // name / notice / avatar / add_mode
+	OldValue     string `json:"old_value"`
+	NewValue     string `json:"new_value"`
+	OperatorId   string `json:"operator_id"`
+	OperatorName string `json:"operator_name"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type GroupProfileHistoryRespond struct {
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+	Items    []GroupProfileHistoryItem `json:"items"`
+}
@@ -0,0 +1,7 @@
+package respond
+
+// InviteGroupMembersRespond Joined 为已直接入群的成员，Pending 为等待群主或管理员审核的成员
+type InviteGroupMembersRespond struct {
+	Joined  []string `json:"joined"`
+	Pending []string `json:"pending"`
+}
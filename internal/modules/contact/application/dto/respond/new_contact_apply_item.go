@@ -3,6 +3,8 @@ package respond
 type NewContactApplyItem struct {
 	Uuid        string `json:"uuid"`
 	UserId      string `json:"user_id"`
+	ContactId   string `json:"contact_id"`   // 好友申请为自己，入群申请为群组uuid
+	ContactType int8   `json:"contact_type"` // 0.好友申请，1.入群申请，需使用群聊审批接口处理
 	Username    string `json:"username"`
 	Nickname    string `json:"nickname"`
 	Avatar      string `json:"avatar"`
@@ -2,12 +2,12 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"strings"
 	"time"
 
 	aiIngest "OmniLink/internal/modules/ai/application/service"
+	chatRepository "OmniLink/internal/modules/chat/domain/repository"
 	contactRequest "OmniLink/internal/modules/contact/application/dto/request"
 	contactRespond "OmniLink/internal/modules/contact/application/dto/respond"
 	contactEntity "OmniLink/internal/modules/contact/domain/entity"
@@ -20,6 +20,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// errGroupApplyMoved 好友申请接口不再处理入群申请
+var errGroupApplyMoved = xerr.New(xerr.BadRequest, "入群申请请使用 /group/passGroupApply 或 /group/refuseGroupApply 审批")
+
 type ContactService interface {
 	GetUserList(req contactRequest.GetUserListRequest) ([]contactRespond.UserListItem, error)
 	GetContactInfo(req contactRequest.GetContactInfoRequest) (*contactRespond.GetContactInfoRespond, error)
@@ -28,6 +31,17 @@ type ContactService interface {
 	PassContactApply(req contactRequest.PassContactApplyRequest) error
 	RefuseContactApply(req contactRequest.RefuseContactApplyRequest) error
 	LoadMyJoinedGroup(req contactRequest.LoadMyJoinedGroupRequest) ([]contactRespond.JoinedGroupItem, error)
+	// DeleteContact 删除好友：双方关系置为删除/被删除，并删除双方的私聊会话；已删除时返回 false
+	DeleteContact(req contactRequest.DeleteContactRequest) (bool, error)
+	// BlackContact 拉黑好友：己方置为拉黑，对方置为被拉黑；对方已拉黑自己时双方均为拉黑；已拉黑时返回 false
+	BlackContact(req contactRequest.BlackContactRequest) (bool, error)
+	// CancelBlackContact 取消拉黑，按对方当前状态恢复双方关系；返回关系是否发生变化
+	CancelBlackContact(req contactRequest.CancelBlackContactRequest) (bool, error)
+}
+
+// PresenceWatchStore 在线状态订阅存储，由 ws.PresenceStore 实现
+type PresenceWatchStore interface {
+	Unwatch(ctx context.Context, watcherID string, targetIDs []string) error
 }
 
 type contactServiceImpl struct {
@@ -36,15 +50,17 @@ type contactServiceImpl struct {
 	userRepo    userRepository.UserInfoRepository
 	uow         contactRepository.ContactUnitOfWork
 	aiIngest    aiIngest.AsyncIngestService
+	presence    PresenceWatchStore
 }
 
-func NewContactService(contactRepo contactRepository.UserContactRepository, applyRepo contactRepository.ContactApplyRepository, userRepo userRepository.UserInfoRepository, uow contactRepository.ContactUnitOfWork, aiIngestSvc aiIngest.AsyncIngestService) ContactService {
+func NewContactService(contactRepo contactRepository.UserContactRepository, applyRepo contactRepository.ContactApplyRepository, userRepo userRepository.UserInfoRepository, uow contactRepository.ContactUnitOfWork, aiIngestSvc aiIngest.AsyncIngestService, presence PresenceWatchStore) ContactService {
 	return &contactServiceImpl{
 		contactRepo: contactRepo,
 		applyRepo:   applyRepo,
 		userRepo:    userRepo,
 		uow:         uow,
 		aiIngest:    aiIngestSvc,
+		presence:    presence,
 	}
 }
 
@@ -193,11 +209,8 @@ func (s *contactServiceImpl) PassContactApply(req contactRequest.PassContactAppl
 	now := time.Now()
 	var friendA string
 	var friendB string
-	var groupID string
-	var groupMembers []string
-	var groupApplicant string
 
-	err := s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+	err := s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, _ contactRepository.GroupInfoRepository) error {
 		apply, err := applyRepo.GetContactApplyByUUIDForUpdate(req.ApplyId)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -206,6 +219,10 @@ func (s *contactServiceImpl) PassContactApply(req contactRequest.PassContactAppl
 			zlog.Error(err.Error())
 			return xerr.ErrServerError
 		}
+		// 入群申请由群聊审批接口处理（加锁顺序、审批结果推送与之保持一致）
+		if apply.ContactType == 1 {
+			return errGroupApplyMoved
+		}
 
 		if apply.Status == 1 {
 			return nil
@@ -265,92 +282,15 @@ func (s *contactServiceImpl) PassContactApply(req contactRequest.PassContactAppl
 			return nil
 		}
 
-		// 群组申请处理
-		if apply.ContactType == 1 {
-			group, err := groupRepo.GetGroupInfoByUUID(apply.ContactId)
-			if err != nil {
-				return err
-			}
-			if group.Status != 0 {
-				return xerr.New(xerr.Forbidden, "群组状态异常")
-			}
-			if group.OwnerId != req.OwnerId {
-				return xerr.New(xerr.Forbidden, "只有群主可以审批入群申请")
-			}
-
-			apply.Status = 1
-			if err := applyRepo.UpdateContactApply(apply); err != nil {
-				return err
-			}
-
-			// Upsert 群成员关系
-			rel, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(apply.UserId, apply.ContactId, 1)
-			if err == nil {
-				if rel.Status != 0 {
-					rel.Status = 0
-					rel.UpdateAt = now
-					if err := contactRepo.UpdateUserContact(rel); err != nil {
-						return err
-					}
-				}
-			} else if errors.Is(err, gorm.ErrRecordNotFound) {
-				newRel := &contactEntity.UserContact{
-					UserId:      apply.UserId,
-					ContactId:   apply.ContactId,
-					ContactType: 1,
-					Status:      0,
-					CreatedAt:   now,
-					UpdateAt:    now,
-				}
-				if err := contactRepo.CreateUserContact(newRel); err != nil {
-					return err
-				}
-			} else {
-				return err
-			}
-
-			// 更新群成员信息
-			allMembers, err := contactRepo.GetGroupMembers(apply.ContactId)
-			if err == nil {
-				var allIDs []string
-				for _, m := range allMembers {
-					allIDs = append(allIDs, m.UserId)
-				}
-				groupMembers = append([]string(nil), allIDs...)
-				membersJSON, _ := json.Marshal(allIDs)
-				group.Members = membersJSON
-				group.MemberCnt = len(allIDs)
-				group.UpdatedAt = now
-				if err := groupRepo.UpdateGroupInfo(group); err != nil {
-					return err
-				}
-			}
-
-			groupID = apply.ContactId
-			groupApplicant = apply.UserId
-			return nil
-		}
-
 		return xerr.New(xerr.BadRequest, "不支持的申请类型")
 	})
 	if err != nil {
 		return err
 	}
 
-	if s.aiIngest != nil {
-		if friendA != "" && friendB != "" {
-			_ = s.aiIngest.EnqueueContactProfile(context.Background(), friendA, friendB)
-			_ = s.aiIngest.EnqueueContactProfile(context.Background(), friendB, friendA)
-		}
-		if groupID != "" {
-			members := groupMembers
-			if len(members) == 0 && groupApplicant != "" {
-				members = []string{groupApplicant}
-			}
-			for _, uid := range members {
-				_ = s.aiIngest.EnqueueGroupProfile(context.Background(), uid, groupID)
-			}
-		}
+	if s.aiIngest != nil && friendA != "" && friendB != "" {
+		_ = s.aiIngest.EnqueueContactProfile(context.Background(), friendA, friendB)
+		_ = s.aiIngest.EnqueueContactProfile(context.Background(), friendB, friendA)
 	}
 
 	return nil
@@ -361,7 +301,7 @@ func (s *contactServiceImpl) RefuseContactApply(req contactRequest.RefuseContact
 		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
 	}
 
-	return s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, _ contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+	return s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, _ contactRepository.UserContactRepository, _ contactRepository.GroupInfoRepository) error {
 		apply, err := applyRepo.GetContactApplyByUUIDForUpdate(req.ApplyId)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -370,6 +310,9 @@ func (s *contactServiceImpl) RefuseContactApply(req contactRequest.RefuseContact
 			zlog.Error(err.Error())
 			return xerr.ErrServerError
 		}
+		if apply.ContactType == 1 {
+			return errGroupApplyMoved
+		}
 
 		if apply.Status == 2 {
 			return nil
@@ -381,22 +324,12 @@ func (s *contactServiceImpl) RefuseContactApply(req contactRequest.RefuseContact
 			return xerr.New(xerr.Forbidden, "该申请已被拉黑")
 		}
 
-		switch apply.ContactType {
-		case 0:
-			if apply.ContactId != req.OwnerId {
-				return xerr.New(xerr.Forbidden, "无权操作该申请")
-			}
-		case 1:
-			group, err := groupRepo.GetGroupInfoByUUID(apply.ContactId)
-			if err != nil {
-				return err
-			}
-			if group.OwnerId != req.OwnerId {
-				return xerr.New(xerr.Forbidden, "只有群主可以审批")
-			}
-		default:
+		if apply.ContactType != 0 {
 			return xerr.New(xerr.BadRequest, "不支持的申请类型")
 		}
+		if apply.ContactId != req.OwnerId {
+			return xerr.New(xerr.Forbidden, "无权操作该申请")
+		}
 
 		apply.Status = 2
 		if err := applyRepo.UpdateContactApply(apply); err != nil {
@@ -525,6 +458,10 @@ func (s *contactServiceImpl) ApplyContact(req contactRequest.ApplyContactRequest
 				zlog.Error(err.Error())
 				return xerr.ErrServerError
 			}
+			// 己方删除了对方后，对方的拉黑仍然有效
+			if peer, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(req.ContactId, req.OwnerId, 0); err == nil && peer.Status == 1 {
+				return xerr.New(xerr.Forbidden, "对方已将您拉黑")
+			}
 		}
 
 		now := time.Now()
@@ -593,8 +530,8 @@ func (s *contactServiceImpl) GetNewContactList(req contactRequest.GetNewContactL
 			return err
 		}
 
-		// 2. 获取我拥有的群组
-		myGroups, err := groupRepo.ListByOwnerID(req.OwnerId)
+		// 2. 获取我作为群主或管理员的群组
+		myGroups, err := groupRepo.ListManagedGroups(req.OwnerId)
 		if err != nil {
 			return err
 		}
@@ -681,7 +618,8 @@ func (s *contactServiceImpl) GetNewContactList(req contactRequest.GetNewContactL
 			item.Nickname = b.nickname
 			item.Avatar = b.avatar
 		}
-		// 可以考虑在 Message 或其他字段标识这是群申请
+		item.ContactId = a.ContactId
+		item.ContactType = a.ContactType
 		if a.ContactType == 1 {
 			item.Message = "[申请入群] " + item.Message
 		}
@@ -693,3 +631,217 @@ func (s *contactServiceImpl) GetNewContactList(req contactRequest.GetNewContactL
 	// 这里暂不重新排序，或者假设前端会处理，或者由于只是少量数据，可以接受
 	return out, nil
 }
+
+// friendPair 读取双方的好友关系行，任一方不存在时返回非好友错误
+func friendPair(contactRepo contactRepository.UserContactRepository, ownerID string, contactID string) (*contactEntity.UserContact, *contactEntity.UserContact, error) {
+	mine, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(ownerID, contactID, 0)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, xerr.New(xerr.NotFound, "对方不是您的好友")
+		}
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	peer, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(contactID, ownerID, 0)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, xerr.New(xerr.NotFound, "对方不是您的好友")
+		}
+		zlog.Error(err.Error())
+		return nil, nil, xerr.ErrServerError
+	}
+	return mine, peer, nil
+}
+
+// updateStatus 状态有变化时才写库
+func updateStatus(contactRepo contactRepository.UserContactRepository, rel *contactEntity.UserContact, status int8, now time.Time) error {
+	if rel.Status == status {
+		return nil
+	}
+	rel.Status = status
+	rel.UpdateAt = now
+	return contactRepo.UpdateUserContact(rel)
+}
+
+func checkFriendReq(ownerID string, contactID string) error {
+	if ownerID == "" || contactID == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if ownerID == contactID {
+		return xerr.New(xerr.BadRequest, "不能对自己操作")
+	}
+	if strings.HasPrefix(contactID, "G") {
+		return xerr.New(xerr.BadRequest, "群聊请使用退群接口")
+	}
+	return nil
+}
+
+func (s *contactServiceImpl) DeleteContact(req contactRequest.DeleteContactRequest) (bool, error) {
+	if err := checkFriendReq(req.OwnerId, req.ContactId); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	changed := false
+	err := s.uow.TransactionWithSessions(func(contactRepo contactRepository.UserContactRepository, sessionRepo chatRepository.SessionRepository) error {
+		mine, peer, err := friendPair(contactRepo, req.OwnerId, req.ContactId)
+		if err != nil {
+			return err
+		}
+		switch mine.Status {
+		case 3:
+			return nil
+		case 0, 1, 2:
+		default:
+			return xerr.New(xerr.BadRequest, "对方不是您的好友")
+		}
+
+		changed = true
+		if err := updateStatus(contactRepo, mine, 3, now); err != nil {
+			return err
+		}
+		// 对方拉黑了自己或已先删除自己时保留对方的状态
+		if peer.Status == 0 || peer.Status == 2 {
+			if err := updateStatus(contactRepo, peer, 4, now); err != nil {
+				return err
+			}
+		}
+		return sessionRepo.DeleteBetween(req.OwnerId, req.ContactId)
+	})
+	if err != nil {
+		var ce *xerr.CodeError
+		if !errors.As(err, &ce) {
+			zlog.Error(err.Error())
+			return false, xerr.ErrServerError
+		}
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+
+	s.unwatchPresence(req.OwnerId, req.ContactId)
+	s.reingestContact(req.OwnerId, req.ContactId)
+	return true, nil
+}
+
+func (s *contactServiceImpl) BlackContact(req contactRequest.BlackContactRequest) (bool, error) {
+	if err := checkFriendReq(req.OwnerId, req.ContactId); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	changed := false
+	err := s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, _ contactRepository.GroupInfoRepository) error {
+		mine, peer, err := friendPair(contactRepo, req.OwnerId, req.ContactId)
+		if err != nil {
+			return err
+		}
+		switch mine.Status {
+		case 1:
+			return nil
+		case 0, 2:
+		default:
+			return xerr.New(xerr.BadRequest, "对方不是您的好友")
+		}
+
+		changed = true
+		if err := updateStatus(contactRepo, mine, 1, now); err != nil {
+			return err
+		}
+		// 对方已拉黑自己（状态 1）时保持不变，双方均为拉黑
+		if peer.Status == 0 {
+			if err := updateStatus(contactRepo, peer, 2, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var ce *xerr.CodeError
+		if !errors.As(err, &ce) {
+			zlog.Error(err.Error())
+			return false, xerr.ErrServerError
+		}
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+
+	s.unwatchPresence(req.OwnerId, req.ContactId)
+	s.reingestContact(req.OwnerId, req.ContactId)
+	return true, nil
+}
+
+func (s *contactServiceImpl) CancelBlackContact(req contactRequest.CancelBlackContactRequest) (bool, error) {
+	if err := checkFriendReq(req.OwnerId, req.ContactId); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	changed := false
+	err := s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, _ contactRepository.GroupInfoRepository) error {
+		mine, peer, err := friendPair(contactRepo, req.OwnerId, req.ContactId)
+		if err != nil {
+			return err
+		}
+		if mine.Status != 1 {
+			return xerr.New(xerr.BadRequest, "未拉黑对方")
+		}
+
+		changed = true
+		switch peer.Status {
+		case 1:
+			// 对方也拉黑了自己：己方变为被拉黑
+			return updateStatus(contactRepo, mine, 2, now)
+		case 3:
+			// 拉黑期间对方删除了自己：己方变为被删除
+			return updateStatus(contactRepo, mine, 4, now)
+		default:
+			if err := updateStatus(contactRepo, mine, 0, now); err != nil {
+				return err
+			}
+			return updateStatus(contactRepo, peer, 0, now)
+		}
+	})
+	if err != nil {
+		var ce *xerr.CodeError
+		if !errors.As(err, &ce) {
+			zlog.Error(err.Error())
+			return false, xerr.ErrServerError
+		}
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+
+	s.unwatchPresence(req.OwnerId, req.ContactId)
+	s.reingestContact(req.OwnerId, req.ContactId)
+	return true, nil
+}
+
+// reingestContact 关系变化后双方重新生成好友画像；关系不再正常时 AI 侧会移除对应画像
+func (s *contactServiceImpl) reingestContact(userA string, userB string) {
+	if s.aiIngest == nil {
+		return
+	}
+	_ = s.aiIngest.EnqueueContactProfile(context.Background(), userA, userB)
+	_ = s.aiIngest.EnqueueContactProfile(context.Background(), userB, userA)
+}
+
+// unwatchPresence 关系变化后撤销双方对彼此在线状态的订阅，恢复正常后由客户端重新订阅
+func (s *contactServiceImpl) unwatchPresence(userA string, userB string) {
+	if s.presence == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := s.presence.Unwatch(ctx, userA, []string{userB}); err != nil {
+		zlog.Error("presence unwatch failed: " + err.Error())
+	}
+	if err := s.presence.Unwatch(ctx, userB, []string{userA}); err != nil {
+		zlog.Error("presence unwatch failed: " + err.Error())
+	}
+}
@@ -0,0 +1,700 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	contactRequest "OmniLink/internal/modules/contact/application/dto/request"
+	contactRespond "OmniLink/internal/modules/contact/application/dto/respond"
+	contactEntity "OmniLink/internal/modules/contact/domain/entity"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	"OmniLink/pkg/util"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"gorm.io/gorm"
+)
+
+// 入群审核相关的通知种类
+const (
+	NotificationGroupApply        = "group.apply"         // 新的入群申请，推送给可审批的群管理者
+	NotificationGroupApplyPassed  = "group.apply.passed"  // 入群申请已通过，推送给申请人与邀请人
+	NotificationGroupApplyRefused = "group.apply.refused" // 入群申请被拒绝，推送给申请人与邀请人
+)
+
+const maxBatchGroupApply = 100
+
+// GroupNotifier 入群审核结果的推送通道，由 ws.Hub 实现
+type GroupNotifier interface {
+	SendNotification(userID string, kind string, data interface{}) error
+}
+
+// isActiveMember 成员关系为正常或被禁言时视为仍在群内
+func isActiveMember(rel *contactEntity.UserContact) bool {
+	return rel != nil && (rel.Status == 0 || rel.Status == 5)
+}
+
+// loadMember 读取仍在群内的成员关系，不在群内时返回 nil
+func loadMember(contactRepo contactRepository.UserContactRepository, groupID string, userID string) (*contactEntity.UserContact, error) {
+	rel, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, groupID, 1)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !isActiveMember(rel) {
+		return nil, nil
+	}
+	return rel, nil
+}
+
+// isGroupManager 判断用户是否为群主或管理员（审批入群申请、邀请成员免审核）
+func isGroupManager(contactRepo contactRepository.UserContactRepository, group *contactEntity.GroupInfo, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	if group.OwnerId == userID {
+		return true, nil
+	}
+	rel, err := loadMember(contactRepo, group.Uuid, userID)
+	if err != nil {
+		return false, err
+	}
+	return rel != nil && group.RoleOf(rel) == contactEntity.GroupRoleAdmin, nil
+}
+
+// groupManagers 返回可审批入群申请的用户：群主与全部管理员
+func groupManagers(contactRepo contactRepository.UserContactRepository, group *contactEntity.GroupInfo) ([]string, error) {
+	members, err := contactRepo.GetGroupMembers(group.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	managers := []string{group.OwnerId}
+	for i := range members {
+		if isActiveMember(&members[i]) && group.RoleOf(&members[i]) == contactEntity.GroupRoleAdmin {
+			managers = append(managers, members[i].UserId)
+		}
+	}
+	return managers, nil
+}
+
+// addGroupMembers 将用户加入群并重算 Members/MemberCnt，返回新加入的用户与加入后的全部成员。
+// 调用方需在事务内以 GetGroupInfoByUUIDForUpdate 读取 group，保证并发入群时成员列表不丢失
+func addGroupMembers(contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository, group *contactEntity.GroupInfo, userIDs []string, now time.Time) ([]string, []string, error) {
+	added := make([]string, 0, len(userIDs))
+	for _, uid := range userIDs {
+		if uid == "" {
+			continue
+		}
+		rel, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(uid, group.Uuid, 1)
+		if err == nil {
+			if isActiveMember(rel) {
+				continue
+			}
+			rel.Status = 0
+			rel.Role = contactEntity.GroupRoleMember
+			rel.MuteUntil = sql.NullTime{}
+			// 重新入群后只能看到（检索到）本次入群之后的消息
+			rel.JoinedAt = sql.NullTime{Time: now, Valid: true}
+			rel.UpdateAt = now
+			if err := contactRepo.UpdateUserContact(rel); err != nil {
+				return nil, nil, err
+			}
+			added = append(added, uid)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		newRel := &contactEntity.UserContact{
+			UserId:      uid,
+			ContactId:   group.Uuid,
+			ContactType: 1,
+			Status:      0,
+			JoinedAt:    sql.NullTime{Time: now, Valid: true},
+			CreatedAt:   now,
+			UpdateAt:    now,
+		}
+		if err := contactRepo.CreateUserContact(newRel); err != nil {
+			return nil, nil, err
+		}
+		added = append(added, uid)
+	}
+	if len(added) == 0 {
+		return added, nil, nil
+	}
+
+	members, err := syncGroupMembers(contactRepo, groupRepo, group, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return added, members, nil
+}
+
+// syncGroupMembers 按成员关系重算群的 Members/MemberCnt 并保存，返回当前全部成员
+func syncGroupMembers(contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository, group *contactEntity.GroupInfo, now time.Time) ([]string, error) {
+	allMembers, err := contactRepo.GetGroupMembers(group.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(allMembers))
+	for _, m := range allMembers {
+		members = append(members, m.UserId)
+	}
+	membersJSON, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	group.Members = membersJSON
+	group.MemberCnt = len(members)
+	group.UpdatedAt = now
+	if err := groupRepo.UpdateGroupInfo(group); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// upsertGroupApply 创建或重新激活一条待审核的入群申请，被拉黑的申请返回错误
+func upsertGroupApply(applyRepo contactRepository.ContactApplyRepository, userID string, groupID string, inviterID string, message string, now time.Time) (*contactEntity.ContactApply, error) {
+	if message == "" {
+		message = "申请加入群聊"
+		if inviterID != "" {
+			message = "群成员邀请入群"
+		}
+	}
+
+	apply, err := applyRepo.GetContactApplyByUserIDAndContactID(userID, groupID, 1)
+	if err == nil {
+		if apply.Status == 3 {
+			return nil, xerr.New(xerr.Forbidden, "已被禁止申请加入该群")
+		}
+		apply.Status = 0
+		apply.Message = message
+		apply.InviterId = inviterID
+		apply.LastApplyAt = now
+		if apply.Uuid == "" {
+			apply.Uuid = util.GenerateApplyID()
+		}
+		if err := applyRepo.UpdateContactApply(apply); err != nil {
+			return nil, err
+		}
+		return apply, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	apply = &contactEntity.ContactApply{
+		Uuid:        util.GenerateApplyID(),
+		UserId:      userID,
+		ContactId:   groupID,
+		ContactType: 1,
+		Status:      0,
+		Message:     message,
+		InviterId:   inviterID,
+		LastApplyAt: now,
+	}
+	if err := applyRepo.CreateContactApply(apply); err != nil {
+		return nil, err
+	}
+	return apply, nil
+}
+
+// lockActiveGroup 加锁读取群组并校验为正常状态
+func lockActiveGroup(groupRepo contactRepository.GroupInfoRepository, groupID string) (*contactEntity.GroupInfo, error) {
+	group, err := groupRepo.GetGroupInfoByUUIDForUpdate(groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "群组不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if group.Status != 0 {
+		return nil, xerr.New(xerr.Forbidden, "群组已解散或状态异常")
+	}
+	return group, nil
+}
+
+func (s *groupServiceImpl) ApplyJoinGroup(req contactRequest.ApplyJoinGroupRequest) (*contactRespond.ApplyJoinGroupRespond, error) {
+	req.GroupId = strings.TrimSpace(req.GroupId)
+	req.Message = strings.TrimSpace(req.Message)
+	if req.OwnerId == "" || req.GroupId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	var (
+		apply    *contactEntity.ContactApply
+		managers []string
+		members  []string
+	)
+	err := s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, err := lockActiveGroup(groupRepo, req.GroupId)
+		if err != nil {
+			return err
+		}
+
+		rel, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(req.OwnerId, req.GroupId, 1)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if err == nil && isActiveMember(rel) {
+			return xerr.New(xerr.BadRequest, "已在群聊中")
+		}
+
+		now := time.Now()
+		if group.AddMode == 0 {
+			_, members, err = addGroupMembers(contactRepo, groupRepo, group, []string{req.OwnerId}, now)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			return nil
+		}
+
+		apply, err = upsertGroupApply(applyRepo, req.OwnerId, req.GroupId, "", req.Message, now)
+		if err != nil {
+			var codeErr *xerr.CodeError
+			if errors.As(err, &codeErr) {
+				return err
+			}
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		managers, err = groupManagers(contactRepo, group)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if apply == nil {
+		s.enqueueGroupProfiles(req.GroupId, members)
+		return &contactRespond.ApplyJoinGroupRespond{Joined: true}, nil
+	}
+
+	s.notifyGroupApply(managers, apply)
+	return &contactRespond.ApplyJoinGroupRespond{ApplyId: apply.Uuid}, nil
+}
+
+func (s *groupServiceImpl) GetGroupApplyList(req contactRequest.GetGroupApplyListRequest) ([]contactRespond.GroupApplyItem, error) {
+	if req.OwnerId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	var applies []contactEntity.ContactApply
+	groupNames := make(map[string]string)
+	err := s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		var groups []contactEntity.GroupInfo
+		if req.GroupId != "" {
+			group, err := groupRepo.GetGroupInfoByUUID(req.GroupId)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return xerr.New(xerr.NotFound, "群组不存在")
+				}
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			ok, err := isGroupManager(contactRepo, group, req.OwnerId)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			if !ok {
+				return xerr.New(xerr.Forbidden, "无权查看该群的入群申请")
+			}
+			groups = append(groups, *group)
+		} else {
+			var err error
+			groups, err = groupRepo.ListManagedGroups(req.OwnerId)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+		}
+
+		groupIDs := make([]string, 0, len(groups))
+		for _, g := range groups {
+			groupIDs = append(groupIDs, g.Uuid)
+			groupNames[g.Uuid] = g.Name
+		}
+		var err error
+		applies, err = applyRepo.ListPendingAppliesByContactIDs(groupIDs, 1)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]contactRespond.GroupApplyItem, 0, len(applies))
+	if len(applies) == 0 {
+		return out, nil
+	}
+
+	userIDs := make([]string, 0, len(applies))
+	seen := make(map[string]struct{}, len(applies))
+	for _, a := range applies {
+		if _, ok := seen[a.UserId]; ok {
+			continue
+		}
+		seen[a.UserId] = struct{}{}
+		userIDs = append(userIDs, a.UserId)
+	}
+	briefs, err := s.userRepo.GetUserBriefByUUIDs(userIDs)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	briefMap := make(map[string]int, len(briefs))
+	for i := range briefs {
+		briefMap[briefs[i].Uuid] = i
+	}
+
+	for _, a := range applies {
+		item := contactRespond.GroupApplyItem{
+			ApplyId:     a.Uuid,
+			GroupId:     a.ContactId,
+			GroupName:   groupNames[a.ContactId],
+			UserId:      a.UserId,
+			InviterId:   a.InviterId,
+			Message:     a.Message,
+			LastApplyAt: a.LastApplyAt.Format("2006-01-02 15:04:05"),
+		}
+		if i, ok := briefMap[a.UserId]; ok {
+			item.Username = briefs[i].Username
+			item.Nickname = briefs[i].Nickname
+			item.Avatar = briefs[i].Avatar
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// groupApplyOutcome 审批完成后需要推送给申请人与邀请人的结果
+type groupApplyOutcome struct {
+	applyID   string
+	groupID   string
+	userID    string
+	inviterID string
+}
+
+// groupApplyFailure 批量审批中单条申请的失败原因
+type groupApplyFailure struct {
+	applyID string
+	err     error
+}
+
+func (s *groupServiceImpl) PassGroupApply(req contactRequest.PassGroupApplyRequest) error {
+	if req.OwnerId == "" || req.ApplyId == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	_, failed, err := s.passGroupApplies(req.OwnerId, []string{req.ApplyId})
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return failed[0].err
+	}
+	return nil
+}
+
+func (s *groupServiceImpl) BatchPassGroupApply(req contactRequest.BatchPassGroupApplyRequest) (*contactRespond.BatchPassGroupApplyRespond, error) {
+	applyIDs := make([]string, 0, len(req.ApplyIds))
+	seen := make(map[string]struct{}, len(req.ApplyIds))
+	for _, id := range req.ApplyIds {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		applyIDs = append(applyIDs, id)
+	}
+	if req.OwnerId == "" || len(applyIDs) == 0 {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if len(applyIDs) > maxBatchGroupApply {
+		return nil, xerr.New(xerr.BadRequest, "单次最多审批 100 条申请")
+	}
+
+	passed, failed, err := s.passGroupApplies(req.OwnerId, applyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &contactRespond.BatchPassGroupApplyRespond{
+		Passed: passed,
+		Failed: make([]contactRespond.GroupApplyFailure, 0, len(failed)),
+	}
+	for _, f := range failed {
+		msg := xerr.ErrServerError.Message
+		var codeErr *xerr.CodeError
+		if errors.As(f.err, &codeErr) {
+			msg = codeErr.Message
+		}
+		res.Failed = append(res.Failed, contactRespond.GroupApplyFailure{ApplyId: f.applyID, Message: msg})
+	}
+	return res, nil
+}
+
+// passGroupApplies 在同一事务内通过多条入群申请：先锁群再锁申请，与申请入群的加锁顺序一致；
+// 同一个群的申请人合并后一次性入群并重算成员列表。无权限或状态不符的申请记为失败，不影响其余申请
+func (s *groupServiceImpl) passGroupApplies(ownerID string, applyIDs []string) ([]string, []groupApplyFailure, error) {
+	var (
+		passed   []string
+		failed   []groupApplyFailure
+		outcomes []groupApplyOutcome
+	)
+	groupMembers := make(map[string][]string)
+
+	err := s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		groups := make(map[string]*contactEntity.GroupInfo)
+		groupErrs := make(map[string]error)
+		joining := make(map[string][]string)
+		var groupOrder []string
+
+		for _, id := range applyIDs {
+			fail := func(err error) {
+				failed = append(failed, groupApplyFailure{applyID: id, err: err})
+			}
+
+			peek, err := applyRepo.GetContactApplyByUUID(id)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fail(xerr.New(xerr.NotFound, "申请不存在"))
+					continue
+				}
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			if peek.ContactType != 1 {
+				fail(xerr.New(xerr.BadRequest, "不是入群申请"))
+				continue
+			}
+
+			group, ok := groups[peek.ContactId]
+			if !ok {
+				if gErr, seen := groupErrs[peek.ContactId]; seen {
+					fail(gErr)
+					continue
+				}
+				group, err = lockActiveGroup(groupRepo, peek.ContactId)
+				if err != nil {
+					if err == xerr.ErrServerError {
+						return err
+					}
+					groupErrs[peek.ContactId] = err
+					fail(err)
+					continue
+				}
+				groups[peek.ContactId] = group
+				groupOrder = append(groupOrder, group.Uuid)
+			}
+			ok, err = isGroupManager(contactRepo, group, ownerID)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			if !ok {
+				fail(xerr.New(xerr.Forbidden, "无权审批该申请"))
+				continue
+			}
+
+			apply, err := applyRepo.GetContactApplyByUUIDForUpdate(id)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			switch apply.Status {
+			case 1:
+				passed = append(passed, id)
+				continue
+			case 2:
+				// 已拒绝的申请需由申请人重新提交，不能直接通过
+				fail(xerr.New(xerr.BadRequest, "该申请已被拒绝"))
+				continue
+			case 3:
+				fail(xerr.New(xerr.Forbidden, "该申请已被拉黑"))
+				continue
+			case 0:
+			default:
+				fail(xerr.New(xerr.BadRequest, "申请状态异常"))
+				continue
+			}
+
+			apply.Status = 1
+			if err := applyRepo.UpdateContactApply(apply); err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			joining[group.Uuid] = append(joining[group.Uuid], apply.UserId)
+			passed = append(passed, id)
+			outcomes = append(outcomes, groupApplyOutcome{
+				applyID:   apply.Uuid,
+				groupID:   group.Uuid,
+				userID:    apply.UserId,
+				inviterID: apply.InviterId,
+			})
+		}
+
+		now := time.Now()
+		for _, gid := range groupOrder {
+			if len(joining[gid]) == 0 {
+				continue
+			}
+			_, members, err := addGroupMembers(contactRepo, groupRepo, groups[gid], joining[gid], now)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			groupMembers[gid] = members
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, o := range outcomes {
+		s.notifyGroupApplyOutcome(NotificationGroupApplyPassed, ownerID, o)
+	}
+	for gid, members := range groupMembers {
+		s.enqueueGroupProfiles(gid, members)
+	}
+	return passed, failed, nil
+}
+
+func (s *groupServiceImpl) RefuseGroupApply(req contactRequest.RefuseGroupApplyRequest) error {
+	if req.OwnerId == "" || req.ApplyId == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	var outcome *groupApplyOutcome
+	err := s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		apply, err := applyRepo.GetContactApplyByUUIDForUpdate(req.ApplyId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return xerr.New(xerr.NotFound, "申请不存在")
+			}
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if apply.ContactType != 1 {
+			return xerr.New(xerr.BadRequest, "不是入群申请")
+		}
+
+		group, err := groupRepo.GetGroupInfoByUUID(apply.ContactId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return xerr.New(xerr.NotFound, "群组不存在")
+			}
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		ok, err := isGroupManager(contactRepo, group, req.OwnerId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if !ok {
+			return xerr.New(xerr.Forbidden, "无权审批该申请")
+		}
+
+		switch apply.Status {
+		case 1:
+			return xerr.New(xerr.BadRequest, "已通过，无法拒绝")
+		case 2:
+			return nil
+		case 3:
+			return xerr.New(xerr.Forbidden, "该申请已被拉黑")
+		}
+
+		apply.Status = 2
+		if err := applyRepo.UpdateContactApply(apply); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		outcome = &groupApplyOutcome{
+			applyID:   apply.Uuid,
+			groupID:   apply.ContactId,
+			userID:    apply.UserId,
+			inviterID: apply.InviterId,
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if outcome != nil {
+		s.notifyGroupApplyOutcome(NotificationGroupApplyRefused, req.OwnerId, *outcome)
+	}
+	return nil
+}
+
+// notifyGroupApply 向群管理者推送新的入群申请
+func (s *groupServiceImpl) notifyGroupApply(managers []string, apply *contactEntity.ContactApply) {
+	if s.notifier == nil || apply == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"apply_id":     apply.Uuid,
+		"group_id":     apply.ContactId,
+		"from_user_id": apply.UserId,
+		"inviter_id":   apply.InviterId,
+		"created_at":   apply.LastApplyAt.Format(time.RFC3339),
+	}
+	for _, uid := range managers {
+		if uid == "" {
+			continue
+		}
+		_ = s.notifier.SendNotification(uid, NotificationGroupApply, data)
+	}
+}
+
+// notifyGroupApplyOutcome 向申请人及邀请人推送审批结果
+func (s *groupServiceImpl) notifyGroupApplyOutcome(kind string, operatorID string, o groupApplyOutcome) {
+	if s.notifier == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"apply_id":    o.applyID,
+		"group_id":    o.groupID,
+		"user_id":     o.userID,
+		"operator_id": operatorID,
+		"created_at":  time.Now().Format(time.RFC3339),
+	}
+	_ = s.notifier.SendNotification(o.userID, kind, data)
+	if o.inviterID != "" && o.inviterID != o.userID && o.inviterID != operatorID {
+		_ = s.notifier.SendNotification(o.inviterID, kind, data)
+	}
+}
+
+// enqueueGroupProfiles 成员变化后刷新每位成员的群画像
+func (s *groupServiceImpl) enqueueGroupProfiles(groupID string, members []string) {
+	if s.aiIngest == nil {
+		return
+	}
+	for _, uid := range members {
+		if strings.TrimSpace(uid) == "" {
+			continue
+		}
+		_ = s.aiIngest.EnqueueGroupProfile(context.Background(), uid, groupID)
+	}
+}
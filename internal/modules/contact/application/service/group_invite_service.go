@@ -0,0 +1,463 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"OmniLink/internal/config"
+	contactRequest "OmniLink/internal/modules/contact/application/dto/request"
+	contactRespond "OmniLink/internal/modules/contact/application/dto/respond"
+	contactEntity "OmniLink/internal/modules/contact/domain/entity"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	"OmniLink/pkg/util"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultGroupInviteTTL = 7 * 24 * time.Hour
+	maxGroupInviteTTL     = 30 * 24 * time.Hour
+	minGroupInviteTTL     = time.Minute
+	maxGroupInviteUses    = 1000
+	maxGroupInviteList    = 100
+
+	// groupInviteScheme 未配置邀请链接前缀时二维码使用的协议地址，由客户端识别后调用兑换接口
+	groupInviteScheme = "omnilink://group/invite"
+)
+
+// 邀请链接返回给客户端的状态，过期与用完由时间和次数推导，不落库
+const (
+	groupInviteValid     int8 = 0
+	groupInviteRevoked   int8 = 1
+	groupInviteExpired   int8 = 2
+	groupInviteExhausted int8 = 3
+)
+
+// groupInviteStatus 计算邀请链接当前状态
+func groupInviteStatus(invite *contactEntity.GroupInvite, now time.Time) int8 {
+	switch {
+	case invite.Status == 1:
+		return groupInviteRevoked
+	case !now.Before(invite.ExpireAt):
+		return groupInviteExpired
+	case invite.MaxUses > 0 && invite.UsedCount >= invite.MaxUses:
+		return groupInviteExhausted
+	}
+	return groupInviteValid
+}
+
+// checkGroupInviteUsable 兑换与预览前校验邀请链接仍可使用
+func checkGroupInviteUsable(invite *contactEntity.GroupInvite, now time.Time) error {
+	switch groupInviteStatus(invite, now) {
+	case groupInviteRevoked:
+		return xerr.New(xerr.Forbidden, "邀请链接已被撤销")
+	case groupInviteExpired:
+		return xerr.New(xerr.Forbidden, "邀请链接已过期")
+	case groupInviteExhausted:
+		return xerr.New(xerr.Forbidden, "邀请链接使用次数已达上限")
+	}
+	return nil
+}
+
+// checkGroupInviteCreator 创建人退群，或群改为仅管理者可邀请后创建人已不是管理者时，链接随之失效
+func checkGroupInviteCreator(contactRepo contactRepository.UserContactRepository, group *contactEntity.GroupInfo, creatorID string) error {
+	rel, err := loadMember(contactRepo, group.Uuid, creatorID)
+	if err != nil {
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	if rel == nil || (group.InviteMode == 1 && group.RoleOf(rel) == contactEntity.GroupRoleMember) {
+		return xerr.New(xerr.Forbidden, "邀请链接已失效")
+	}
+	return nil
+}
+
+// loadGroupInvite 读取邀请链接，forUpdate 为 true 时加行锁
+func loadGroupInvite(inviteRepo contactRepository.GroupInviteRepository, token string, forUpdate bool) (*contactEntity.GroupInvite, error) {
+	var (
+		invite *contactEntity.GroupInvite
+		err    error
+	)
+	if forUpdate {
+		invite, err = inviteRepo.GetInviteByTokenForUpdate(token)
+	} else {
+		invite, err = inviteRepo.GetInviteByToken(token)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "邀请链接不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	return invite, nil
+}
+
+// groupInviteLink 返回分享链接与二维码内容，未配置链接前缀时只有二维码内容
+func groupInviteLink(token string) (string, string) {
+	query := "token=" + url.QueryEscape(token)
+	base := strings.TrimSpace(config.GetConfig().ChatConfig.GroupInviteBaseUrl)
+	if base == "" {
+		return "", groupInviteScheme + "?" + query
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	link := base + sep + query
+	return link, link
+}
+
+func groupInviteRespond(invite *contactEntity.GroupInvite, creatorName string, now time.Time) contactRespond.GroupInviteRespond {
+	link, payload := groupInviteLink(invite.Token)
+	return contactRespond.GroupInviteRespond{
+		Token:       invite.Token,
+		GroupId:     invite.GroupId,
+		CreatorId:   invite.CreatorId,
+		CreatorName: creatorName,
+		ExpireAt:    invite.ExpireAt.Format(time.RFC3339),
+		MaxUses:     invite.MaxUses,
+		UsedCount:   invite.UsedCount,
+		Status:      groupInviteStatus(invite, now),
+		CreatedAt:   invite.CreatedAt.Format(time.RFC3339),
+		Link:        link,
+		QrPayload:   payload,
+	}
+}
+
+func (s *groupServiceImpl) CreateGroupInvite(req contactRequest.CreateGroupInviteRequest) (*contactRespond.GroupInviteRespond, error) {
+	if req.OwnerId == "" || req.GroupId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	ttl := defaultGroupInviteTTL
+	if req.ExpireSeconds != 0 {
+		ttl = time.Duration(req.ExpireSeconds) * time.Second
+		if ttl < minGroupInviteTTL || ttl > maxGroupInviteTTL {
+			return nil, xerr.New(xerr.BadRequest, "邀请链接有效期需在 1 分钟到 30 天之间")
+		}
+	}
+	if req.MaxUses < 0 || req.MaxUses > maxGroupInviteUses {
+		return nil, xerr.New(xerr.BadRequest, "邀请链接使用次数需在 0（不限）到 1000 之间")
+	}
+
+	var invite *contactEntity.GroupInvite
+	err := s.uow.InviteTransaction(func(inviteRepo contactRepository.GroupInviteRepository, _ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, _, role, err := groupOperator(contactRepo, groupRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			return err
+		}
+		if group.InviteMode == 1 && role == contactEntity.GroupRoleMember {
+			return xerr.New(xerr.Forbidden, "只有群主或管理员可以创建邀请链接")
+		}
+
+		now := time.Now()
+		invite = &contactEntity.GroupInvite{
+			Token:     util.GenerateInviteToken(),
+			GroupId:   group.Uuid,
+			CreatorId: req.OwnerId,
+			ExpireAt:  now.Add(ttl),
+			MaxUses:   req.MaxUses,
+			CreatedAt: now,
+		}
+		if err := inviteRepo.CreateInvite(invite); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := groupInviteRespond(invite, s.displayNames(req.OwnerId)[req.OwnerId], time.Now())
+	return &res, nil
+}
+
+func (s *groupServiceImpl) GetGroupInviteList(req contactRequest.GetGroupInviteListRequest) ([]contactRespond.GroupInviteRespond, error) {
+	if req.OwnerId == "" || req.GroupId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	var invites []contactEntity.GroupInvite
+	err := s.uow.InviteTransaction(func(inviteRepo contactRepository.GroupInviteRepository, _ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, err := groupRepo.GetGroupInfoByUUID(req.GroupId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return xerr.New(xerr.NotFound, "群组不存在")
+			}
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		rel, err := loadMember(contactRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if rel == nil {
+			return xerr.New(xerr.Forbidden, "非群成员")
+		}
+
+		// 群主与管理员可以查看全部链接，普通成员只能查看自己创建的
+		creatorID := req.OwnerId
+		if group.RoleOf(rel) != contactEntity.GroupRoleMember {
+			creatorID = ""
+		}
+		invites, err = inviteRepo.ListInvites(req.GroupId, creatorID, maxGroupInviteList)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]contactRespond.GroupInviteRespond, 0, len(invites))
+	if len(invites) == 0 {
+		return out, nil
+	}
+	creatorIDs := make([]string, 0, len(invites))
+	seen := make(map[string]struct{}, len(invites))
+	for _, inv := range invites {
+		if _, ok := seen[inv.CreatorId]; ok {
+			continue
+		}
+		seen[inv.CreatorId] = struct{}{}
+		creatorIDs = append(creatorIDs, inv.CreatorId)
+	}
+	names := s.displayNames(creatorIDs...)
+	now := time.Now()
+	for i := range invites {
+		out = append(out, groupInviteRespond(&invites[i], names[invites[i].CreatorId], now))
+	}
+	return out, nil
+}
+
+func (s *groupServiceImpl) RevokeGroupInvite(req contactRequest.GroupInviteTokenRequest) error {
+	req.Token = strings.TrimSpace(req.Token)
+	if req.OwnerId == "" || req.Token == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	return s.uow.InviteTransaction(func(inviteRepo contactRepository.GroupInviteRepository, _ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		invite, err := loadGroupInvite(inviteRepo, req.Token, true)
+		if err != nil {
+			return err
+		}
+		if invite.Status == 1 {
+			return nil
+		}
+		if invite.CreatorId != req.OwnerId {
+			group, err := groupRepo.GetGroupInfoByUUID(invite.GroupId)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return xerr.New(xerr.NotFound, "群组不存在")
+				}
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			ok, err := isGroupManager(contactRepo, group, req.OwnerId)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			if !ok {
+				return xerr.New(xerr.Forbidden, "只有创建人、群主或管理员可以撤销邀请链接")
+			}
+		}
+
+		invite.Status = 1
+		invite.RevokedBy = req.OwnerId
+		invite.RevokedAt.Time = time.Now()
+		invite.RevokedAt.Valid = true
+		if err := inviteRepo.UpdateInvite(invite); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+}
+
+func (s *groupServiceImpl) GetGroupInviteInfo(req contactRequest.GroupInviteTokenRequest) (*contactRespond.GroupInvitePreviewRespond, error) {
+	req.Token = strings.TrimSpace(req.Token)
+	if req.OwnerId == "" || req.Token == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	var (
+		invite *contactEntity.GroupInvite
+		group  *contactEntity.GroupInfo
+		joined bool
+	)
+	err := s.uow.InviteTransaction(func(inviteRepo contactRepository.GroupInviteRepository, _ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		var err error
+		invite, err = loadGroupInvite(inviteRepo, req.Token, false)
+		if err != nil {
+			return err
+		}
+		if err := checkGroupInviteUsable(invite, time.Now()); err != nil {
+			return err
+		}
+		group, err = groupRepo.GetGroupInfoByUUID(invite.GroupId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return xerr.New(xerr.NotFound, "群组不存在")
+			}
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if group.Status != 0 {
+			return xerr.New(xerr.Forbidden, "群组已解散或状态异常")
+		}
+		if err := checkGroupInviteCreator(contactRepo, group, invite.CreatorId); err != nil {
+			return err
+		}
+		rel, err := loadMember(contactRepo, group.Uuid, req.OwnerId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		joined = rel != nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &contactRespond.GroupInvitePreviewRespond{
+		GroupId:     group.Uuid,
+		GroupName:   group.Name,
+		Avatar:      group.Avatar,
+		MemberCnt:   group.MemberCnt,
+		AddMode:     group.AddMode,
+		CreatorId:   invite.CreatorId,
+		CreatorName: s.displayNames(invite.CreatorId)[invite.CreatorId],
+		ExpireAt:    invite.ExpireAt.Format(time.RFC3339),
+		Joined:      joined,
+	}, nil
+}
+
+// RedeemGroupInvite 兑换邀请链接：直接入群的群立即加入，需要审核的群以创建人为邀请人生成入群申请。
+// 加锁顺序为邀请链接、群组；已在群内、已有待审核申请或曾兑换过该链接时不计入使用次数
+func (s *groupServiceImpl) RedeemGroupInvite(req contactRequest.RedeemGroupInviteRequest) (*contactRespond.ApplyJoinGroupRespond, error) {
+	req.Token = strings.TrimSpace(req.Token)
+	req.Message = strings.TrimSpace(req.Message)
+	if req.OwnerId == "" || req.Token == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	var (
+		groupID  string
+		apply    *contactEntity.ContactApply
+		managers []string
+		members  []string
+	)
+	err := s.uow.InviteTransaction(func(inviteRepo contactRepository.GroupInviteRepository, applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		invite, err := loadGroupInvite(inviteRepo, req.Token, true)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := checkGroupInviteUsable(invite, now); err != nil {
+			return err
+		}
+		group, err := lockActiveGroup(groupRepo, invite.GroupId)
+		if err != nil {
+			return err
+		}
+		groupID = group.Uuid
+		if err := checkGroupInviteCreator(contactRepo, group, invite.CreatorId); err != nil {
+			return err
+		}
+		rel, err := loadMember(contactRepo, group.Uuid, req.OwnerId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if rel != nil {
+			return xerr.New(xerr.BadRequest, "已在群聊中")
+		}
+
+		// 同一用户重复兑换同一链接只计一次
+		used, err := inviteRepo.HasInviteUse(invite.Id, req.OwnerId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		use := &contactEntity.GroupInviteUse{
+			InviteId:  invite.Id,
+			GroupId:   group.Uuid,
+			UserId:    req.OwnerId,
+			CreatedAt: now,
+		}
+		if group.AddMode == 0 {
+			_, members, err = addGroupMembers(contactRepo, groupRepo, group, []string{req.OwnerId}, now)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			use.Joined = true
+		} else {
+			// 已有待审核的申请时直接返回，不重复计数也不再次通知群管理者
+			pending, err := applyRepo.GetContactApplyByUserIDAndContactID(req.OwnerId, group.Uuid, 1)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			if err == nil && pending.Status == 0 {
+				apply = pending
+				return nil
+			}
+
+			message := req.Message
+			if message == "" {
+				message = "通过邀请链接申请入群"
+			}
+			apply, err = upsertGroupApply(applyRepo, req.OwnerId, group.Uuid, invite.CreatorId, message, now)
+			if err != nil {
+				var codeErr *xerr.CodeError
+				if errors.As(err, &codeErr) {
+					return err
+				}
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			use.ApplyId = apply.Uuid
+			managers, err = groupManagers(contactRepo, group)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+		}
+
+		if used {
+			return nil
+		}
+		invite.UsedCount++
+		if err := inviteRepo.UpdateInvite(invite); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if err := inviteRepo.CreateInviteUse(use); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if apply == nil {
+		s.enqueueGroupProfiles(groupID, members)
+		return &contactRespond.ApplyJoinGroupRespond{Joined: true}, nil
+	}
+
+	s.notifyGroupApply(managers, apply)
+	return &contactRespond.ApplyJoinGroupRespond{ApplyId: apply.Uuid}, nil
+}
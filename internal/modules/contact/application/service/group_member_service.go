@@ -0,0 +1,457 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	contactRequest "OmniLink/internal/modules/contact/application/dto/request"
+	contactEntity "OmniLink/internal/modules/contact/domain/entity"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+)
+
+// NotificationGroupRemoved 被移出群聊，推送给被移除的成员
+const NotificationGroupRemoved = "group.removed"
+
+const (
+	maxGroupMuteDuration = 30 * 24 * time.Hour
+	maxRemoveMembers     = 100
+)
+
+// GroupSystemMessenger 向群会话写入系统消息并推送给当前成员，由聊天模块实现
+type GroupSystemMessenger interface {
+	SendGroupSystemMessage(operatorID string, groupID string, content string)
+}
+
+// groupOperator 加锁读取群组，返回操作人在群内的成员关系与角色，操作人不在群内时返回错误
+func groupOperator(contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository, groupID string, userID string) (*contactEntity.GroupInfo, *contactEntity.UserContact, int8, error) {
+	group, err := lockActiveGroup(groupRepo, groupID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	rel, err := loadMember(contactRepo, groupID, userID)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, nil, 0, xerr.ErrServerError
+	}
+	if rel == nil {
+		return nil, nil, 0, xerr.New(xerr.Forbidden, "非群成员")
+	}
+	return group, rel, group.RoleOf(rel), nil
+}
+
+// checkManageTarget 群主可以管理所有成员，管理员只能管理普通成员，群主不能被管理
+func checkManageTarget(group *contactEntity.GroupInfo, operatorRole int8, target *contactEntity.UserContact) error {
+	switch group.RoleOf(target) {
+	case contactEntity.GroupRoleOwner:
+		return xerr.New(xerr.Forbidden, "不能对群主执行该操作")
+	case contactEntity.GroupRoleAdmin:
+		if operatorRole != contactEntity.GroupRoleOwner {
+			return xerr.New(xerr.Forbidden, "管理员不能对其他管理员执行该操作")
+		}
+	}
+	return nil
+}
+
+// muteDuration 校验禁言时长，0 表示解除禁言
+func muteDuration(seconds int64) (time.Duration, error) {
+	if seconds < 0 || seconds > int64(maxGroupMuteDuration/time.Second) {
+		return 0, xerr.New(xerr.BadRequest, "禁言时长需在 0 到 30 天之间")
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// formatDuration 系统消息中展示的时长，如“1天2小时”“10分钟”
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d秒", int64(d/time.Second))
+	}
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	var b strings.Builder
+	if days > 0 {
+		fmt.Fprintf(&b, "%d天", days)
+	}
+	if hours > 0 {
+		fmt.Fprintf(&b, "%d小时", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(&b, "%d分钟", minutes)
+	}
+	return b.String()
+}
+
+// groupMemberIDs 群内当前成员 uuid
+func groupMemberIDs(contactRepo contactRepository.UserContactRepository, groupID string) ([]string, error) {
+	members, err := contactRepo.GetGroupMembers(groupID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserId)
+	}
+	return ids, nil
+}
+
+func (s *groupServiceImpl) TransferGroupOwner(req contactRequest.TransferGroupOwnerRequest) error {
+	if req.OwnerId == "" || req.GroupId == "" || req.NewOwnerId == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if req.NewOwnerId == req.OwnerId {
+		return xerr.New(xerr.BadRequest, "不能转让给自己")
+	}
+
+	var members []string
+	err := s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, operator, role, err := groupOperator(contactRepo, groupRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			return err
+		}
+		if role != contactEntity.GroupRoleOwner {
+			return xerr.New(xerr.Forbidden, "只有群主可以转让群主")
+		}
+		target, err := loadMember(contactRepo, req.GroupId, req.NewOwnerId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if target == nil {
+			return xerr.New(xerr.BadRequest, "对方不是群成员")
+		}
+
+		now := time.Now()
+		operator.Role = contactEntity.GroupRoleMember
+		operator.UpdateAt = now
+		target.Role = contactEntity.GroupRoleOwner
+		target.Status = 0
+		target.MuteUntil = sql.NullTime{}
+		target.UpdateAt = now
+		for _, rel := range []*contactEntity.UserContact{operator, target} {
+			if err := contactRepo.UpdateUserContact(rel); err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+		}
+
+		group.OwnerId = req.NewOwnerId
+		group.UpdatedAt = now
+		if err := groupRepo.UpdateGroupInfo(group); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		members, err = groupMemberIDs(contactRepo, req.GroupId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	names := s.displayNames(req.OwnerId, req.NewOwnerId)
+	s.systemMessage(req.OwnerId, req.GroupId, fmt.Sprintf("%s 将群主转让给了 %s", names[req.OwnerId], names[req.NewOwnerId]))
+	s.enqueueGroupProfiles(req.GroupId, members)
+	return nil
+}
+
+func (s *groupServiceImpl) SetGroupAdmin(req contactRequest.SetGroupAdminRequest) error {
+	if req.OwnerId == "" || req.GroupId == "" || req.UserId == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	changed := false
+	err := s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, _, role, err := groupOperator(contactRepo, groupRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			return err
+		}
+		if role != contactEntity.GroupRoleOwner {
+			return xerr.New(xerr.Forbidden, "只有群主可以设置管理员")
+		}
+		target, err := loadMember(contactRepo, req.GroupId, req.UserId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if target == nil {
+			return xerr.New(xerr.BadRequest, "对方不是群成员")
+		}
+		if group.RoleOf(target) == contactEntity.GroupRoleOwner {
+			return xerr.New(xerr.BadRequest, "不能修改群主的角色")
+		}
+
+		want := contactEntity.GroupRoleMember
+		if req.IsAdmin {
+			want = contactEntity.GroupRoleAdmin
+		}
+		if target.Role == want {
+			return nil
+		}
+		target.Role = want
+		target.UpdateAt = time.Now()
+		if err := contactRepo.UpdateUserContact(target); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		changed = true
+		return nil
+	})
+	if err != nil || !changed {
+		return err
+	}
+
+	names := s.displayNames(req.OwnerId, req.UserId)
+	content := fmt.Sprintf("%s 将 %s 设为管理员", names[req.OwnerId], names[req.UserId])
+	if !req.IsAdmin {
+		content = fmt.Sprintf("%s 取消了 %s 的管理员身份", names[req.OwnerId], names[req.UserId])
+	}
+	s.systemMessage(req.OwnerId, req.GroupId, content)
+	return nil
+}
+
+func (s *groupServiceImpl) RemoveGroupMembers(req contactRequest.RemoveGroupMembersRequest) error {
+	if req.OwnerId == "" || req.GroupId == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	targetIDs := make([]string, 0, len(req.MemberIds))
+	seen := make(map[string]struct{}, len(req.MemberIds))
+	for _, id := range req.MemberIds {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if id == req.OwnerId {
+			return xerr.New(xerr.BadRequest, "不能移除自己，请使用退出群聊")
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		targetIDs = append(targetIDs, id)
+	}
+	if len(targetIDs) == 0 {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if len(targetIDs) > maxRemoveMembers {
+		return xerr.New(xerr.BadRequest, "单次最多移除 100 名成员")
+	}
+
+	var removed, members []string
+	err := s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, _, role, err := groupOperator(contactRepo, groupRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			return err
+		}
+		if role == contactEntity.GroupRoleMember {
+			return xerr.New(xerr.Forbidden, "只有群主或管理员可以移除成员")
+		}
+
+		now := time.Now()
+		for _, uid := range targetIDs {
+			target, err := loadMember(contactRepo, req.GroupId, uid)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			if target == nil {
+				continue
+			}
+			if err := checkManageTarget(group, role, target); err != nil {
+				return err
+			}
+			target.Status = 7
+			target.Role = contactEntity.GroupRoleMember
+			target.MuteUntil = sql.NullTime{}
+			target.UpdateAt = now
+			if err := contactRepo.UpdateUserContact(target); err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+			removed = append(removed, uid)
+		}
+		if len(removed) == 0 {
+			return nil
+		}
+
+		members, err = syncGroupMembers(contactRepo, groupRepo, group, now)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil || len(removed) == 0 {
+		return err
+	}
+
+	names := s.displayNames(append([]string{req.OwnerId}, removed...)...)
+	removedNames := make([]string, 0, len(removed))
+	for _, uid := range removed {
+		removedNames = append(removedNames, names[uid])
+	}
+	s.systemMessage(req.OwnerId, req.GroupId, fmt.Sprintf("%s 将 %s 移出了群聊", names[req.OwnerId], strings.Join(removedNames, "、")))
+
+	if s.notifier != nil {
+		data := map[string]interface{}{
+			"group_id":    req.GroupId,
+			"operator_id": req.OwnerId,
+			"created_at":  time.Now().Format(time.RFC3339),
+		}
+		for _, uid := range removed {
+			_ = s.notifier.SendNotification(uid, NotificationGroupRemoved, data)
+		}
+	}
+	s.enqueueGroupProfiles(req.GroupId, removed)
+	s.enqueueGroupProfiles(req.GroupId, members)
+	return nil
+}
+
+func (s *groupServiceImpl) MuteGroupMember(req contactRequest.MuteGroupMemberRequest) error {
+	if req.OwnerId == "" || req.GroupId == "" || req.UserId == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	if req.UserId == req.OwnerId {
+		return xerr.New(xerr.BadRequest, "不能禁言自己")
+	}
+	dur, err := muteDuration(req.Duration)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	err = s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, _, role, err := groupOperator(contactRepo, groupRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			return err
+		}
+		if role == contactEntity.GroupRoleMember {
+			return xerr.New(xerr.Forbidden, "只有群主或管理员可以禁言成员")
+		}
+		target, err := loadMember(contactRepo, req.GroupId, req.UserId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if target == nil {
+			return xerr.New(xerr.BadRequest, "对方不是群成员")
+		}
+		if err := checkManageTarget(group, role, target); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if dur == 0 {
+			if target.Status != 5 {
+				return nil
+			}
+			target.Status = 0
+			target.MuteUntil = sql.NullTime{}
+		} else {
+			target.Status = 5
+			target.MuteUntil = sql.NullTime{Time: now.Add(dur), Valid: true}
+		}
+		target.UpdateAt = now
+		if err := contactRepo.UpdateUserContact(target); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		changed = true
+		return nil
+	})
+	if err != nil || !changed {
+		return err
+	}
+
+	names := s.displayNames(req.OwnerId, req.UserId)
+	content := fmt.Sprintf("%s 禁言了 %s %s", names[req.OwnerId], names[req.UserId], formatDuration(dur))
+	if dur == 0 {
+		content = fmt.Sprintf("%s 解除了 %s 的禁言", names[req.OwnerId], names[req.UserId])
+	}
+	s.systemMessage(req.OwnerId, req.GroupId, content)
+	return nil
+}
+
+func (s *groupServiceImpl) MuteGroup(req contactRequest.MuteGroupRequest) error {
+	if req.OwnerId == "" || req.GroupId == "" {
+		return xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	dur, err := muteDuration(req.Duration)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	err = s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, _, role, err := groupOperator(contactRepo, groupRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			return err
+		}
+		if role == contactEntity.GroupRoleMember {
+			return xerr.New(xerr.Forbidden, "只有群主或管理员可以设置全员禁言")
+		}
+
+		now := time.Now()
+		if dur == 0 {
+			if !group.IsMuted(now) {
+				return nil
+			}
+			group.MuteUntil = sql.NullTime{}
+		} else {
+			group.MuteUntil = sql.NullTime{Time: now.Add(dur), Valid: true}
+		}
+		group.UpdatedAt = now
+		if err := groupRepo.UpdateGroupInfo(group); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		changed = true
+		return nil
+	})
+	if err != nil || !changed {
+		return err
+	}
+
+	names := s.displayNames(req.OwnerId)
+	content := fmt.Sprintf("%s 开启了全员禁言，时长 %s", names[req.OwnerId], formatDuration(dur))
+	if dur == 0 {
+		content = fmt.Sprintf("%s 关闭了全员禁言", names[req.OwnerId])
+	}
+	s.systemMessage(req.OwnerId, req.GroupId, content)
+	return nil
+}
+
+// displayNames 系统消息中展示的用户名称：优先昵称，其次用户名，查不到时使用 uuid
+func (s *groupServiceImpl) displayNames(userIDs ...string) map[string]string {
+	names := make(map[string]string, len(userIDs))
+	for _, uid := range userIDs {
+		names[uid] = uid
+	}
+	briefs, err := s.userRepo.GetUserBriefByUUIDs(userIDs)
+	if err != nil {
+		zlog.Error(err.Error())
+		return names
+	}
+	for _, b := range briefs {
+		switch {
+		case b.Nickname != "":
+			names[b.Uuid] = b.Nickname
+		case b.Username != "":
+			names[b.Uuid] = b.Username
+		}
+	}
+	return names
+}
+
+func (s *groupServiceImpl) systemMessage(operatorID string, groupID string, content string) {
+	if s.messenger == nil {
+		return
+	}
+	s.messenger.SendGroupSystemMessage(operatorID, groupID, content)
+}
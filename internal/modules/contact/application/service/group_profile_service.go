@@ -0,0 +1,302 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	chatRepository "OmniLink/internal/modules/chat/domain/repository"
+	contactRequest "OmniLink/internal/modules/contact/application/dto/request"
+	contactRespond "OmniLink/internal/modules/contact/application/dto/respond"
+	contactEntity "OmniLink/internal/modules/contact/domain/entity"
+	contactRepository "OmniLink/internal/modules/contact/domain/repository"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxGroupNameLen   = 20
+	maxGroupNoticeLen = 500
+	maxGroupAvatarLen = 255
+)
+
+// groupProfileChange 一次修改中的单个字段变化
+type groupProfileChange struct {
+	field    string
+	oldValue string
+	newValue string
+}
+
+// diffGroupProfile 校验请求并计算有变化的字段，未传的字段不参与比较
+func diffGroupProfile(group *contactEntity.GroupInfo, req contactRequest.UpdateGroupInfoRequest) ([]groupProfileChange, error) {
+	var changes []groupProfileChange
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, xerr.New(xerr.BadRequest, "群名称不能为空")
+		}
+		if utf8.RuneCountInString(name) > maxGroupNameLen {
+			return nil, xerr.New(xerr.BadRequest, "群名称不能超过 20 个字符")
+		}
+		if name != group.Name {
+			changes = append(changes, groupProfileChange{field: contactEntity.GroupFieldName, oldValue: group.Name, newValue: name})
+		}
+	}
+	if req.Notice != nil {
+		notice := strings.TrimSpace(*req.Notice)
+		if utf8.RuneCountInString(notice) > maxGroupNoticeLen {
+			return nil, xerr.New(xerr.BadRequest, "群公告不能超过 500 个字符")
+		}
+		if notice != group.Notice {
+			changes = append(changes, groupProfileChange{field: contactEntity.GroupFieldNotice, oldValue: group.Notice, newValue: notice})
+		}
+	}
+	if req.Avatar != nil {
+		avatar := strings.TrimSpace(*req.Avatar)
+		if avatar == "" {
+			return nil, xerr.New(xerr.BadRequest, "群头像不能为空")
+		}
+		if len(avatar) > maxGroupAvatarLen {
+			return nil, xerr.New(xerr.BadRequest, "群头像地址过长")
+		}
+		if avatar != group.Avatar {
+			changes = append(changes, groupProfileChange{field: contactEntity.GroupFieldAvatar, oldValue: group.Avatar, newValue: avatar})
+		}
+	}
+	if req.AddMode != nil {
+		if *req.AddMode != 0 && *req.AddMode != 1 {
+			return nil, xerr.New(xerr.BadRequest, "加群方式只能为 0（直接加入）或 1（需要审核）")
+		}
+		if *req.AddMode != group.AddMode {
+			changes = append(changes, groupProfileChange{
+				field:    contactEntity.GroupFieldAddMode,
+				oldValue: strconv.Itoa(int(group.AddMode)),
+				newValue: strconv.Itoa(int(*req.AddMode)),
+			})
+		}
+	}
+	if req.InviteMode != nil {
+		if *req.InviteMode != 0 && *req.InviteMode != 1 {
+			return nil, xerr.New(xerr.BadRequest, "邀请链接权限只能为 0（所有成员）或 1（仅群主和管理员）")
+		}
+		if *req.InviteMode != group.InviteMode {
+			changes = append(changes, groupProfileChange{
+				field:    contactEntity.GroupFieldInviteMode,
+				oldValue: strconv.Itoa(int(group.InviteMode)),
+				newValue: strconv.Itoa(int(*req.InviteMode)),
+			})
+		}
+	}
+	return changes, nil
+}
+
+// applyGroupProfile 将变化写回群组实体
+func applyGroupProfile(group *contactEntity.GroupInfo, changes []groupProfileChange) {
+	for _, c := range changes {
+		switch c.field {
+		case contactEntity.GroupFieldName:
+			group.Name = c.newValue
+		case contactEntity.GroupFieldNotice:
+			group.Notice = c.newValue
+		case contactEntity.GroupFieldAvatar:
+			group.Avatar = c.newValue
+		case contactEntity.GroupFieldAddMode:
+			mode, _ := strconv.Atoi(c.newValue)
+			group.AddMode = int8(mode)
+		case contactEntity.GroupFieldInviteMode:
+			mode, _ := strconv.Atoi(c.newValue)
+			group.InviteMode = int8(mode)
+		}
+	}
+}
+
+// groupProfileMessage 群资料变化对应的系统消息
+func groupProfileMessage(operatorName string, c groupProfileChange) string {
+	switch c.field {
+	case contactEntity.GroupFieldName:
+		return fmt.Sprintf("%s 将群名称修改为“%s”", operatorName, c.newValue)
+	case contactEntity.GroupFieldNotice:
+		if c.newValue == "" {
+			return fmt.Sprintf("%s 清空了群公告", operatorName)
+		}
+		return fmt.Sprintf("%s 修改了群公告：%s", operatorName, c.newValue)
+	case contactEntity.GroupFieldAvatar:
+		return fmt.Sprintf("%s 修改了群头像", operatorName)
+	case contactEntity.GroupFieldAddMode:
+		if c.newValue == "1" {
+			return fmt.Sprintf("%s 将加群方式修改为“需要审核”", operatorName)
+		}
+		return fmt.Sprintf("%s 将加群方式修改为“直接加入”", operatorName)
+	case contactEntity.GroupFieldInviteMode:
+		if c.newValue == "1" {
+			return fmt.Sprintf("%s 将邀请链接权限修改为“仅群主和管理员”", operatorName)
+		}
+		return fmt.Sprintf("%s 将邀请链接权限修改为“所有成员”", operatorName)
+	}
+	return ""
+}
+
+func (s *groupServiceImpl) UpdateGroupInfo(req contactRequest.UpdateGroupInfoRequest) (*contactRespond.CreateGroupRespond, error) {
+	if req.OwnerId == "" || req.GroupId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+
+	var (
+		group   *contactEntity.GroupInfo
+		changes []groupProfileChange
+		members []string
+	)
+	err := s.uow.GroupTransactionWithSessions(func(contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository, sessionRepo chatRepository.SessionRepository) error {
+		var (
+			role int8
+			err  error
+		)
+		group, _, role, err = groupOperator(contactRepo, groupRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			return err
+		}
+		if role == contactEntity.GroupRoleMember {
+			return xerr.New(xerr.Forbidden, "只有群主或管理员可以修改群资料")
+		}
+		changes, err = diffGroupProfile(group, req)
+		if err != nil || len(changes) == 0 {
+			return err
+		}
+
+		now := time.Now()
+		profileSynced := false
+		records := make([]contactEntity.GroupProfileHistory, 0, len(changes))
+		for _, c := range changes {
+			records = append(records, contactEntity.GroupProfileHistory{
+				GroupId:    group.Uuid,
+				OperatorId: req.OwnerId,
+				Field:      c.field,
+				OldValue:   c.oldValue,
+				NewValue:   c.newValue,
+				CreatedAt:  now,
+			})
+			if c.field == contactEntity.GroupFieldName || c.field == contactEntity.GroupFieldAvatar {
+				profileSynced = true
+			}
+		}
+
+		applyGroupProfile(group, changes)
+		group.UpdatedAt = now
+		if err := groupRepo.UpdateGroupInfo(group); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if err := groupRepo.CreateProfileHistory(records); err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if profileSynced {
+			if err := sessionRepo.UpdateReceiverProfile(group.Uuid, group.Name, group.Avatar); err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
+			}
+		}
+		members, err = groupMemberIDs(contactRepo, group.Uuid)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 {
+		operatorName := s.displayNames(req.OwnerId)[req.OwnerId]
+		for _, c := range changes {
+			s.systemMessage(req.OwnerId, group.Uuid, groupProfileMessage(operatorName, c))
+		}
+		s.enqueueGroupProfiles(group.Uuid, members)
+	}
+	return groupInfoRespond(group), nil
+}
+
+func (s *groupServiceImpl) GetGroupInfoHistory(req contactRequest.GetGroupInfoHistoryRequest) (*contactRespond.GroupProfileHistoryRespond, error) {
+	if req.OwnerId == "" || req.GroupId == "" {
+		return nil, xerr.New(xerr.BadRequest, xerr.ErrParam.Message)
+	}
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	var (
+		records []contactEntity.GroupProfileHistory
+		total   int64
+	)
+	err := s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		if _, err := groupRepo.GetGroupInfoByUUID(req.GroupId); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return xerr.New(xerr.NotFound, "群组不存在")
+			}
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		rel, err := loadMember(contactRepo, req.GroupId, req.OwnerId)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		if rel == nil {
+			return xerr.New(xerr.Forbidden, "非群成员")
+		}
+		records, total, err = groupRepo.ListProfileHistory(req.GroupId, (page-1)*pageSize, pageSize)
+		if err != nil {
+			zlog.Error(err.Error())
+			return xerr.ErrServerError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	operatorIDs := make([]string, 0, len(records))
+	seen := make(map[string]struct{}, len(records))
+	for _, r := range records {
+		if _, ok := seen[r.OperatorId]; ok {
+			continue
+		}
+		seen[r.OperatorId] = struct{}{}
+		operatorIDs = append(operatorIDs, r.OperatorId)
+	}
+	names := map[string]string{}
+	if len(operatorIDs) > 0 {
+		names = s.displayNames(operatorIDs...)
+	}
+
+	items := make([]contactRespond.GroupProfileHistoryItem, 0, len(records))
+	for _, r := range records {
+		items = append(items, contactRespond.GroupProfileHistoryItem{
+			Field:        r.Field,
+			OldValue:     r.OldValue,
+			NewValue:     r.NewValue,
+			OperatorId:   r.OperatorId,
+			OperatorName: names[r.OperatorId],
+			CreatedAt:    r.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return &contactRespond.GroupProfileHistoryRespond{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Items:    items,
+	}, nil
+}
@@ -26,15 +26,50 @@ type GroupService interface {
 	CreateGroup(req contactRequest.CreateGroupRequest) (*contactRespond.CreateGroupRespond, error)
 	GetGroupInfo(req contactRequest.GetGroupInfoRequest) (*contactRespond.CreateGroupRespond, error)
 	GetGroupMemberList(req contactRequest.GetGroupMemberListRequest) ([]*contactRespond.GroupMemberRespond, error)
-	InviteGroupMembers(req contactRequest.InviteGroupMembersRequest) error
+	// InviteGroupMembers 邀请成员入群；审核模式的群由普通成员邀请时生成待审核申请
+	InviteGroupMembers(req contactRequest.InviteGroupMembersRequest) (*contactRespond.InviteGroupMembersRespond, error)
 	LeaveGroup(req contactRequest.LeaveGroupRequest) error
 	DismissGroup(req contactRequest.DismissGroupRequest) error
+	// ApplyJoinGroup 申请入群：直接入群模式立即加入，审核模式生成申请并通知群主与管理员
+	ApplyJoinGroup(req contactRequest.ApplyJoinGroupRequest) (*contactRespond.ApplyJoinGroupRespond, error)
+	// GetGroupApplyList 获取自己可审批的待处理入群申请
+	GetGroupApplyList(req contactRequest.GetGroupApplyListRequest) ([]contactRespond.GroupApplyItem, error)
+	PassGroupApply(req contactRequest.PassGroupApplyRequest) error
+	RefuseGroupApply(req contactRequest.RefuseGroupApplyRequest) error
+	// BatchPassGroupApply 批量通过入群申请，逐条返回失败原因
+	BatchPassGroupApply(req contactRequest.BatchPassGroupApplyRequest) (*contactRespond.BatchPassGroupApplyRespond, error)
+	// TransferGroupOwner 群主将群转让给其他成员，原群主成为普通成员
+	TransferGroupOwner(req contactRequest.TransferGroupOwnerRequest) error
+	// SetGroupAdmin 群主设置或取消管理员
+	SetGroupAdmin(req contactRequest.SetGroupAdminRequest) error
+	// RemoveGroupMembers 群主或管理员移除成员，管理员只能移除普通成员
+	RemoveGroupMembers(req contactRequest.RemoveGroupMembersRequest) error
+	// MuteGroupMember 群主或管理员按时长禁言成员，时长为 0 时解除禁言
+	MuteGroupMember(req contactRequest.MuteGroupMemberRequest) error
+	// MuteGroup 群主或管理员开启或关闭全员禁言
+	MuteGroup(req contactRequest.MuteGroupRequest) error
+	// UpdateGroupInfo 群主或管理员修改群名称、公告、头像、加群方式与邀请链接权限，记录修改历史并通知成员
+	UpdateGroupInfo(req contactRequest.UpdateGroupInfoRequest) (*contactRespond.CreateGroupRespond, error)
+	// GetGroupInfoHistory 群成员查看群资料修改记录
+	GetGroupInfoHistory(req contactRequest.GetGroupInfoHistoryRequest) (*contactRespond.GroupProfileHistoryRespond, error)
+	// CreateGroupInvite 按群的邀请链接权限创建带有效期与次数上限的邀请链接
+	CreateGroupInvite(req contactRequest.CreateGroupInviteRequest) (*contactRespond.GroupInviteRespond, error)
+	// GetGroupInviteList 群主与管理员查看全部邀请链接，普通成员查看自己创建的
+	GetGroupInviteList(req contactRequest.GetGroupInviteListRequest) ([]contactRespond.GroupInviteRespond, error)
+	// RevokeGroupInvite 创建人、群主或管理员撤销邀请链接
+	RevokeGroupInvite(req contactRequest.GroupInviteTokenRequest) error
+	// GetGroupInviteInfo 兑换前预览邀请链接对应的群
+	GetGroupInviteInfo(req contactRequest.GroupInviteTokenRequest) (*contactRespond.GroupInvitePreviewRespond, error)
+	// RedeemGroupInvite 兑换邀请链接，按加群方式直接入群或生成入群申请
+	RedeemGroupInvite(req contactRequest.RedeemGroupInviteRequest) (*contactRespond.ApplyJoinGroupRespond, error)
 }
 
 type groupServiceImpl struct {
-	userRepo userRepository.UserInfoRepository
-	uow      contactRepository.ContactUnitOfWork
-	aiIngest aiIngest.AsyncIngestService
+	userRepo  userRepository.UserInfoRepository
+	uow       contactRepository.ContactUnitOfWork
+	aiIngest  aiIngest.AsyncIngestService
+	notifier  GroupNotifier
+	messenger GroupSystemMessenger
 }
 
 func NewGroupService(
@@ -43,11 +78,15 @@ func NewGroupService(
 	userRepo userRepository.UserInfoRepository,
 	uow contactRepository.ContactUnitOfWork,
 	aiIngestSvc aiIngest.AsyncIngestService,
+	notifier GroupNotifier,
+	messenger GroupSystemMessenger,
 ) GroupService {
 	return &groupServiceImpl{
-		userRepo: userRepo,
-		uow:      uow,
-		aiIngest: aiIngestSvc,
+		userRepo:  userRepo,
+		uow:       uow,
+		aiIngest:  aiIngestSvc,
+		notifier:  notifier,
+		messenger: messenger,
 	}
 }
 
@@ -156,11 +195,16 @@ func (s *groupServiceImpl) CreateGroup(req contactRequest.CreateGroupRequest) (*
 				return xerr.ErrServerError
 			}
 
+			role := contactEntity.GroupRoleMember
+			if userID == req.OwnerId {
+				role = contactEntity.GroupRoleOwner
+			}
 			newRel := &contactEntity.UserContact{
 				UserId:      userID,
 				ContactId:   groupID,
 				ContactType: 1,
 				Status:      0,
+				Role:        role,
 				CreatedAt:   now,
 				UpdateAt:    now,
 			}
@@ -219,18 +263,29 @@ func (s *groupServiceImpl) GetGroupInfo(req contactRequest.GetGroupInfoRequest)
 		return nil, xerr.ErrServerError
 	}
 
-	return &contactRespond.CreateGroupRespond{
-		Uuid:      group.Uuid,
-		GroupId:   group.Uuid,
-		Name:      group.Name,
-		Notice:    group.Notice,
-		OwnerId:   group.OwnerId,
-		MemberCnt: group.MemberCnt,
-		Avatar:    group.Avatar,
-		Status:    group.Status,
-		CreatedAt: group.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: group.UpdatedAt.Format(time.RFC3339),
-	}, nil
+	return groupInfoRespond(group), nil
+}
+
+// groupInfoRespond 群资料返回值，全员禁言生效时带上截止时间
+func groupInfoRespond(group *contactEntity.GroupInfo) *contactRespond.CreateGroupRespond {
+	res := &contactRespond.CreateGroupRespond{
+		Uuid:       group.Uuid,
+		GroupId:    group.Uuid,
+		Name:       group.Name,
+		Notice:     group.Notice,
+		OwnerId:    group.OwnerId,
+		MemberCnt:  group.MemberCnt,
+		Avatar:     group.Avatar,
+		Status:     group.Status,
+		AddMode:    group.AddMode,
+		InviteMode: group.InviteMode,
+		CreatedAt:  group.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  group.UpdatedAt.Format(time.RFC3339),
+	}
+	if group.IsMuted(time.Now()) {
+		res.MuteUntil = group.MuteUntil.Time.Format(time.RFC3339)
+	}
+	return res
 }
 
 func (s *groupServiceImpl) GetGroupMemberList(req contactRequest.GetGroupMemberListRequest) ([]*contactRespond.GroupMemberRespond, error) {
@@ -271,6 +326,7 @@ func (s *groupServiceImpl) GetGroupMemberList(req contactRequest.GetGroupMemberL
 		infoMap[userInfos[i].Uuid] = &userInfos[i]
 	}
 
+	now := time.Now()
 	res := make([]*contactRespond.GroupMemberRespond, 0, len(memberRels))
 	for _, rel := range memberRels {
 		info, ok := infoMap[rel.UserId]
@@ -278,31 +334,38 @@ func (s *groupServiceImpl) GetGroupMemberList(req contactRequest.GetGroupMemberL
 			continue
 		}
 
-		role := int8(0)
-		if rel.UserId == group.OwnerId {
-			role = 1
-		}
-
-		res = append(res, &contactRespond.GroupMemberRespond{
+		item := &contactRespond.GroupMemberRespond{
 			UserId:   info.Uuid,
 			Username: info.Username,
 			Nickname: info.Nickname,
 			Avatar:   info.Avatar,
 			Gender:   info.Gender,
-			Role:     role,
-		})
+			Role:     group.RoleOf(&rel),
+		}
+		if rel.IsMuted(now) {
+			item.Muted = true
+			if rel.MuteUntil.Valid {
+				item.MuteUntil = rel.MuteUntil.Time.Format(time.RFC3339)
+			}
+		}
+		res = append(res, item)
 	}
 	return res, nil
 }
 
-func (s *groupServiceImpl) InviteGroupMembers(req contactRequest.InviteGroupMembersRequest) error {
+func (s *groupServiceImpl) InviteGroupMembers(req contactRequest.InviteGroupMembersRequest) (*contactRespond.InviteGroupMembersRespond, error) {
+	res := &contactRespond.InviteGroupMembersRespond{Joined: []string{}, Pending: []string{}}
 	if len(req.MemberIds) == 0 {
-		return nil
+		return res, nil
 	}
 
-	var updatedMembers []string
-	err := s.uow.Transaction(func(_ contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
-		group, err := groupRepo.GetGroupInfoByUUID(req.GroupId)
+	var (
+		updatedMembers []string
+		applies        []*contactEntity.ContactApply
+		managers       []string
+	)
+	err := s.uow.Transaction(func(applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error {
+		group, err := groupRepo.GetGroupInfoByUUIDForUpdate(req.GroupId)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return xerr.New(xerr.NotFound, "群组不存在")
@@ -314,87 +377,67 @@ func (s *groupServiceImpl) InviteGroupMembers(req contactRequest.InviteGroupMemb
 			return xerr.New(xerr.Forbidden, "群组已解散或状态异常，无法邀请成员")
 		}
 
-		now := time.Now()
-		addedCount := 0
-
-		upsertMemberRel := func(userID string) error {
-			rel, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(userID, req.GroupId, 1)
-			if err == nil {
-				if rel.Status == 0 || rel.Status == 5 {
-					return nil
-				}
-				rel.ContactType = 1
-				rel.Status = 0
-				rel.UpdateAt = now
-				if err := contactRepo.UpdateUserContact(rel); err != nil {
-					return err
-				}
-				addedCount++
-				return nil
-			}
-
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				return err
-			}
+		inviter, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(req.OwnerId, req.GroupId, 1)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err != nil || !isActiveMember(inviter) {
+			return xerr.New(xerr.Forbidden, "非群成员无法邀请")
+		}
 
-			newRel := &contactEntity.UserContact{
-				UserId:      userID,
-				ContactId:   req.GroupId,
-				ContactType: 1,
-				Status:      0,
-				CreatedAt:   now,
-				UpdateAt:    now,
-			}
-			if err := contactRepo.CreateUserContact(newRel); err != nil {
-				return err
+		now := time.Now()
+		manager, err := isGroupManager(contactRepo, group, req.OwnerId)
+		if err != nil {
+			return err
+		}
+		if group.AddMode == 0 || manager {
+			added, members, err := addGroupMembers(contactRepo, groupRepo, group, req.MemberIds, now)
+			if err != nil {
+				zlog.Error(err.Error())
+				return xerr.ErrServerError
 			}
-			addedCount++
+			res.Joined = append(res.Joined, added...)
+			updatedMembers = members
 			return nil
 		}
 
+		// 审核模式下普通成员的邀请需群主或管理员审核，已在群内的成员跳过
 		for _, uid := range req.MemberIds {
-			if uid == "" {
+			if uid == "" || uid == req.OwnerId {
 				continue
 			}
-			if err := upsertMemberRel(uid); err != nil {
+			rel, err := contactRepo.GetUserContactByUserIDAndContactIDAndType(uid, req.GroupId, 1)
+			if err == nil && isActiveMember(rel) {
+				continue
+			}
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 				zlog.Error(err.Error())
 				return xerr.ErrServerError
 			}
-		}
-
-		if addedCount > 0 {
-			allMembers, err := contactRepo.GetGroupMembers(req.GroupId)
-			if err == nil {
-				var allIDs []string
-				for _, m := range allMembers {
-					allIDs = append(allIDs, m.UserId)
+			apply, err := upsertGroupApply(applyRepo, uid, req.GroupId, req.OwnerId, "", now)
+			if err != nil {
+				var codeErr *xerr.CodeError
+				if errors.As(err, &codeErr) {
+					continue
 				}
-				updatedMembers = append([]string(nil), allIDs...)
-				membersJSON, _ := json.Marshal(allIDs)
-				group.Members = membersJSON
-				group.MemberCnt = len(allIDs)
-			}
-
-			group.UpdatedAt = now
-			if err := groupRepo.UpdateGroupInfo(group); err != nil {
 				zlog.Error(err.Error())
 				return xerr.ErrServerError
 			}
+			applies = append(applies, apply)
+			res.Pending = append(res.Pending, uid)
 		}
-
-		return nil
+		managers, err = groupManagers(contactRepo, group)
+		return err
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if s.aiIngest != nil {
-		for _, uid := range updatedMembers {
-			_ = s.aiIngest.EnqueueGroupProfile(context.Background(), uid, req.GroupId)
-		}
+	s.enqueueGroupProfiles(req.GroupId, updatedMembers)
+	for _, apply := range applies {
+		s.notifyGroupApply(managers, apply)
 	}
-
-	return nil
+	return res, nil
 }
 
 func (s *groupServiceImpl) LeaveGroup(req contactRequest.LeaveGroupRequest) error {
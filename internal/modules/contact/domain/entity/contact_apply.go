@@ -14,6 +14,7 @@ type ContactApply struct {
 	ContactType int8           `gorm:"column:contact_type;not null;comment:被申请类型，0.用户，1.群聊"`
 	Status      int8           `gorm:"column:status;not null;comment:申请状态，0.申请中，1.通过，2.拒绝，3.拉黑"`
 	Message     string         `gorm:"column:message;type:varchar(100);comment:申请信息"`
+	InviterId   string         `gorm:"column:inviter_id;type:char(20);comment:邀请人id，群成员邀请入群待审核时记录"`
 	LastApplyAt time.Time      `gorm:"column:last_apply_at;type:datetime;not null;comment:最后申请时间"`
 	DeletedAt   gorm.DeletedAt `gorm:"column:deleted_at;index;type:datetime;comment:删除时间"`
 }
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// GroupInvite 群邀请链接，Token 用于分享链接与二维码；MaxUses 为 0 表示不限次数
+type GroupInvite struct {
+	Id        int64        `gorm:"column:id;primaryKey;comment:自增id"`
+	Token     string       `gorm:"column:token;uniqueIndex;type:varchar(32);not null;comment:邀请令牌"`
+	GroupId   string       `gorm:"column:group_id;index:idx_group_created,priority:1;type:char(20);not null;comment:群组uuid"`
+	CreatorId string       `gorm:"column:creator_id;index;type:char(20);not null;comment:创建人uuid"`
+	ExpireAt  time.Time    `gorm:"column:expire_at;type:datetime;not null;comment:过期时间"`
+	MaxUses   int          `gorm:"column:max_uses;not null;default:0;comment:最大使用次数，0.不限"`
+	UsedCount int          `gorm:"column:used_count;not null;default:0;comment:已使用次数"`
+	Status    int8         `gorm:"column:status;not null;default:0;comment:状态，0.有效，1.已撤销"`
+	RevokedBy string       `gorm:"column:revoked_by;type:char(20);comment:撤销人uuid"`
+	RevokedAt sql.NullTime `gorm:"column:revoked_at;type:datetime;comment:撤销时间"`
+	CreatedAt time.Time    `gorm:"column:created_at;index:idx_group_created,priority:2;type:datetime;not null;comment:创建时间"`
+}
+
+func (GroupInvite) TableName() string {
+	return "group_invite"
+}
+
+// GroupInviteUse 邀请链接使用记录，同一用户对同一链接只记录首次兑换
+type GroupInviteUse struct {
+	Id        int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	InviteId  int64     `gorm:"column:invite_id;uniqueIndex:uk_invite_user,priority:1;not null;comment:邀请链接id"`
+	GroupId   string    `gorm:"column:group_id;index;type:char(20);not null;comment:群组uuid"`
+	UserId    string    `gorm:"column:user_id;uniqueIndex:uk_invite_user,priority:2;index;type:char(20);not null;comment:使用人uuid"`
+	Joined    bool      `gorm:"column:joined;not null;default:false;comment:是否直接入群，否则生成了入群申请"`
+	ApplyId   string    `gorm:"column:apply_id;type:char(20);comment:生成的入群申请id"`
+	CreatedAt time.Time `gorm:"column:created_at;type:datetime;not null;comment:使用时间"`
+}
+
+func (GroupInviteUse) TableName() string {
+	return "group_invite_use"
+}
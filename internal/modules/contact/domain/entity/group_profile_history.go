@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// 群资料可修改的字段，对应 GroupProfileHistory.Field
+const (
+	GroupFieldName    = "name"
+	GroupFieldNotice  = "notice"
+	GroupFieldAvatar  = "avatar"
+	GroupFieldAddMode = "add_mode"
+	// GroupFieldInviteMode 邀请链接创建权限
+	GroupFieldInviteMode = "invite_mode"
+)
+
+// GroupProfileHistory 群资料修改记录，每个被修改的字段一条，只增不改
+type GroupProfileHistory struct {
+	Id         int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	GroupId    string    `gorm:"column:group_id;index:idx_group_created,priority:1;type:char(20);not null;comment:群组uuid"`
+	OperatorId string    `gorm:"column:operator_id;type:char(20);not null;comment:操作人uuid"`
+	Field      string    `gorm:"column:field;type:varchar(16);not null;comment:修改的字段，name/notice/avatar/add_mode/invite_mode"`
+	OldValue   string    `gorm:"column:old_value;type:varchar(500);comment:修改前的值"`
+	NewValue   string    `gorm:"column:new_value;type:varchar(500);comment:修改后的值"`
+	CreatedAt  time.Time `gorm:"column:created_at;index:idx_group_created,priority:2;type:datetime;not null;comment:修改时间"`
+}
+
+func (GroupProfileHistory) TableName() string {
+	return "group_profile_history"
+}
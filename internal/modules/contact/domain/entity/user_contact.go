@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"database/sql"
 	"time"
 
 	"gorm.io/gorm"
@@ -12,6 +13,9 @@ type UserContact struct {
 	ContactId   string         `gorm:"column:contact_id;index;type:char(20);not null;comment:对应联系id"`
 	ContactType int8           `gorm:"column:contact_type;not null;comment:联系类型，0.用户，1.群聊"`
 	Status      int8           `gorm:"column:status;not null;comment:联系状态，0.正常，1.拉黑，2.被拉黑，3.删除好友，4.被删除好友，5.被禁言，6.退出群聊，7.被踢出群聊，8.群聊已解散"`
+	Role        int8           `gorm:"column:role;not null;default:0;comment:群成员角色，0.普通成员，1.群主，2.管理员，仅群聊"`
+	MuteUntil   sql.NullTime   `gorm:"column:mute_until;type:datetime;comment:禁言截止时间，仅 status=5，为空表示永久"`
+	JoinedAt    sql.NullTime   `gorm:"column:joined_at;type:datetime;comment:最近一次入群时间，仅群聊，为空时以创建时间为准"`
 	CreatedAt   time.Time      `gorm:"column:created_at;type:datetime;not null;comment:创建时间"`
 	UpdateAt    time.Time      `gorm:"column:update_at;type:datetime;not null;comment:更新时间"`
 	DeletedAt   gorm.DeletedAt `gorm:"column:deleted_at;type:datetime;index;comment:删除时间"`
@@ -20,3 +24,23 @@ type UserContact struct {
 func (UserContact) TableName() string {
 	return "user_contact"
 }
+
+// 群成员角色，与群成员列表返回的 role 一致
+const (
+	GroupRoleMember int8 = 0
+	GroupRoleOwner  int8 = 1
+	GroupRoleAdmin  int8 = 2
+)
+
+// JoinTime 最近一次入群时间；退群后重新入群会刷新，旧数据没有该字段时取关系创建时间
+func (c *UserContact) JoinTime() time.Time {
+	if c.JoinedAt.Valid {
+		return c.JoinedAt.Time
+	}
+	return c.CreatedAt
+}
+
+// IsMuted 成员禁言是否仍在生效，截止时间已过视为未禁言
+func (c *UserContact) IsMuted(now time.Time) bool {
+	return c.Status == 5 && (!c.MuteUntil.Valid || c.MuteUntil.Time.After(now))
+}
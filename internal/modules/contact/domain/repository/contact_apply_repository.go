@@ -7,6 +7,8 @@ type ContactApplyRepository interface {
 	GetContactApplyByUUID(uuid string) (*entity.ContactApply, error)
 	GetContactApplyByUUIDForUpdate(uuid string) (*entity.ContactApply, error)
 	ListPendingAppliesByContactID(contactID string) ([]entity.ContactApply, error)
+	// ListPendingAppliesByContactIDs 批量查询多个被申请方的待处理申请，按最后申请时间倒序
+	ListPendingAppliesByContactIDs(contactIDs []string, contactType int8) ([]entity.ContactApply, error)
 	CreateContactApply(apply *entity.ContactApply) error
 	UpdateContactApply(apply *entity.ContactApply) error
 }
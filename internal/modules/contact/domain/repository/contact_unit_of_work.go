@@ -1,5 +1,13 @@
 package repository
 
+import chatRepository "OmniLink/internal/modules/chat/domain/repository"
+
 type ContactUnitOfWork interface {
 	Transaction(fn func(applyRepo ContactApplyRepository, contactRepo UserContactRepository, groupRepo GroupInfoRepository) error) error
+	// TransactionWithSessions 在同一事务内修改联系人关系与会话，保证删除好友时双方关系与会话一致
+	TransactionWithSessions(fn func(contactRepo UserContactRepository, sessionRepo chatRepository.SessionRepository) error) error
+	// GroupTransactionWithSessions 在同一事务内修改群资料并同步群会话的名称与头像
+	GroupTransactionWithSessions(fn func(contactRepo UserContactRepository, groupRepo GroupInfoRepository, sessionRepo chatRepository.SessionRepository) error) error
+	// InviteTransaction 在同一事务内兑换群邀请链接：扣减次数、入群或生成入群申请
+	InviteTransaction(fn func(inviteRepo GroupInviteRepository, applyRepo ContactApplyRepository, contactRepo UserContactRepository, groupRepo GroupInfoRepository) error) error
 }
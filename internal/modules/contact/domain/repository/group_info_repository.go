@@ -2,14 +2,35 @@ package repository
 
 import "OmniLink/internal/modules/contact/domain/entity"
 
+// GroupQuery 管理端群组列表查询条件，指针字段为 nil 时不过滤
+type GroupQuery struct {
+	Keyword string // 按群名模糊匹配，或精确匹配群 uuid
+	OwnerId string
+	Status  *int8
+	Offset  int
+	Limit   int
+}
+
 type GroupInfoRepository interface {
 	CreateGroupInfo(group *entity.GroupInfo) error
 	UpdateGroupInfo(group *entity.GroupInfo) error
 	GetGroupInfoByUUID(uuid string) (*entity.GroupInfo, error)
+	// GetGroupInfoByUUIDForUpdate 加行锁读取群组，用于在事务内串行化成员变更
+	GetGroupInfoByUUIDForUpdate(uuid string) (*entity.GroupInfo, error)
 	ListByOwnerID(ownerID string) ([]entity.GroupInfo, error)
 	ListJoinedGroups(userID string) ([]entity.GroupInfo, error)
+	// ListManagedGroups 查询用户作为群主或管理员的正常群组
+	ListManagedGroups(userID string) ([]entity.GroupInfo, error)
 	// SearchGroupsByName 根据群名模糊搜索群组
 	SearchGroupsByName(keyword string, limit int) ([]entity.GroupInfo, error)
 	// FindGroupByExactName 根据精确群名查找群组
 	FindGroupByExactName(name string) (*entity.GroupInfo, error)
+	// ListGroups 按创建时间倒序分页查询群组，返回当前页与总数
+	ListGroups(q GroupQuery) ([]entity.GroupInfo, int64, error)
+	// UpdateStatusByUUIDs 批量启用/禁用群组，已解散的群不受影响，返回受影响行数
+	UpdateStatusByUUIDs(uuids []string, status int8) (int64, error)
+	// CreateProfileHistory 批量写入群资料修改记录
+	CreateProfileHistory(records []entity.GroupProfileHistory) error
+	// ListProfileHistory 按修改时间倒序分页查询群资料修改记录，返回当前页与总数
+	ListProfileHistory(groupID string, offset int, limit int) ([]entity.GroupProfileHistory, int64, error)
 }
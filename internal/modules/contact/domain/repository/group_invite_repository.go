@@ -0,0 +1,15 @@
+package repository
+
+import "OmniLink/internal/modules/contact/domain/entity"
+
+type GroupInviteRepository interface {
+	CreateInvite(invite *entity.GroupInvite) error
+	GetInviteByToken(token string) (*entity.GroupInvite, error)
+	// GetInviteByTokenForUpdate 加行锁读取邀请链接，兑换时串行化使用次数
+	GetInviteByTokenForUpdate(token string) (*entity.GroupInvite, error)
+	UpdateInvite(invite *entity.GroupInvite) error
+	// ListInvites 按创建时间倒序查询群的邀请链接，creatorID 为空时不按创建人过滤
+	ListInvites(groupID string, creatorID string, limit int) ([]entity.GroupInvite, error)
+	CreateInviteUse(use *entity.GroupInviteUse) error
+	HasInviteUse(inviteID int64, userID string) (bool, error)
+}
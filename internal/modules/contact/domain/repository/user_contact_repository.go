@@ -13,6 +13,8 @@ type UserContactRepository interface {
 	ListContactsWithInfo(userID string) ([]entity.ContactWithUserInfo, error)
 	GetGroupMembers(groupID string) ([]entity.UserContact, error)
 	GetGroupMembersWithInfo(groupID string) ([]entity.ContactWithUserInfo, error)
+	// ListGroupUserIDs 返回与群有过成员关系的全部用户（含已退群、被踢出），用于清理其 AI 知识库
+	ListGroupUserIDs(groupID string) ([]string, error)
 	CreateUserContact(contact *entity.UserContact) error
 	UpdateUserContact(contact *entity.UserContact) error
 	UpdateGroupContactsStatus(groupID string, status int8, updateAt time.Time) error
@@ -61,6 +61,21 @@ func (r *contactApplyRepositoryImpl) ListPendingAppliesByContactID(contactID str
 	return applies, nil
 }
 
+func (r *contactApplyRepositoryImpl) ListPendingAppliesByContactIDs(contactIDs []string, contactType int8) ([]entity.ContactApply, error) {
+	var applies []entity.ContactApply
+	if len(contactIDs) == 0 {
+		return applies, nil
+	}
+	err := r.db.
+		Where("contact_id IN ? AND contact_type = ? AND status = 0", contactIDs, contactType).
+		Order("last_apply_at DESC").
+		Find(&applies).Error
+	if err != nil {
+		return nil, err
+	}
+	return applies, nil
+}
+
 func (r *contactApplyRepositoryImpl) CreateContactApply(apply *entity.ContactApply) error {
 	return r.db.Create(apply).Error
 }
@@ -72,6 +87,7 @@ func (r *contactApplyRepositoryImpl) UpdateContactApply(apply *entity.ContactApp
 			"uuid":          apply.Uuid,
 			"status":        apply.Status,
 			"message":       apply.Message,
+			"inviter_id":    apply.InviterId,
 			"last_apply_at": apply.LastApplyAt,
 		}).Error
 }
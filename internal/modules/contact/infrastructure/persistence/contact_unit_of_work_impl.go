@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	chatRepository "OmniLink/internal/modules/chat/domain/repository"
+	chatPersistence "OmniLink/internal/modules/chat/infrastructure/persistence"
 	contactRepository "OmniLink/internal/modules/contact/domain/repository"
 
 	"gorm.io/gorm"
@@ -22,3 +24,21 @@ func (u *contactUnitOfWorkImpl) Transaction(fn func(applyRepo contactRepository.
 		return fn(applyRepo, contactRepo, groupRepo)
 	})
 }
+
+func (u *contactUnitOfWorkImpl) TransactionWithSessions(fn func(contactRepo contactRepository.UserContactRepository, sessionRepo chatRepository.SessionRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewUserContactRepository(tx), chatPersistence.NewSessionRepository(tx))
+	})
+}
+
+func (u *contactUnitOfWorkImpl) GroupTransactionWithSessions(fn func(contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository, sessionRepo chatRepository.SessionRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewUserContactRepository(tx), NewGroupInfoRepository(tx), chatPersistence.NewSessionRepository(tx))
+	})
+}
+
+func (u *contactUnitOfWorkImpl) InviteTransaction(fn func(inviteRepo contactRepository.GroupInviteRepository, applyRepo contactRepository.ContactApplyRepository, contactRepo contactRepository.UserContactRepository, groupRepo contactRepository.GroupInfoRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGroupInviteRepository(tx), NewContactApplyRepository(tx), NewUserContactRepository(tx), NewGroupInfoRepository(tx))
+	})
+}
@@ -1,10 +1,13 @@
 package persistence
 
 import (
+	"time"
+
 	"OmniLink/internal/modules/contact/domain/entity"
 	"OmniLink/internal/modules/contact/domain/repository"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type groupInfoRepositoryImpl struct {
@@ -32,6 +35,18 @@ func (r *groupInfoRepositoryImpl) GetGroupInfoByUUID(uuid string) (*entity.Group
 	return &g, nil
 }
 
+func (r *groupInfoRepositoryImpl) GetGroupInfoByUUIDForUpdate(uuid string) (*entity.GroupInfo, error) {
+	var g entity.GroupInfo
+	err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uuid = ?", uuid).
+		First(&g).Error
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
 func (r *groupInfoRepositoryImpl) ListByOwnerID(ownerID string) ([]entity.GroupInfo, error) {
 	var groups []entity.GroupInfo
 	err := r.db.Where("owner_id = ? AND status = 0", ownerID).Find(&groups).Error
@@ -55,6 +70,19 @@ func (r *groupInfoRepositoryImpl) ListJoinedGroups(userID string) ([]entity.Grou
 	return groups, nil
 }
 
+func (r *groupInfoRepositoryImpl) ListManagedGroups(userID string) ([]entity.GroupInfo, error) {
+	var groups []entity.GroupInfo
+	err := r.db.Table("group_info").
+		Select("group_info.*").
+		Joins("LEFT JOIN user_contact ON group_info.uuid = user_contact.contact_id AND user_contact.user_id = ? AND user_contact.contact_type = 1 AND user_contact.status IN ? AND user_contact.deleted_at IS NULL", userID, []int8{0, 5}).
+		Where("group_info.status = 0 AND group_info.deleted_at IS NULL AND (group_info.owner_id = ? OR user_contact.role = ?)", userID, entity.GroupRoleAdmin).
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
 // SearchGroupsByName 根据群名模糊搜索群组
 func (r *groupInfoRepositoryImpl) SearchGroupsByName(keyword string, limit int) ([]entity.GroupInfo, error) {
 	if keyword == "" {
@@ -89,3 +117,65 @@ func (r *groupInfoRepositoryImpl) FindGroupByExactName(name string) (*entity.Gro
 	}
 	return &group, nil
 }
+
+// ListGroups 按创建时间倒序分页查询群组
+func (r *groupInfoRepositoryImpl) ListGroups(q repository.GroupQuery) ([]entity.GroupInfo, int64, error) {
+	query := r.db.Model(&entity.GroupInfo{})
+	if q.Keyword != "" {
+		query = query.Where("name LIKE ? OR uuid = ?", "%"+q.Keyword+"%", q.Keyword)
+	}
+	if q.OwnerId != "" {
+		query = query.Where("owner_id = ?", q.OwnerId)
+	}
+	if q.Status != nil {
+		query = query.Where("status = ?", *q.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var groups []entity.GroupInfo
+	err := query.Order("created_at DESC, id DESC").
+		Offset(q.Offset).
+		Limit(q.Limit).
+		Find(&groups).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return groups, total, nil
+}
+
+// UpdateStatusByUUIDs 批量启用/禁用群组，已解散（status=2）的群不受影响
+func (r *groupInfoRepositoryImpl) UpdateStatusByUUIDs(uuids []string, status int8) (int64, error) {
+	if len(uuids) == 0 {
+		return 0, nil
+	}
+	res := r.db.Model(&entity.GroupInfo{}).
+		Where("uuid IN ? AND status IN ? AND status <> ?", uuids, []int8{0, 1}, status).
+		Updates(map[string]interface{}{"status": status, "updated_at": time.Now()})
+	return res.RowsAffected, res.Error
+}
+
+func (r *groupInfoRepositoryImpl) CreateProfileHistory(records []entity.GroupProfileHistory) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return r.db.Create(&records).Error
+}
+
+func (r *groupInfoRepositoryImpl) ListProfileHistory(groupID string, offset int, limit int) ([]entity.GroupProfileHistory, int64, error) {
+	var (
+		records []entity.GroupProfileHistory
+		total   int64
+	)
+	db := r.db.Model(&entity.GroupProfileHistory{}).Where("group_id = ?", groupID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
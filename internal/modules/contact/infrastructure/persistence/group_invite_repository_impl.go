@@ -0,0 +1,81 @@
+package persistence
+
+import (
+	"OmniLink/internal/modules/contact/domain/entity"
+	"OmniLink/internal/modules/contact/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type groupInviteRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewGroupInviteRepository(db *gorm.DB) repository.GroupInviteRepository {
+	return &groupInviteRepositoryImpl{db: db}
+}
+
+func (r *groupInviteRepositoryImpl) CreateInvite(invite *entity.GroupInvite) error {
+	return r.db.Create(invite).Error
+}
+
+func (r *groupInviteRepositoryImpl) GetInviteByToken(token string) (*entity.GroupInvite, error) {
+	var invite entity.GroupInvite
+	err := r.db.Where("token = ?", token).First(&invite).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (r *groupInviteRepositoryImpl) GetInviteByTokenForUpdate(token string) (*entity.GroupInvite, error) {
+	var invite entity.GroupInvite
+	err := r.db.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token = ?", token).
+		First(&invite).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (r *groupInviteRepositoryImpl) UpdateInvite(invite *entity.GroupInvite) error {
+	return r.db.Model(&entity.GroupInvite{}).
+		Where("id = ?", invite.Id).
+		Updates(map[string]interface{}{
+			"used_count": invite.UsedCount,
+			"status":     invite.Status,
+			"revoked_by": invite.RevokedBy,
+			"revoked_at": invite.RevokedAt,
+		}).Error
+}
+
+func (r *groupInviteRepositoryImpl) ListInvites(groupID string, creatorID string, limit int) ([]entity.GroupInvite, error) {
+	var invites []entity.GroupInvite
+	db := r.db.Where("group_id = ?", groupID)
+	if creatorID != "" {
+		db = db.Where("creator_id = ?", creatorID)
+	}
+	err := db.Order("created_at DESC, id DESC").Limit(limit).Find(&invites).Error
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (r *groupInviteRepositoryImpl) HasInviteUse(inviteID int64, userID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&entity.GroupInviteUse{}).
+		Where("invite_id = ? AND user_id = ?", inviteID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *groupInviteRepositoryImpl) CreateInviteUse(use *entity.GroupInviteUse) error {
+	return r.db.Create(use).Error
+}
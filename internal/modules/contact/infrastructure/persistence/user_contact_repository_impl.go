@@ -88,6 +88,15 @@ func (r *userContactRepositoryImpl) CreateUserContact(contact *entity.UserContac
 	return r.db.Create(contact).Error
 }
 
+func (r *userContactRepositoryImpl) ListGroupUserIDs(groupID string) ([]string, error) {
+	var userIDs []string
+	err := r.db.Model(&entity.UserContact{}).
+		Where("contact_id = ? AND contact_type = ?", groupID, 1).
+		Distinct().
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
 func (r *userContactRepositoryImpl) UpdateUserContact(contact *entity.UserContact) error {
 	return r.db.Model(&entity.UserContact{}).
 		Where("id = ?", contact.Id).
@@ -96,6 +105,9 @@ func (r *userContactRepositoryImpl) UpdateUserContact(contact *entity.UserContac
 			"contact_id":   contact.ContactId,
 			"contact_type": contact.ContactType,
 			"status":       contact.Status,
+			"role":         contact.Role,
+			"mute_until":   contact.MuteUntil,
+			"joined_at":    contact.JoinedAt,
 			"update_at":    contact.UpdateAt,
 		}).Error
 }
@@ -83,11 +83,10 @@ func (h *ContactHandler) ApplyContact(c *gin.Context) {
 
 	data, err := h.svc.ApplyContact(req)
 	if err == nil && h.hub != nil && req.ContactId != "" {
-		_ = h.hub.SendJSON(req.ContactId, map[string]interface{}{
-			"type":        "contact.apply",
-			"apply_id":    data.ApplyId,
+		_ = h.hub.SendNotification(req.ContactId, "contact.apply", map[string]interface{}{
+			"apply_id":     data.ApplyId,
 			"from_user_id": req.OwnerId,
-			"created_at":  time.Now().Format(time.RFC3339),
+			"created_at":   time.Now().Format(time.RFC3339),
 		})
 	}
 	back.Result(c, data, err)
@@ -140,3 +139,71 @@ func (h *ContactHandler) RefuseContactApply(c *gin.Context) {
 	err := h.svc.RefuseContactApply(req)
 	back.Result(c, nil, err)
 }
+
+func (h *ContactHandler) DeleteContact(c *gin.Context) {
+	var req contactRequest.DeleteContactRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	if uuid := c.GetString("uuid"); uuid != "" {
+		req.OwnerId = uuid
+	}
+
+	changed, err := h.svc.DeleteContact(req)
+	if err == nil && changed {
+		h.notifyPeer(req.ContactId, ws.NotificationContactDeleted, req.OwnerId)
+	}
+	back.Result(c, nil, err)
+}
+
+func (h *ContactHandler) BlackContact(c *gin.Context) {
+	var req contactRequest.BlackContactRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	if uuid := c.GetString("uuid"); uuid != "" {
+		req.OwnerId = uuid
+	}
+
+	changed, err := h.svc.BlackContact(req)
+	if err == nil && changed {
+		h.notifyPeer(req.ContactId, ws.NotificationContactBlocked, req.OwnerId)
+	}
+	back.Result(c, nil, err)
+}
+
+func (h *ContactHandler) CancelBlackContact(c *gin.Context) {
+	var req contactRequest.CancelBlackContactRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+
+	if uuid := c.GetString("uuid"); uuid != "" {
+		req.OwnerId = uuid
+	}
+
+	changed, err := h.svc.CancelBlackContact(req)
+	if err == nil && changed {
+		h.notifyPeer(req.ContactId, ws.NotificationContactUnblocked, req.OwnerId)
+	}
+	back.Result(c, nil, err)
+}
+
+// notifyPeer 关系变化后通知对方刷新好友列表与会话
+func (h *ContactHandler) notifyPeer(peerID string, kind string, fromUserID string) {
+	if h.hub == nil || peerID == "" {
+		return
+	}
+	_ = h.hub.SendNotification(peerID, kind, map[string]interface{}{
+		"from_user_id": fromUserID,
+		"created_at":   time.Now().Format(time.RFC3339),
+	})
+}
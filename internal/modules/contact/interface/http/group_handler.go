@@ -76,8 +76,8 @@ func (h *GroupHandler) InviteGroupMembers(c *gin.Context) {
 		req.OwnerId = uuid
 	}
 
-	err := h.svc.InviteGroupMembers(req)
-	back.Result(c, nil, err)
+	data, err := h.svc.InviteGroupMembers(req)
+	back.Result(c, data, err)
 }
 
 func (h *GroupHandler) LeaveGroup(c *gin.Context) {
@@ -110,4 +110,225 @@ func (h *GroupHandler) DismissGroup(c *gin.Context) {
 
 	err := h.svc.DismissGroup(req)
 	back.Result(c, nil, err)
-}
\ No newline at end of file
+}
+
+func (h *GroupHandler) ApplyJoinGroup(c *gin.Context) {
+	var req contactRequest.ApplyJoinGroupRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.ApplyJoinGroup(req)
+	back.Result(c, data, err)
+}
+
+func (h *GroupHandler) GetGroupApplyList(c *gin.Context) {
+	var req contactRequest.GetGroupApplyListRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.GetGroupApplyList(req)
+	back.Result(c, data, err)
+}
+
+func (h *GroupHandler) PassGroupApply(c *gin.Context) {
+	var req contactRequest.PassGroupApplyRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	err := h.svc.PassGroupApply(req)
+	back.Result(c, nil, err)
+}
+
+func (h *GroupHandler) RefuseGroupApply(c *gin.Context) {
+	var req contactRequest.RefuseGroupApplyRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	err := h.svc.RefuseGroupApply(req)
+	back.Result(c, nil, err)
+}
+
+func (h *GroupHandler) BatchPassGroupApply(c *gin.Context) {
+	var req contactRequest.BatchPassGroupApplyRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.BatchPassGroupApply(req)
+	back.Result(c, data, err)
+}
+
+func (h *GroupHandler) TransferGroupOwner(c *gin.Context) {
+	var req contactRequest.TransferGroupOwnerRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	err := h.svc.TransferGroupOwner(req)
+	back.Result(c, nil, err)
+}
+
+func (h *GroupHandler) SetGroupAdmin(c *gin.Context) {
+	var req contactRequest.SetGroupAdminRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	err := h.svc.SetGroupAdmin(req)
+	back.Result(c, nil, err)
+}
+
+func (h *GroupHandler) RemoveGroupMembers(c *gin.Context) {
+	var req contactRequest.RemoveGroupMembersRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	err := h.svc.RemoveGroupMembers(req)
+	back.Result(c, nil, err)
+}
+
+func (h *GroupHandler) MuteGroupMember(c *gin.Context) {
+	var req contactRequest.MuteGroupMemberRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	err := h.svc.MuteGroupMember(req)
+	back.Result(c, nil, err)
+}
+
+func (h *GroupHandler) MuteGroup(c *gin.Context) {
+	var req contactRequest.MuteGroupRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	err := h.svc.MuteGroup(req)
+	back.Result(c, nil, err)
+}
+
+func (h *GroupHandler) UpdateGroupInfo(c *gin.Context) {
+	var req contactRequest.UpdateGroupInfoRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.UpdateGroupInfo(req)
+	back.Result(c, data, err)
+}
+
+func (h *GroupHandler) GetGroupInfoHistory(c *gin.Context) {
+	var req contactRequest.GetGroupInfoHistoryRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.GetGroupInfoHistory(req)
+	back.Result(c, data, err)
+}
+
+func (h *GroupHandler) CreateGroupInvite(c *gin.Context) {
+	var req contactRequest.CreateGroupInviteRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.CreateGroupInvite(req)
+	back.Result(c, data, err)
+}
+
+func (h *GroupHandler) GetGroupInviteList(c *gin.Context) {
+	var req contactRequest.GetGroupInviteListRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.GetGroupInviteList(req)
+	back.Result(c, data, err)
+}
+
+func (h *GroupHandler) RevokeGroupInvite(c *gin.Context) {
+	var req contactRequest.GroupInviteTokenRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	err := h.svc.RevokeGroupInvite(req)
+	back.Result(c, nil, err)
+}
+
+func (h *GroupHandler) GetGroupInviteInfo(c *gin.Context) {
+	var req contactRequest.GroupInviteTokenRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.GetGroupInviteInfo(req)
+	back.Result(c, data, err)
+}
+
+func (h *GroupHandler) RedeemGroupInvite(c *gin.Context) {
+	var req contactRequest.RedeemGroupInviteRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	req.OwnerId = c.GetString("uuid")
+
+	data, err := h.svc.RedeemGroupInvite(req)
+	back.Result(c, data, err)
+}
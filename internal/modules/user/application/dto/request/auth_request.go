@@ -0,0 +1,6 @@
+package request
+
+// RefreshTokenRequest 用刷新令牌换取新的令牌对
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,19 @@
+package request
+
+// DeviceInfo 登录设备信息，均为可选；DeviceId 为空时由服务端分配并在登录结果中返回
+type DeviceInfo struct {
+	DeviceId   string `json:"device_id"`
+	Platform   string `json:"platform"` // web / ios / android / desktop
+	DeviceName string `json:"device_name"`
+	Ip         string `json:"-"` // 由 handler 填充
+}
+
+// LogoutDeviceRequest 退出指定设备的登录，DeviceId 为空表示当前设备
+type LogoutDeviceRequest struct {
+	DeviceId string `json:"device_id"`
+}
+
+// KickDeviceRequest 将自己的其他设备踢下线
+type KickDeviceRequest struct {
+	DeviceId string `json:"device_id" binding:"required"`
+}
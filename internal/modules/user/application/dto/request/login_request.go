@@ -3,4 +3,5 @@ package request
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
-}
\ No newline at end of file
+	DeviceInfo
+}
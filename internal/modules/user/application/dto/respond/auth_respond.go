@@ -0,0 +1,9 @@
+package respond
+
+// RefreshTokenRespond 刷新后的令牌对，旧的刷新令牌已失效
+type RefreshTokenRespond struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresAt    int64  `json:"expires_at"` // 访问令牌过期时间（Unix 秒）
+	DeviceId     string `json:"device_id"`
+}
@@ -0,0 +1,18 @@
+package respond
+
+// DeviceItem 登录设备
+type DeviceItem struct {
+	DeviceId   string `json:"device_id"`
+	Platform   string `json:"platform"`
+	DeviceName string `json:"device_name"`
+	Ip         string `json:"ip"`
+	Status     int8   `json:"status"` // 0.已登录，1.已退出，2.被踢下线
+	LastSeenAt string `json:"last_seen_at"`
+	CreatedAt  string `json:"created_at"`
+	Current    bool   `json:"current"` // 是否为发起请求的设备
+}
+
+// DeviceListRespond 用户的登录设备列表，按最近活跃时间倒序
+type DeviceListRespond struct {
+	Devices []DeviceItem `json:"devices"`
+}
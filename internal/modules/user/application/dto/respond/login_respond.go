@@ -1,15 +1,18 @@
 package respond
 
 type LoginRespond struct {
-	Uuid      string `json:"uuid"`
-	Username  string `json:"username"`
-	Nickname  string `json:"nickname"`
-	Avatar    string `json:"avatar"`
-	Gender    int8   `json:"gender"`
-	Birthday  string `json:"birthday"`
-	Signature string `json:"signature"`
-	CreatedAt string `json:"created_at"`
-	IsAdmin   int8   `json:"is_admin"`
-	Status    int8   `json:"status"`
-	Token     string `json:"token"`
+	Uuid         string `json:"uuid"`
+	Username     string `json:"username"`
+	Nickname     string `json:"nickname"`
+	Avatar       string `json:"avatar"`
+	Gender       int8   `json:"gender"`
+	Birthday     string `json:"birthday"`
+	Signature    string `json:"signature"`
+	CreatedAt    string `json:"created_at"`
+	IsAdmin      int8   `json:"is_admin"`
+	Status       int8   `json:"status"`
+	Token        string `json:"token"`         // 访问令牌
+	RefreshToken string `json:"refresh_token"` // 访问令牌过期后调用 /auth/refresh 换取新令牌，每次刷新都会轮换
+	ExpiresAt    int64  `json:"expires_at"`    // 访问令牌过期时间（Unix 秒）
+	DeviceId     string `json:"device_id"`
 }
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"OmniLink/internal/config"
+	"OmniLink/internal/modules/user/application/dto/request"
+	"OmniLink/internal/modules/user/application/dto/respond"
+	"OmniLink/internal/modules/user/domain/entity"
+	"OmniLink/internal/modules/user/domain/repository"
+	"OmniLink/pkg/util"
+	"OmniLink/pkg/util/myjwt"
+	"OmniLink/pkg/ws"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"gorm.io/gorm"
+)
+
+const maxDeviceIDLen = 64
+
+// DeviceKicker 断开设备连接，由 ws.Hub 实现
+type DeviceKicker interface {
+	KickDevice(userID string, deviceID string, reason string)
+}
+
+// TokenPair 登录或刷新后下发的令牌
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time // 访问令牌过期时间
+	DeviceId     string
+}
+
+// DeviceService 多端登录管理：登录时登记设备并签发与设备绑定的短期访问令牌和刷新令牌，
+// 退出或被踢时吊销该设备的全部令牌并断开其 WS 连接
+type DeviceService interface {
+	// Login 登记登录设备并签发令牌；开启单平台单会话时顶替同平台的其他设备
+	Login(userID string, username string, info request.DeviceInfo) (*TokenPair, error)
+	// Refresh 用刷新令牌换取新的令牌对，旧刷新令牌随即失效；已轮换的令牌被重复使用时视为泄露，吊销整个设备会话
+	Refresh(refreshToken string, ip string) (*TokenPair, error)
+	// Connected WS 连接建立时调用，刷新最近活跃时间；未登记的设备（旧令牌）补登记
+	Connected(userID string, deviceID string, ip string)
+	// Disconnected WS 连接断开时调用
+	Disconnected(userID string, deviceID string)
+	ListDevices(userID string, currentDeviceID string) (*respond.DeviceListRespond, error)
+	// Logout 退出指定设备的登录，deviceID 为空表示当前设备
+	Logout(userID string, currentDeviceID string, deviceID string) error
+	// Kick 将自己的其他设备踢下线
+	Kick(userID string, currentDeviceID string, deviceID string) error
+	// LogoutOthers 除 currentDeviceID 外的已登录设备全部退出，currentDeviceID 为空时全部退出
+	LogoutOthers(userID string, currentDeviceID string) error
+	// ForceLogout 管理员禁用或删除账号时，让该用户全部设备下线
+	ForceLogout(userID string) error
+}
+
+type deviceServiceImpl struct {
+	repo        repository.UserDeviceRepository
+	refreshRepo repository.RefreshTokenRepository
+	userRepo    repository.UserInfoRepository
+	kicker      DeviceKicker
+}
+
+func NewDeviceService(repo repository.UserDeviceRepository, refreshRepo repository.RefreshTokenRepository, userRepo repository.UserInfoRepository, kicker DeviceKicker) DeviceService {
+	return &deviceServiceImpl{repo: repo, refreshRepo: refreshRepo, userRepo: userRepo, kicker: kicker}
+}
+
+func (s *deviceServiceImpl) Login(userID string, username string, info request.DeviceInfo) (*TokenPair, error) {
+	deviceID := strings.TrimSpace(info.DeviceId)
+	if len(deviceID) > maxDeviceIDLen {
+		return nil, xerr.New(xerr.BadRequest, "device_id 过长")
+	}
+	if deviceID == "" {
+		deviceID = util.GenerateDeviceID()
+	}
+	platform := normalizePlatform(info.Platform)
+
+	// 同一设备重新登录时旧令牌作废
+	prev, err := s.repo.Get(userID, deviceID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if prev != nil {
+		s.revoke(prev)
+		if err := s.refreshRepo.RevokeByDevice(userID, deviceID); err != nil {
+			zlog.Error(err.Error())
+			return nil, xerr.ErrServerError
+		}
+	}
+
+	pair, claims, err := s.issue(userID, username, deviceID, util.GenerateShortUUID())
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	now := time.Now()
+	device := &entity.UserDevice{
+		UserId:        userID,
+		DeviceId:      deviceID,
+		Platform:      platform,
+		DeviceName:    truncate(strings.TrimSpace(info.DeviceName), 64),
+		Ip:            info.Ip,
+		TokenId:       claims.ID,
+		TokenExpireAt: claims.ExpiresAtTime(),
+		Status:        entity.DeviceStatusActive,
+		LastSeenAt:    now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.Upsert(device); err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	if config.GetConfig().AuthConfig.SingleSessionPerPlatform && platform != entity.PlatformUnknown {
+		s.replaceSamePlatform(userID, deviceID, platform)
+	}
+	return pair, nil
+}
+
+func (s *deviceServiceImpl) Refresh(refreshToken string, ip string) (*TokenPair, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil, xerr.New(xerr.BadRequest, "refresh_token 不能为空")
+	}
+	rt, err := s.refreshRepo.GetByHash(myjwt.HashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.Unauthorized, "refresh token 无效")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	switch rt.Status {
+	case entity.RefreshTokenRevoked:
+		return nil, xerr.New(xerr.Unauthorized, "refresh token 已失效，请重新登录")
+	case entity.RefreshTokenUsed:
+		s.reuseDetected(rt)
+		return nil, xerr.New(xerr.Unauthorized, "refresh token 已失效，请重新登录")
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, xerr.New(xerr.Unauthorized, "refresh token 已过期，请重新登录")
+	}
+
+	device, err := s.getDevice(rt.UserId, rt.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+	if device.Status != entity.DeviceStatusActive {
+		return nil, xerr.New(xerr.Unauthorized, "设备已退出登录，请重新登录")
+	}
+	user, err := s.userRepo.GetUserInfoByUUIDWithoutPassword(rt.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.Unauthorized, "用户不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if user.Status != 0 {
+		return nil, xerr.New(xerr.Forbidden, "用户已被禁用")
+	}
+
+	// 条件更新保证同一令牌只能轮换一次，并发刷新时后到者按重放处理
+	ok, err := s.refreshRepo.MarkUsed(rt.Id)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	if !ok {
+		s.reuseDetected(rt)
+		return nil, xerr.New(xerr.Unauthorized, "refresh token 已失效，请重新登录")
+	}
+
+	pair, claims, err := s.issue(user.Uuid, user.Username, device.DeviceId, rt.FamilyId)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	// 旧访问令牌随轮换作废，设备上只保留最新的一个
+	s.revoke(device)
+	now := time.Now()
+	device.Ip = ip
+	device.TokenId = claims.ID
+	device.TokenExpireAt = claims.ExpiresAtTime()
+	device.LastSeenAt = now
+	device.UpdatedAt = now
+	if err := s.repo.Upsert(device); err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	return pair, nil
+}
+
+// reuseDetected 已轮换的刷新令牌再次出现，说明令牌可能已泄露：吊销整个令牌族并让该设备下线
+func (s *deviceServiceImpl) reuseDetected(rt *entity.RefreshToken) {
+	zlog.Warn("refresh token reuse detected, user=" + rt.UserId + " device=" + rt.DeviceId)
+	if err := s.refreshRepo.RevokeFamily(rt.FamilyId); err != nil {
+		zlog.Error(err.Error())
+	}
+	device, err := s.repo.Get(rt.UserId, rt.DeviceId)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			zlog.Error(err.Error())
+		}
+		return
+	}
+	if err := s.signOut(device, entity.DeviceStatusKicked, ws.KickReasonRevoked); err != nil {
+		zlog.Error(err.Error())
+	}
+}
+
+// issue 签发访问令牌，并在 familyID 下签发新的刷新令牌
+func (s *deviceServiceImpl) issue(userID string, username string, deviceID string, familyID string) (*TokenPair, *myjwt.CustomClaims, error) {
+	access, claims, err := myjwt.GenerateToken(userID, username, deviceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	refresh, hash := myjwt.NewRefreshToken()
+	now := time.Now()
+	if err := s.refreshRepo.Create(&entity.RefreshToken{
+		TokenHash: hash,
+		FamilyId:  familyID,
+		UserId:    userID,
+		DeviceId:  deviceID,
+		Status:    entity.RefreshTokenActive,
+		ExpiresAt: now.Add(myjwt.RefreshTTL()),
+		CreatedAt: now,
+	}); err != nil {
+		return nil, nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresAt:    claims.ExpiresAtTime(),
+		DeviceId:     deviceID,
+	}, claims, nil
+}
+
+// replaceSamePlatform 同平台的其他已登录设备被顶替下线
+func (s *deviceServiceImpl) replaceSamePlatform(userID string, deviceID string, platform string) {
+	status := entity.DeviceStatusActive
+	devices, err := s.repo.ListByUserID(userID, &status)
+	if err != nil {
+		zlog.Error(err.Error())
+		return
+	}
+	for i := range devices {
+		d := &devices[i]
+		if d.DeviceId == deviceID || d.Platform != platform {
+			continue
+		}
+		if err := s.signOut(d, entity.DeviceStatusKicked, ws.KickReasonReplaced); err != nil {
+			zlog.Error(err.Error())
+		}
+	}
+}
+
+func (s *deviceServiceImpl) Connected(userID string, deviceID string, ip string) {
+	if userID == "" || deviceID == "" {
+		return
+	}
+	now := time.Now()
+	_, err := s.repo.Get(userID, deviceID)
+	if err == nil {
+		if err := s.repo.Touch(userID, deviceID, ip, now); err != nil {
+			zlog.Error("device touch failed: " + err.Error())
+		}
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		zlog.Error(err.Error())
+		return
+	}
+	// 旧令牌不带设备信息，连接时按客户端上报的 device_id 补登记，便于在设备列表中管理
+	if err := s.repo.Upsert(&entity.UserDevice{
+		UserId:     userID,
+		DeviceId:   deviceID,
+		Platform:   entity.PlatformUnknown,
+		Ip:         ip,
+		Status:     entity.DeviceStatusActive,
+		LastSeenAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}); err != nil {
+		zlog.Error("device register failed: " + err.Error())
+	}
+}
+
+func (s *deviceServiceImpl) Disconnected(userID string, deviceID string) {
+	if userID == "" || deviceID == "" {
+		return
+	}
+	if err := s.repo.Touch(userID, deviceID, "", time.Now()); err != nil {
+		zlog.Error("device touch failed: " + err.Error())
+	}
+}
+
+func (s *deviceServiceImpl) ListDevices(userID string, currentDeviceID string) (*respond.DeviceListRespond, error) {
+	if userID == "" {
+		return nil, xerr.New(xerr.Unauthorized, "未登录")
+	}
+	devices, err := s.repo.ListByUserID(userID, nil)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+
+	items := make([]respond.DeviceItem, 0, len(devices))
+	for _, d := range devices {
+		items = append(items, respond.DeviceItem{
+			DeviceId:   d.DeviceId,
+			Platform:   d.Platform,
+			DeviceName: d.DeviceName,
+			Ip:         d.Ip,
+			Status:     d.Status,
+			LastSeenAt: d.LastSeenAt.Format("2006-01-02 15:04:05"),
+			CreatedAt:  d.CreatedAt.Format("2006-01-02 15:04:05"),
+			Current:    d.DeviceId == currentDeviceID,
+		})
+	}
+	return &respond.DeviceListRespond{Devices: items}, nil
+}
+
+func (s *deviceServiceImpl) Logout(userID string, currentDeviceID string, deviceID string) error {
+	deviceID = strings.TrimSpace(deviceID)
+	if deviceID == "" {
+		deviceID = currentDeviceID
+	}
+	if deviceID == "" {
+		return xerr.New(xerr.BadRequest, "当前登录未绑定设备，请重新登录")
+	}
+	device, err := s.getDevice(userID, deviceID)
+	if err != nil {
+		return err
+	}
+	if err := s.signOut(device, entity.DeviceStatusLoggedOut, ws.KickReasonLogout); err != nil {
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	return nil
+}
+
+func (s *deviceServiceImpl) Kick(userID string, currentDeviceID string, deviceID string) error {
+	deviceID = strings.TrimSpace(deviceID)
+	if deviceID == "" {
+		return xerr.New(xerr.BadRequest, "device_id 不能为空")
+	}
+	if deviceID == currentDeviceID {
+		return xerr.New(xerr.BadRequest, "不能将当前设备踢下线，请使用退出登录")
+	}
+	device, err := s.getDevice(userID, deviceID)
+	if err != nil {
+		return err
+	}
+	if err := s.signOut(device, entity.DeviceStatusKicked, ws.KickReasonKicked); err != nil {
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	return nil
+}
+
+func (s *deviceServiceImpl) LogoutOthers(userID string, currentDeviceID string) error {
+	status := entity.DeviceStatusActive
+	devices, err := s.repo.ListByUserID(userID, &status)
+	if err != nil {
+		return err
+	}
+	for i := range devices {
+		if devices[i].DeviceId == currentDeviceID {
+			continue
+		}
+		if err := s.signOut(&devices[i], entity.DeviceStatusLoggedOut, ws.KickReasonLogout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *deviceServiceImpl) ForceLogout(userID string) error {
+	status := entity.DeviceStatusActive
+	devices, err := s.repo.ListByUserID(userID, &status)
+	if err != nil {
+		return err
+	}
+	for i := range devices {
+		if err := s.signOut(&devices[i], entity.DeviceStatusKicked, ws.KickReasonDisabled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *deviceServiceImpl) getDevice(userID string, deviceID string) (*entity.UserDevice, error) {
+	device, err := s.repo.Get(userID, deviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, xerr.New(xerr.NotFound, "设备不存在")
+		}
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
+	return device, nil
+}
+
+// signOut 吊销设备的访问令牌与刷新令牌、更新状态并断开其连接；已下线的设备重复操作时只补发断开
+func (s *deviceServiceImpl) signOut(device *entity.UserDevice, status int8, reason string) error {
+	s.revoke(device)
+	if err := s.refreshRepo.RevokeByDevice(device.UserId, device.DeviceId); err != nil {
+		return err
+	}
+	if device.Status == entity.DeviceStatusActive {
+		if _, err := s.repo.UpdateStatus(device.UserId, device.DeviceId, device.TokenId, status); err != nil {
+			return err
+		}
+	}
+	if s.kicker != nil {
+		s.kicker.KickDevice(device.UserId, device.DeviceId, reason)
+	}
+	return nil
+}
+
+func (s *deviceServiceImpl) revoke(device *entity.UserDevice) {
+	if device.TokenId == "" {
+		return
+	}
+	if err := myjwt.Revoke(device.TokenId, device.TokenExpireAt); err != nil {
+		zlog.Error("jwt revoke failed: " + err.Error())
+	}
+}
+
+func normalizePlatform(platform string) string {
+	switch p := strings.ToLower(strings.TrimSpace(platform)); p {
+	case entity.PlatformWeb, entity.PlatformIOS, entity.PlatformAndroid, entity.PlatformDesktop:
+		return p
+	default:
+		return entity.PlatformUnknown
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -1,18 +1,21 @@
 package service
 
 import (
+	"OmniLink/internal/config"
 	"OmniLink/internal/modules/user/application/dto/request"
 	"OmniLink/internal/modules/user/application/dto/respond"
 	"OmniLink/internal/modules/user/domain/entity"
 	"OmniLink/internal/modules/user/domain/repository"
 	"OmniLink/pkg/util"
-	"OmniLink/pkg/util/myjwt"
+	"OmniLink/pkg/util/password"
 	"OmniLink/pkg/xerr"
 	"OmniLink/pkg/zlog"
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
+	"unicode"
 
 	aiService "OmniLink/internal/modules/ai/application/service"
 
@@ -23,25 +26,36 @@ import (
 type UserInfoService interface {
 	Register(registerReq request.RegisterRequest) (*respond.RegisterRespond, error)
 	Login(loginReq request.LoginRequest) (*respond.LoginRespond, error)
+	// ChangePassword 校验旧密码后修改密码，并让该用户的其他设备下线
+	ChangePassword(uuid string, currentDeviceID string, req request.ChangePasswordRequest) error
 	GetUserInfoInternal(ctx context.Context, uuid string) (*respond.InternalUserInfoRespond, error)
+	// GetRole 查询用户当前角色，供权限校验使用；账号不存在或已禁用时返回错误
+	GetRole(uuid string) (entity.Role, error)
 }
 
 type userInfoServiceImpl struct {
 	repo         repository.UserInfoRepository
+	deviceSvc    DeviceService
 	lifecycleSvc aiService.UserLifecycleService
 	jobSvc       aiService.AIJobService
+	throttle     loginThrottle
 }
 
 // NewUserInfoService 构造函数
-func NewUserInfoService(repo repository.UserInfoRepository, lifecycleSvc aiService.UserLifecycleService, jobSvc aiService.AIJobService) UserInfoService {
+func NewUserInfoService(repo repository.UserInfoRepository, deviceSvc DeviceService, lifecycleSvc aiService.UserLifecycleService, jobSvc aiService.AIJobService) UserInfoService {
 	return &userInfoServiceImpl{
 		repo:         repo,
+		deviceSvc:    deviceSvc,
 		lifecycleSvc: lifecycleSvc,
 		jobSvc:       jobSvc,
 	}
 }
 
 func (u *userInfoServiceImpl) Register(registerReq request.RegisterRequest) (*respond.RegisterRespond, error) {
+	if err := checkPasswordPolicy(registerReq.Password); err != nil {
+		return nil, err
+	}
+
 	// 1. Check if user exists (only username)
 	_, err := u.repo.GetUserInfoByUsername(registerReq.Username)
 	if err == nil {
@@ -51,6 +65,11 @@ func (u *userInfoServiceImpl) Register(registerReq request.RegisterRequest) (*re
 		zlog.Error(err.Error())
 		return nil, xerr.ErrServerError
 	}
+	hashed, err := password.Hash(registerReq.Password)
+	if err != nil {
+		zlog.Error(err.Error())
+		return nil, xerr.ErrServerError
+	}
 
 	// 2. Generate UUID
 	uuid := util.GenerateUserID()
@@ -59,7 +78,7 @@ func (u *userInfoServiceImpl) Register(registerReq request.RegisterRequest) (*re
 		Uuid:      uuid,
 		Username:  registerReq.Username,
 		Nickname:  registerReq.Nickname,
-		Password:  registerReq.Password,
+		Password:  hashed,
 		Avatar:    "https://cube.elemecdn.com/0/88/03b0d39583f48206768a7534e55bcpng.png",
 		Status:    0,
 		IsAdmin:   0,
@@ -83,43 +102,62 @@ func (u *userInfoServiceImpl) Register(registerReq request.RegisterRequest) (*re
 	}
 	// =====================================================
 
-	token, err := myjwt.GenerateToken(newUser.Uuid, newUser.Username)
+	tokens, err := u.deviceSvc.Login(newUser.Uuid, newUser.Username, registerReq.DeviceInfo)
 	if err != nil {
-		zlog.Error(err.Error())
-		return nil, xerr.ErrServerError
+		return nil, err
 	}
 
 	return &respond.RegisterRespond{
-		Uuid:      newUser.Uuid,
-		Username:  newUser.Username,
-		Nickname:  newUser.Nickname,
-		Avatar:    newUser.Avatar,
-		Gender:    newUser.Gender,
-		Birthday:  newUser.Birthday,
-		Signature: newUser.Signature,
-		CreatedAt: newUser.CreatedAt.Format("2006-01-02 15:04:05"),
-		IsAdmin:   newUser.IsAdmin,
-		Status:    newUser.Status,
-		Token:     token,
+		Uuid:         newUser.Uuid,
+		Username:     newUser.Username,
+		Nickname:     newUser.Nickname,
+		Avatar:       newUser.Avatar,
+		Gender:       newUser.Gender,
+		Birthday:     newUser.Birthday,
+		Signature:    newUser.Signature,
+		CreatedAt:    newUser.CreatedAt.Format("2006-01-02 15:04:05"),
+		IsAdmin:      newUser.IsAdmin,
+		Status:       newUser.Status,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt.Unix(),
+		DeviceId:     tokens.DeviceId,
 	}, nil
 }
 
 func (u *userInfoServiceImpl) Login(loginReq request.LoginRequest) (*respond.LoginRespond, error) {
+	if err := u.throttle.Check(loginReq.Username, loginReq.Ip); err != nil {
+		return nil, err
+	}
+
 	user, err := u.repo.GetUserInfoByUsername(loginReq.Username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, xerr.New(xerr.BadRequest, "用户不存在")
+			u.throttle.Fail(loginReq.Username, loginReq.Ip)
+			return nil, xerr.New(xerr.BadRequest, "用户名或密码错误")
 		}
 		zlog.Error(err.Error())
 		return nil, xerr.ErrServerError
 	}
 
+	ok, needRehash := password.Verify(user.Password, loginReq.Password)
+	if !ok {
+		u.throttle.Fail(loginReq.Username, loginReq.Ip)
+		return nil, xerr.New(xerr.BadRequest, "用户名或密码错误")
+	}
+	u.throttle.Reset(loginReq.Username)
+
 	if user.Status != 0 {
 		return nil, xerr.New(xerr.Forbidden, "用户已被禁用")
 	}
 
-	if user.Password != loginReq.Password {
-		return nil, xerr.New(xerr.BadRequest, "密码错误")
+	// 旧数据的明文密码在首次登录成功时透明升级为哈希
+	if needRehash {
+		if hashed, err := password.Hash(loginReq.Password); err != nil {
+			zlog.Error(err.Error())
+		} else if err := u.repo.UpdatePassword(user.Uuid, hashed); err != nil {
+			zlog.Error("password rehash failed, uuid=" + user.Uuid + ": " + err.Error())
+		}
 	}
 
 	// ==================== AI模块兜底初始化 ====================
@@ -144,27 +182,99 @@ func (u *userInfoServiceImpl) Login(loginReq request.LoginRequest) (*respond.Log
 		}()
 	}
 
-	token, err := myjwt.GenerateToken(user.Uuid, user.Username)
+	tokens, err := u.deviceSvc.Login(user.Uuid, user.Username, loginReq.DeviceInfo)
 	if err != nil {
-		zlog.Error(err.Error())
-		return nil, xerr.ErrServerError
+		return nil, err
 	}
 
 	return &respond.LoginRespond{
-		Uuid:      user.Uuid,
-		Username:  user.Username,
-		Nickname:  user.Nickname,
-		Avatar:    user.Avatar,
-		Gender:    user.Gender,
-		Birthday:  user.Birthday,
-		Signature: user.Signature,
-		CreatedAt: user.CreatedAt.Format("2006-01-02 15:04:05"),
-		IsAdmin:   user.IsAdmin,
-		Status:    user.Status,
-		Token:     token,
+		Uuid:         user.Uuid,
+		Username:     user.Username,
+		Nickname:     user.Nickname,
+		Avatar:       user.Avatar,
+		Gender:       user.Gender,
+		Birthday:     user.Birthday,
+		Signature:    user.Signature,
+		CreatedAt:    user.CreatedAt.Format("2006-01-02 15:04:05"),
+		IsAdmin:      user.IsAdmin,
+		Status:       user.Status,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt.Unix(),
+		DeviceId:     tokens.DeviceId,
 	}, nil
 }
 
+func (u *userInfoServiceImpl) ChangePassword(uuid string, currentDeviceID string, req request.ChangePasswordRequest) error {
+	if uuid == "" {
+		return xerr.New(xerr.Unauthorized, "未登录")
+	}
+	user, err := u.repo.GetUserInfoByUUID(uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return xerr.New(xerr.BadRequest, "用户不存在")
+		}
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+
+	if err := u.throttle.Check(user.Username, ""); err != nil {
+		return err
+	}
+	if ok, _ := password.Verify(user.Password, req.OldPassword); !ok {
+		u.throttle.Fail(user.Username, "")
+		return xerr.New(xerr.BadRequest, "原密码错误")
+	}
+	if req.NewPassword == req.OldPassword {
+		return xerr.New(xerr.BadRequest, "新密码不能与原密码相同")
+	}
+	if err := checkPasswordPolicy(req.NewPassword); err != nil {
+		return err
+	}
+
+	hashed, err := password.Hash(req.NewPassword)
+	if err != nil {
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+	if err := u.repo.UpdatePassword(uuid, hashed); err != nil {
+		zlog.Error(err.Error())
+		return xerr.ErrServerError
+	}
+
+	// 改密后其他设备上的登录全部失效
+	if err := u.deviceSvc.LogoutOthers(uuid, currentDeviceID); err != nil {
+		zlog.Error("logout other devices failed, uuid=" + uuid + ": " + err.Error())
+	}
+	return nil
+}
+
+// checkPasswordPolicy 密码策略：长度在 [passwordMinLength, password.MaxLength] 内，且同时包含字母和数字
+func checkPasswordPolicy(pw string) error {
+	minLen := config.GetConfig().AuthConfig.PasswordMinLength
+	if minLen <= 0 {
+		minLen = 8
+	}
+	if len(pw) < minLen || len(pw) > password.MaxLength {
+		return xerr.New(xerr.BadRequest, fmt.Sprintf("密码长度需在 %d 到 %d 位之间", minLen, password.MaxLength))
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsSpace(r):
+			return xerr.New(xerr.BadRequest, "密码不能包含空白字符")
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return xerr.New(xerr.BadRequest, "密码需同时包含字母和数字")
+	}
+	return nil
+}
+
 func (u *userInfoServiceImpl) GetUserInfoInternal(ctx context.Context, uuid string) (*respond.InternalUserInfoRespond, error) {
 	uuid = strings.TrimSpace(uuid)
 	if uuid == "" {
@@ -205,3 +315,18 @@ func (u *userInfoServiceImpl) GetUserInfoInternal(ctx context.Context, uuid stri
 		Status:        user.Status,
 	}, nil
 }
+
+func (u *userInfoServiceImpl) GetRole(uuid string) (entity.Role, error) {
+	user, err := u.repo.GetUserInfoByUUIDWithoutPassword(uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", xerr.New(xerr.Unauthorized, "用户不存在")
+		}
+		zlog.Error(err.Error())
+		return "", xerr.ErrServerError
+	}
+	if user.Status != 0 {
+		return "", xerr.New(xerr.Forbidden, "账号已被禁用")
+	}
+	return user.Role(), nil
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 刷新令牌状态
+const (
+	RefreshTokenActive  int8 = 0 // 可用
+	RefreshTokenUsed    int8 = 1 // 已轮换，再次出现视为被盗用
+	RefreshTokenRevoked int8 = 2 // 已吊销（退出登录、被踢或检测到盗用）
+)
+
+// RefreshToken 刷新令牌，只存 SHA-256 摘要；同一次登录轮换出的令牌属于同一 FamilyId，
+// 检测到已轮换的令牌被重复使用时吊销整个 family
+type RefreshToken struct {
+	Id        int64        `gorm:"column:id;primaryKey;comment:自增id"`
+	TokenHash string       `gorm:"column:token_hash;uniqueIndex;type:char(64);not null;comment:令牌SHA-256摘要"`
+	FamilyId  string       `gorm:"column:family_id;index;type:char(32);not null;comment:令牌族id，同一次登录轮换出的令牌相同"`
+	UserId    string       `gorm:"column:user_id;index:idx_user_device,priority:1;type:char(20);not null;comment:用户uuid"`
+	DeviceId  string       `gorm:"column:device_id;index:idx_user_device,priority:2;type:varchar(64);not null;comment:登录设备id"`
+	Status    int8         `gorm:"column:status;not null;comment:状态，0.可用，1.已轮换，2.已吊销"`
+	ExpiresAt time.Time    `gorm:"column:expires_at;not null;comment:过期时间"`
+	CreatedAt time.Time    `gorm:"column:created_at;not null;comment:签发时间"`
+	UsedAt    sql.NullTime `gorm:"column:used_at;comment:轮换时间"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_token"
+}
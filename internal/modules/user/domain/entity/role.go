@@ -0,0 +1,44 @@
+package entity
+
+// Role 用户角色，由 UserInfo.IsAdmin 决定
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+// Permission 受保护接口所需的权限
+type Permission string
+
+const (
+	PermUserRead   Permission = "user:read"   // 查询用户列表
+	PermUserWrite  Permission = "user:write"  // 启用、禁用、删除用户，设置管理员
+	PermGroupRead  Permission = "group:read"  // 查询群组列表
+	PermGroupWrite Permission = "group:write" // 启用、禁用群组
+	PermAuditRead  Permission = "audit:read"  // 查询管理操作审计日志
+	PermAIInternal Permission = "ai:internal" // AI 内部运维接口（如知识库回填）
+)
+
+// rolePermissions 角色 -> 权限，普通用户不具备任何管理权限
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {PermUserRead, PermUserWrite, PermGroupRead, PermGroupWrite, PermAuditRead, PermAIInternal},
+}
+
+// Can 角色是否拥有权限
+func (r Role) Can(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Role 用户角色
+func (u *UserInfo) Role() Role {
+	if u.IsAdmin == 1 {
+		return RoleAdmin
+	}
+	return RoleUser
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// 设备状态
+const (
+	DeviceStatusActive    int8 = 0 // 已登录
+	DeviceStatusLoggedOut int8 = 1 // 已退出登录
+	DeviceStatusKicked    int8 = 2 // 被踢下线
+)
+
+// 设备平台
+const (
+	PlatformWeb     = "web"
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformDesktop = "desktop"
+	PlatformUnknown = "unknown"
+)
+
+// UserDevice 登录设备，每个 (用户, 设备) 一条；TokenId 为该设备当前令牌的 jti，退出或被踢时据此吊销
+type UserDevice struct {
+	Id            int64     `gorm:"column:id;primaryKey;comment:自增id"`
+	UserId        string    `gorm:"column:user_id;uniqueIndex:uk_user_device,priority:1;type:char(20);not null;comment:用户uuid"`
+	DeviceId      string    `gorm:"column:device_id;uniqueIndex:uk_user_device,priority:2;type:varchar(64);not null;comment:设备id，客户端生成或登录时由服务端分配"`
+	Platform      string    `gorm:"column:platform;type:varchar(16);not null;comment:平台，web/ios/android/desktop/unknown"`
+	DeviceName    string    `gorm:"column:device_name;type:varchar(64);comment:设备名称"`
+	Ip            string    `gorm:"column:ip;type:varchar(64);comment:最近一次登录或连接的IP"`
+	TokenId       string    `gorm:"column:token_id;type:varchar(64);comment:当前令牌jti"`
+	TokenExpireAt time.Time `gorm:"column:token_expire_at;comment:当前令牌过期时间"`
+	Status        int8      `gorm:"column:status;not null;comment:状态，0.已登录，1.已退出，2.被踢下线"`
+	LastSeenAt    time.Time `gorm:"column:last_seen_at;not null;comment:最近活跃时间"`
+	CreatedAt     time.Time `gorm:"column:created_at;not null;comment:首次登录时间"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;not null;comment:更新时间"`
+}
+
+func (UserDevice) TableName() string {
+	return "user_device"
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "OmniLink/internal/modules/user/domain/entity"
+
+// RefreshTokenRepository 刷新令牌存储
+type RefreshTokenRepository interface {
+	Create(token *entity.RefreshToken) error
+	GetByHash(tokenHash string) (*entity.RefreshToken, error)
+	// MarkUsed 将可用令牌标记为已轮换，返回 false 表示令牌已不是可用状态（并发刷新或重放）
+	MarkUsed(id int64) (bool, error)
+	// RevokeFamily 吊销令牌族中仍可用的令牌
+	RevokeFamily(familyID string) error
+	// RevokeByDevice 吊销设备上仍可用的令牌
+	RevokeByDevice(userID string, deviceID string) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"time"
+
+	"OmniLink/internal/modules/user/domain/entity"
+)
+
+// UserDeviceRepository 登录设备登记
+type UserDeviceRepository interface {
+	Get(userID string, deviceID string) (*entity.UserDevice, error)
+	// ListByUserID 按最近活跃时间倒序返回用户的设备，status 为 nil 时不过滤
+	ListByUserID(userID string, status *int8) ([]entity.UserDevice, error)
+	// Upsert 登录时写入设备，已存在则覆盖平台、名称、IP、令牌与状态
+	Upsert(device *entity.UserDevice) error
+	// Touch 刷新最近活跃时间，ip 为空时不更新
+	Touch(userID string, deviceID string, ip string, t time.Time) error
+	// UpdateStatus 更新设备状态，只修改当前令牌仍为 tokenID 的记录，避免覆盖重新登录后的状态
+	UpdateStatus(userID string, deviceID string, tokenID string, status int8) (bool, error)
+}
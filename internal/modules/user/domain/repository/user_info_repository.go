@@ -8,11 +8,24 @@ import (
 	"OmniLink/internal/modules/user/domain/entity"
 )
 
+// UserQuery 管理端用户列表查询条件，指针字段为 nil 时不过滤
+type UserQuery struct {
+	Keyword string // 按用户名或昵称模糊匹配
+	Status  *int8
+	IsAdmin *int8
+	Offset  int
+	Limit   int
+}
+
 // UserInfoRepository 接口定义
 type UserInfoRepository interface {
 	CreateUserInfo(user *entity.UserInfo) error
 	GetUserInfoById(id int64) (*entity.UserInfo, error)
 	GetUserInfoByUsername(username string) (*entity.UserInfo, error)
+	// GetUserInfoByUUID 含密码字段，仅用于密码校验
+	GetUserInfoByUUID(uuid string) (*entity.UserInfo, error)
+	// UpdatePassword 写入新的密码哈希
+	UpdatePassword(uuid string, hashed string) error
 	GetUserInfoByUUIDWithoutPassword(uuid string) (*entity.UserInfo, error)
 	GetBatchUserInfoWithoutPassword(uuids []string) ([]entity.UserInfo, error)
 	GetUserBriefByUUIDs(uuids []string) ([]entity.UserBrief, error)
@@ -22,6 +35,15 @@ type UserInfoRepository interface {
 	// FindUserByExactNickname 根据精确昵称查找用户（支持用户名降级）
 	FindUserByExactNickname(nickname string) (*entity.UserBrief, error)
 
+	// ListUsers 按注册时间倒序分页查询用户（不含密码），返回当前页与总数
+	ListUsers(q UserQuery) ([]entity.UserInfo, int64, error)
+	// UpdateStatusByUUIDs 批量修改用户状态，返回受影响行数
+	UpdateStatusByUUIDs(uuids []string, status int8) (int64, error)
+	// UpdateIsAdmin 设置或取消管理员
+	UpdateIsAdmin(uuid string, isAdmin int8) error
+	// DeleteByUUIDs 批量软删除用户，返回受影响行数
+	DeleteByUUIDs(uuids []string) (int64, error)
+
 	// UpdateLastOnlineAt 更新用户上线时间
 	UpdateLastOnlineAt(ctx context.Context, uuid string, t time.Time) error
 	// UpdateLastOfflineAt 更新用户离线时间
@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"time"
+
+	"OmniLink/internal/modules/user/domain/entity"
+	"OmniLink/internal/modules/user/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) repository.RefreshTokenRepository {
+	return &refreshTokenRepositoryImpl{db: db}
+}
+
+func (r *refreshTokenRepositoryImpl) Create(token *entity.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *refreshTokenRepositoryImpl) GetByHash(tokenHash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *refreshTokenRepositoryImpl) MarkUsed(id int64) (bool, error) {
+	res := r.db.Model(&entity.RefreshToken{}).
+		Where("id = ? AND status = ?", id, entity.RefreshTokenActive).
+		Updates(map[string]interface{}{
+			"status":  entity.RefreshTokenUsed,
+			"used_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *refreshTokenRepositoryImpl) RevokeFamily(familyID string) error {
+	return r.db.Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND status = ?", familyID, entity.RefreshTokenActive).
+		Update("status", entity.RefreshTokenRevoked).Error
+}
+
+func (r *refreshTokenRepositoryImpl) RevokeByDevice(userID string, deviceID string) error {
+	return r.db.Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND device_id = ? AND status = ?", userID, deviceID, entity.RefreshTokenActive).
+		Update("status", entity.RefreshTokenRevoked).Error
+}
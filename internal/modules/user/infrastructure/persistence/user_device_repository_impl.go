@@ -0,0 +1,69 @@
+package persistence
+
+import (
+	"time"
+
+	"OmniLink/internal/modules/user/domain/entity"
+	"OmniLink/internal/modules/user/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type userDeviceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUserDeviceRepository(db *gorm.DB) repository.UserDeviceRepository {
+	return &userDeviceRepositoryImpl{db: db}
+}
+
+func (r *userDeviceRepositoryImpl) Get(userID string, deviceID string) (*entity.UserDevice, error) {
+	var device entity.UserDevice
+	if err := r.db.Where("user_id = ? AND device_id = ?", userID, deviceID).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *userDeviceRepositoryImpl) ListByUserID(userID string, status *int8) ([]entity.UserDevice, error) {
+	var devices []entity.UserDevice
+	query := r.db.Where("user_id = ?", userID)
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	err := query.Order("last_seen_at DESC").Find(&devices).Error
+	return devices, err
+}
+
+func (r *userDeviceRepositoryImpl) Upsert(device *entity.UserDevice) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "device_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"platform", "device_name", "ip", "token_id", "token_expire_at", "status", "last_seen_at", "updated_at",
+		}),
+	}).Create(device).Error
+}
+
+func (r *userDeviceRepositoryImpl) Touch(userID string, deviceID string, ip string, t time.Time) error {
+	updates := map[string]interface{}{
+		"last_seen_at": t,
+		"updated_at":   t,
+	}
+	if ip != "" {
+		updates["ip"] = ip
+	}
+	return r.db.Model(&entity.UserDevice{}).
+		Where("user_id = ? AND device_id = ?", userID, deviceID).
+		Updates(updates).Error
+}
+
+func (r *userDeviceRepositoryImpl) UpdateStatus(userID string, deviceID string, tokenID string, status int8) (bool, error) {
+	res := r.db.Model(&entity.UserDevice{}).
+		Where("user_id = ? AND device_id = ? AND token_id = ?", userID, deviceID, tokenID).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
@@ -47,6 +47,23 @@ func (r *userInfoRepositoryImpl) GetUserInfoByUsername(username string) (*entity
 	return &user, nil
 }
 
+// GetUserInfoByUUID 含密码字段，仅用于密码校验
+func (r *userInfoRepositoryImpl) GetUserInfoByUUID(uuid string) (*entity.UserInfo, error) {
+	var user entity.UserInfo
+	err := r.db.Where("uuid = ?", uuid).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdatePassword 写入新的密码哈希
+func (r *userInfoRepositoryImpl) UpdatePassword(uuid string, hashed string) error {
+	return r.db.Model(&entity.UserInfo{}).
+		Where("uuid = ?", uuid).
+		Update("password", hashed).Error
+}
+
 // UpdateLastOnlineAt 更新用户上线时间
 func (r *userInfoRepositoryImpl) UpdateLastOnlineAt(ctx context.Context, uuid string, t time.Time) error {
 	return r.db.WithContext(ctx).Model(&entity.UserInfo{}).
@@ -73,6 +90,63 @@ func (r *userInfoRepositoryImpl) GetUserInfoByUUIDWithoutPassword(uuid string) (
 	return &user, nil
 }
 
+// ListUsers 按注册时间倒序分页查询用户（不含密码）
+func (r *userInfoRepositoryImpl) ListUsers(q repository.UserQuery) ([]entity.UserInfo, int64, error) {
+	query := r.db.Model(&entity.UserInfo{})
+	if q.Keyword != "" {
+		like := "%" + q.Keyword + "%"
+		query = query.Where("username LIKE ? OR nickname LIKE ?", like, like)
+	}
+	if q.Status != nil {
+		query = query.Where("status = ?", *q.Status)
+	}
+	if q.IsAdmin != nil {
+		query = query.Where("is_admin = ?", *q.IsAdmin)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []entity.UserInfo
+	err := query.Select("id, uuid, username, nickname, avatar, gender, signature, birthday, created_at, last_online_at, last_offline_at, is_admin, status").
+		Order("created_at DESC, id DESC").
+		Offset(q.Offset).
+		Limit(q.Limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateStatusByUUIDs 批量修改用户状态
+func (r *userInfoRepositoryImpl) UpdateStatusByUUIDs(uuids []string, status int8) (int64, error) {
+	if len(uuids) == 0 {
+		return 0, nil
+	}
+	res := r.db.Model(&entity.UserInfo{}).
+		Where("uuid IN ? AND status <> ?", uuids, status).
+		Update("status", status)
+	return res.RowsAffected, res.Error
+}
+
+// UpdateIsAdmin 设置或取消管理员
+func (r *userInfoRepositoryImpl) UpdateIsAdmin(uuid string, isAdmin int8) error {
+	return r.db.Model(&entity.UserInfo{}).
+		Where("uuid = ?", uuid).
+		Update("is_admin", isAdmin).Error
+}
+
+// DeleteByUUIDs 批量软删除用户
+func (r *userInfoRepositoryImpl) DeleteByUUIDs(uuids []string) (int64, error) {
+	if len(uuids) == 0 {
+		return 0, nil
+	}
+	res := r.db.Where("uuid IN ?", uuids).Delete(&entity.UserInfo{})
+	return res.RowsAffected, res.Error
+}
+
 func (r *userInfoRepositoryImpl) GetBatchUserInfoWithoutPassword(uuids []string) ([]entity.UserInfo, error) {
 	if len(uuids) == 0 {
 		return []entity.UserInfo{}, nil
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"OmniLink/internal/modules/user/application/dto/request"
+	"OmniLink/internal/modules/user/application/dto/respond"
+	"OmniLink/internal/modules/user/application/service"
+	"OmniLink/pkg/back"
+	"OmniLink/pkg/util/myjwt"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	deviceSvc service.DeviceService
+}
+
+func NewAuthHandler(deviceSvc service.DeviceService) *AuthHandler {
+	return &AuthHandler{deviceSvc: deviceSvc}
+}
+
+// Refresh 用刷新令牌换取新的访问令牌与刷新令牌（无需访问令牌）
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req request.RefreshTokenRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	pair, err := h.deviceSvc.Refresh(req.RefreshToken, c.ClientIP())
+	if err != nil {
+		back.Result(c, nil, err)
+		return
+	}
+	back.Result(c, &respond.RefreshTokenRespond{
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresAt:    pair.ExpiresAt.Unix(),
+		DeviceId:     pair.DeviceId,
+	}, nil)
+}
+
+// Logout 退出当前登录：吊销当前访问令牌与所在设备的刷新令牌，并断开该设备的 WS
+func (h *AuthHandler) Logout(c *gin.Context) {
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+	if deviceID := c.GetString("device_id"); deviceID != "" {
+		back.Result(c, nil, h.deviceSvc.Logout(uuid, deviceID, deviceID))
+		return
+	}
+
+	// 旧令牌没有绑定设备，只能吊销令牌本身
+	claims, _ := c.Get("claims")
+	if cc, ok := claims.(*myjwt.CustomClaims); ok {
+		if err := myjwt.Revoke(cc.ID, cc.ExpiresAtTime()); err != nil {
+			zlog.Error(err.Error())
+			back.Result(c, nil, xerr.ErrServerError)
+			return
+		}
+	}
+	back.Result(c, nil, nil)
+}
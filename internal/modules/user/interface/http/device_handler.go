@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"OmniLink/internal/modules/user/application/dto/request"
+	"OmniLink/internal/modules/user/application/service"
+	"OmniLink/pkg/back"
+	"OmniLink/pkg/xerr"
+	"OmniLink/pkg/zlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceHandler struct {
+	svc service.DeviceService
+}
+
+func NewDeviceHandler(svc service.DeviceService) *DeviceHandler {
+	return &DeviceHandler{svc: svc}
+}
+
+// GetDeviceList 查询当前用户的登录设备
+func (h *DeviceHandler) GetDeviceList(c *gin.Context) {
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+	data, err := h.svc.ListDevices(uuid, c.GetString("device_id"))
+	back.Result(c, data, err)
+}
+
+// LogoutDevice 退出指定设备（默认当前设备）的登录，吊销其令牌并断开 WS
+func (h *DeviceHandler) LogoutDevice(c *gin.Context) {
+	var req request.LogoutDeviceRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+	err := h.svc.Logout(uuid, c.GetString("device_id"), req.DeviceId)
+	back.Result(c, nil, err)
+}
+
+// KickDevice 将自己的其他设备踢下线
+func (h *DeviceHandler) KickDevice(c *gin.Context) {
+	var req request.KickDeviceRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	uuid := c.GetString("uuid")
+	if uuid == "" {
+		back.Error(c, xerr.Unauthorized, "未登录")
+		return
+	}
+	err := h.svc.Kick(uuid, c.GetString("device_id"), req.DeviceId)
+	back.Result(c, nil, err)
+}
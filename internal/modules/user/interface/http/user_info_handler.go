@@ -29,6 +29,7 @@ func (h *UserInfoHandler) Login(c *gin.Context) {
 		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
 		return
 	}
+	loginReq.Ip = c.ClientIP()
 	data, err := h.svc.Login(loginReq)
 	back.Result(c, data, err)
 }
@@ -41,10 +42,23 @@ func (h *UserInfoHandler) Register(c *gin.Context) {
 		return
 	}
 	fmt.Println(registerReq)
+	registerReq.Ip = c.ClientIP()
 	data, err := h.svc.Register(registerReq)
 	back.Result(c, data, err)
 }
 
+// ChangePassword 修改密码，成功后其他设备需重新登录
+func (h *UserInfoHandler) ChangePassword(c *gin.Context) {
+	var req request.ChangePasswordRequest
+	if err := c.BindJSON(&req); err != nil {
+		zlog.Error(err.Error())
+		back.Error(c, xerr.BadRequest, xerr.ErrParam.Message)
+		return
+	}
+	err := h.svc.ChangePassword(c.GetString("uuid"), c.GetString("device_id"), req)
+	back.Result(c, nil, err)
+}
+
 func (h *UserInfoHandler) GetUserInfoInternal(c *gin.Context) {
 	var req request.InternalUserInfoRequest
 	if err := c.BindJSON(&req); err != nil {
@@ -0,0 +1,16 @@
+package ratelimit
+
+import (
+	"strconv"
+
+	"OmniLink/pkg/back"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reject 以统一格式拒绝被限流的请求：业务码 429，Retry-After 头给出建议等待的秒数
+func Reject(c *gin.Context, res Result) {
+	c.Header("Retry-After", strconv.Itoa(res.RetryAfterSeconds()))
+	back.Result(c, nil, res.Err())
+	c.Abort()
+}
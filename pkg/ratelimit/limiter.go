@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"OmniLink/pkg/redis"
+	"OmniLink/pkg/zlog"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix = "omnilink:ratelimit:" // Hash：令牌桶 {tokens, ts}
+
+	redisDeadline = 200 * time.Millisecond
+	// memorySweepEvery 内存桶每处理多少次请求清理一次过期桶
+	memorySweepEvery = 1024
+)
+
+// Rule 令牌桶规则：每 Window 补充 Limit 个令牌，桶容量为 Burst（<=0 时等于 Limit）
+type Rule struct {
+	Limit  int
+	Window time.Duration
+	Burst  int
+}
+
+func (r Rule) capacity() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return float64(r.Limit)
+}
+
+// ratePerMs 每毫秒补充的令牌数
+func (r Rule) ratePerMs() float64 {
+	w := r.Window
+	if w <= 0 {
+		w = time.Second
+	}
+	return float64(r.Limit) / float64(w.Milliseconds())
+}
+
+// Result 限流结果，RetryAfter 为下一个令牌可用前需等待的时间
+type Result struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// RetryAfterSeconds 向上取整的等待秒数，用于 Retry-After 头，至少为 1
+func (r Result) RetryAfterSeconds() int {
+	s := int(math.Ceil(r.RetryAfter.Seconds()))
+	if s < 1 {
+		s = 1
+	}
+	return s
+}
+
+// tokenBucketScript 原子地补充并扣减令牌，返回 {是否放行, 需等待毫秒数}
+var tokenBucketScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+  tokens = capacity
+  ts = now
+end
+if now > ts then
+  tokens = math.min(capacity, tokens + (now - ts) * rate)
+  ts = now
+end
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity / rate) + 1000)
+return {allowed, wait}
+`)
+
+// Allow 对 key 消耗一个令牌。Redis 可用时多节点共享计数，否则退化为单节点内存计数；
+// Redis 出错时放行，限流故障不应影响正常业务
+func Allow(key string, rule Rule) Result {
+	if rule.Limit <= 0 {
+		return Result{Allowed: true}
+	}
+	now := time.Now()
+	if redis.IsConnected() {
+		ctx, cancel := context.WithTimeout(context.Background(), redisDeadline)
+		defer cancel()
+		res, err := tokenBucketScript.Run(ctx, redis.GetClient(), []string{keyPrefix + key},
+			strconv.FormatFloat(rule.ratePerMs(), 'g', -1, 64),
+			strconv.FormatFloat(rule.capacity(), 'g', -1, 64),
+			now.UnixMilli(),
+		).Int64Slice()
+		if err != nil || len(res) != 2 {
+			if err != nil {
+				zlog.Warn("ratelimit redis failed: " + err.Error())
+			}
+			return Result{Allowed: true}
+		}
+		return Result{Allowed: res[0] == 1, RetryAfter: time.Duration(res[1]) * time.Millisecond}
+	}
+	return memory.allow(key, rule, now)
+}
+
+type bucket struct {
+	tokens float64
+	ts     time.Time
+	idle   time.Time // 桶补满的时间，之后可以清理
+}
+
+// memoryLimiter Redis 未连接时的单机令牌桶
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+var memory = &memoryLimiter{buckets: make(map[string]*bucket)}
+
+func (m *memoryLimiter) allow(key string, rule Rule, now time.Time) Result {
+	rate, capacity := rule.ratePerMs(), rule.capacity()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.calls%memorySweepEvery == 0 {
+		for k, b := range m.buckets {
+			if now.After(b.idle) {
+				delete(m.buckets, k)
+			}
+		}
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, ts: now}
+		m.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.ts).Milliseconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)*rate)
+		b.ts = now
+	}
+
+	res := Result{Allowed: true}
+	if b.tokens >= 1 {
+		b.tokens--
+	} else {
+		res = Result{RetryAfter: time.Duration(math.Ceil((1-b.tokens)/rate)) * time.Millisecond}
+	}
+	b.idle = now.Add(time.Duration((capacity-b.tokens)/rate) * time.Millisecond)
+	return res
+}
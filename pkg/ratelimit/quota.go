@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"OmniLink/internal/config"
+	"OmniLink/pkg/xerr"
+)
+
+// 限流维度，与配置中的 routes / wsFrames / actions 对应
+const (
+	ScopeRoute   = "route"
+	ScopeWsFrame = "ws"
+	ScopeAction  = "action"
+)
+
+// Check 按配置对 subject（用户 uuid 或 IP）在 scope 下的 name 限流，未配置规则或未开启限流时放行
+func Check(scope string, name string, subject string) Result {
+	conf := config.GetConfig().RateLimitConfig
+	if !conf.Enabled {
+		return Result{Allowed: true}
+	}
+	var rules map[string]config.RateLimitRule
+	switch scope {
+	case ScopeRoute:
+		rules = conf.Routes
+	case ScopeWsFrame:
+		rules = conf.WsFrames
+	case ScopeAction:
+		rules = conf.Actions
+	}
+	r, ok := rules[name]
+	if !ok || r.Limit <= 0 {
+		return Result{Allowed: true}
+	}
+	return Allow(scope+":"+name+":"+subject, Rule{
+		Limit:  r.Limit,
+		Window: time.Duration(r.WindowSeconds) * time.Second,
+		Burst:  r.Burst,
+	})
+}
+
+// Error 被限流时返回的统一错误，提示需等待的秒数
+func (r Result) Err() error {
+	return xerr.New(xerr.TooManyRequests, fmt.Sprintf("操作过于频繁，请 %d 秒后再试", r.RetryAfterSeconds()))
+}
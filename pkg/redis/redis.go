@@ -395,3 +395,21 @@ func TxPipeline() redis.Pipeliner {
 	}
 	return client.TxPipeline()
 }
+
+// ==================== 发布订阅 ====================
+
+// Publish 向频道发布消息
+func Publish(ctx context.Context, channel string, message interface{}) (int64, error) {
+	if err := checkClient(); err != nil {
+		return 0, err
+	}
+	return client.Publish(ctx, channel, message).Result()
+}
+
+// Subscribe 订阅频道，调用方负责 Close
+func Subscribe(ctx context.Context, channels ...string) (*redis.PubSub, error) {
+	if err := checkClient(); err != nil {
+		return nil, err
+	}
+	return client.Subscribe(ctx, channels...), nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStorage 本地磁盘存储。下载地址指向服务自身的下载接口，
+// 通过 HMAC 签名 + 过期时间防止越权访问
+type LocalStorage struct {
+	root        string
+	downloadURL string
+	secret      []byte
+}
+
+// NewLocalStorage root 为存储根目录；downloadURL 为下载接口的完整地址（如 http://host/file/download）
+func NewLocalStorage(root string, downloadURL string, secret string) (*LocalStorage, error) {
+	if root == "" {
+		return nil, errors.New("storage: local root 不能为空")
+	}
+	if secret == "" {
+		return nil, errors.New("storage: local sign secret 不能为空")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root: root, downloadURL: downloadURL, secret: []byte(secret)}, nil
+}
+
+// path 将 key 映射为磁盘路径，拒绝跳出根目录的 key
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(strings.TrimPrefix(clean, "/"))), nil
+}
+
+func (s *LocalStorage) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	// 先写临时文件再改名，避免读到写了一半的对象
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *LocalStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) Exists(_ context.Context, key string) (bool, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s *LocalStorage) SignedURL(_ context.Context, key string, expires time.Duration, fileName string) (string, error) {
+	exp := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	q := url.Values{}
+	q.Set("key", key)
+	q.Set("exp", exp)
+	if fileName != "" {
+		q.Set("name", fileName)
+	}
+	q.Set("sig", s.sign(key, exp, fileName))
+	return s.downloadURL + "?" + q.Encode(), nil
+}
+
+// Verify 校验下载地址的签名与有效期
+func (s *LocalStorage) Verify(key string, exp string, fileName string, sig string) bool {
+	ts, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > ts {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(s.sign(key, exp, fileName)))
+}
+
+func (s *LocalStorage) sign(key string, exp string, fileName string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + exp + "\n" + fileName))
+	return hex.EncodeToString(mac.Sum(nil))
+}
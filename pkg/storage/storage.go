@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage 对象存储抽象，上传子系统只依赖该接口
+type Storage interface {
+	// Put 写入对象，size 未知时传 -1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责 Close
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// SignedURL 生成限时下载地址，fileName 用于下载时的文件名
+	SignedURL(ctx context.Context, key string, expires time.Duration, fileName string) (string, error)
+}
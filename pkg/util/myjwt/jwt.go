@@ -2,6 +2,7 @@ package myjwt
 
 import (
 	"OmniLink/internal/config"
+	"OmniLink/pkg/util"
 	"errors"
 	"time"
 
@@ -11,19 +12,25 @@ import (
 type CustomClaims struct {
 	Uuid     string `json:"uuid"`
 	Username string `json:"username"`
+	DeviceId string `json:"device_id,omitempty"` // 登录设备，旧令牌为空
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(uuid string, username string) (string, error) {
+// GenerateToken 签发令牌，返回的 claims 中 ID（jti）用于吊销，ExpiresAt 为过期时间
+func GenerateToken(uuid string, username string, deviceID string) (string, *CustomClaims, error) {
 	conf := config.GetConfig()
 	key := conf.JwtConfig.Key
 	if key == "" {
-		return "", errors.New("jwt key is empty")
+		return "", nil, errors.New("jwt key is empty")
 	}
 
-	expireHours := conf.JwtConfig.ExpireHours
-	if expireHours <= 0 {
-		expireHours = 24
+	ttl := time.Duration(conf.JwtConfig.AccessExpireMinutes) * time.Minute
+	if ttl <= 0 {
+		expireHours := conf.JwtConfig.ExpireHours
+		if expireHours <= 0 {
+			expireHours = 24
+		}
+		ttl = time.Duration(expireHours) * time.Hour
 	}
 
 	issuer := conf.JwtConfig.Issuer
@@ -34,16 +41,21 @@ func GenerateToken(uuid string, username string) (string, error) {
 	claims := CustomClaims{
 		Uuid:     uuid,
 		Username: username,
+		DeviceId: deviceID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expireHours) * time.Hour)),
+			ID:        util.GenerateShortUUID(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    issuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(key))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &claims, nil
 }
 
 func ParseToken(tokenString string) (*CustomClaims, error) {
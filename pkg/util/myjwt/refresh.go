@@ -0,0 +1,34 @@
+package myjwt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"OmniLink/internal/config"
+	"OmniLink/pkg/util"
+)
+
+// refreshTokenLen 刷新令牌随机部分长度（base62，约 256 bit）
+const refreshTokenLen = 43
+
+// NewRefreshToken 生成不透明的刷新令牌，返回明文（只下发给客户端）与落库用的摘要
+func NewRefreshToken() (string, string) {
+	token := util.GenerateIDWithLen("R", refreshTokenLen)
+	return token, HashRefreshToken(token)
+}
+
+// HashRefreshToken 刷新令牌摘要，服务端只保存摘要
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RefreshTTL 刷新令牌有效期
+func RefreshTTL() time.Duration {
+	hours := config.GetConfig().JwtConfig.RefreshExpireHours
+	if hours <= 0 {
+		hours = 720
+	}
+	return time.Duration(hours) * time.Hour
+}
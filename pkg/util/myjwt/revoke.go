@@ -0,0 +1,80 @@
+package myjwt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"OmniLink/pkg/redis"
+	"OmniLink/pkg/zlog"
+)
+
+// revokedKeyPrefix String：已吊销令牌的 jti，过期时间与令牌一致
+const revokedKeyPrefix = "omnilink:jwt:revoked:"
+
+const revokeDeadline = 3 * time.Second
+
+// memoryRevoked Redis 未连接时的单机黑名单：jti -> 令牌过期时间
+var memoryRevoked = struct {
+	sync.Mutex
+	ids map[string]time.Time
+}{ids: make(map[string]time.Time)}
+
+// Revoke 吊销令牌，直到其自然过期前都会被 IsRevoked 拦截
+func Revoke(tokenID string, expiresAt time.Time) error {
+	if tokenID == "" {
+		return nil
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if redis.IsConnected() {
+		ctx, cancel := context.WithTimeout(context.Background(), revokeDeadline)
+		defer cancel()
+		return redis.Set(ctx, revokedKeyPrefix+tokenID, 1, ttl)
+	}
+
+	now := time.Now()
+	memoryRevoked.Lock()
+	defer memoryRevoked.Unlock()
+	for id, exp := range memoryRevoked.ids {
+		if exp.Before(now) {
+			delete(memoryRevoked.ids, id)
+		}
+	}
+	memoryRevoked.ids[tokenID] = expiresAt
+	return nil
+}
+
+// IsRevoked 令牌是否已被吊销；Redis 查询失败时放行，避免 Redis 抖动导致全员掉线
+func IsRevoked(tokenID string) bool {
+	if tokenID == "" {
+		return false
+	}
+
+	if redis.IsConnected() {
+		ctx, cancel := context.WithTimeout(context.Background(), revokeDeadline)
+		defer cancel()
+		n, err := redis.Exists(ctx, revokedKeyPrefix+tokenID)
+		if err != nil {
+			zlog.Error("jwt revoke check failed: " + err.Error())
+			return false
+		}
+		return n > 0
+	}
+
+	memoryRevoked.Lock()
+	defer memoryRevoked.Unlock()
+	exp, ok := memoryRevoked.ids[tokenID]
+	return ok && exp.After(time.Now())
+}
+
+// ExpiresAtTime 令牌过期时间，未设置时返回零值
+func (c *CustomClaims) ExpiresAtTime() time.Time {
+	if c == nil || c.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return c.ExpiresAt.Time
+}
@@ -0,0 +1,119 @@
+package myjwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"OmniLink/pkg/redis"
+	"OmniLink/pkg/util"
+
+	"github.com/golang-jwt/jwt/v5"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// wsTicketKeyPrefix String：一次性 WS 连接票据 -> 签发时令牌的 claims
+const wsTicketKeyPrefix = "omnilink:ws:ticket:"
+
+// ErrTicketInvalid 票据不存在、已使用或已过期
+var ErrTicketInvalid = errors.New("ws ticket invalid")
+
+// memoryTickets Redis 未连接时的单机票据存储
+var memoryTickets = struct {
+	sync.Mutex
+	items map[string]ticketEntry
+}{items: make(map[string]ticketEntry)}
+
+type ticketEntry struct {
+	claims    CustomClaims
+	expiresAt time.Time
+}
+
+// ticketClaims 票据中保存的令牌信息，连接建立后按原令牌的过期时间续期校验
+type ticketClaims struct {
+	Uuid      string `json:"uuid"`
+	Username  string `json:"username"`
+	DeviceId  string `json:"device_id,omitempty"`
+	TokenId   string `json:"jti,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// IssueTicket 为已认证的令牌签发一次性 WS 票据。
+// 浏览器原生 WebSocket 无法设置请求头，用短期票据代替把长期令牌放进 URL
+func IssueTicket(claims *CustomClaims, ttl time.Duration) (string, error) {
+	if claims == nil {
+		return "", errors.New("claims is nil")
+	}
+	ticket := util.GenerateIDWithLen("T", 32)
+
+	if redis.IsConnected() {
+		body, err := json.Marshal(ticketClaims{
+			Uuid:      claims.Uuid,
+			Username:  claims.Username,
+			DeviceId:  claims.DeviceId,
+			TokenId:   claims.ID,
+			ExpiresAt: claims.ExpiresAtTime().Unix(),
+		})
+		if err != nil {
+			return "", err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), revokeDeadline)
+		defer cancel()
+		if err := redis.Set(ctx, wsTicketKeyPrefix+ticket, body, ttl); err != nil {
+			return "", err
+		}
+		return ticket, nil
+	}
+
+	now := time.Now()
+	memoryTickets.Lock()
+	defer memoryTickets.Unlock()
+	for k, e := range memoryTickets.items {
+		if e.expiresAt.Before(now) {
+			delete(memoryTickets.items, k)
+		}
+	}
+	memoryTickets.items[ticket] = ticketEntry{claims: *claims, expiresAt: now.Add(ttl)}
+	return ticket, nil
+}
+
+// ConsumeTicket 校验并作废票据，返回签发票据时的令牌 claims
+func ConsumeTicket(ticket string) (*CustomClaims, error) {
+	if ticket == "" {
+		return nil, ErrTicketInvalid
+	}
+
+	if redis.IsConnected() {
+		ctx, cancel := context.WithTimeout(context.Background(), revokeDeadline)
+		defer cancel()
+		body, err := redis.GetClient().GetDel(ctx, wsTicketKeyPrefix+ticket).Bytes()
+		if err != nil {
+			if errors.Is(err, goredis.Nil) {
+				return nil, ErrTicketInvalid
+			}
+			return nil, err
+		}
+		var tc ticketClaims
+		if err := json.Unmarshal(body, &tc); err != nil {
+			return nil, err
+		}
+		claims := &CustomClaims{Uuid: tc.Uuid, Username: tc.Username, DeviceId: tc.DeviceId}
+		claims.ID = tc.TokenId
+		if tc.ExpiresAt > 0 {
+			claims.ExpiresAt = jwt.NewNumericDate(time.Unix(tc.ExpiresAt, 0))
+		}
+		return claims, nil
+	}
+
+	memoryTickets.Lock()
+	defer memoryTickets.Unlock()
+	e, ok := memoryTickets.items[ticket]
+	delete(memoryTickets.items, ticket)
+	if !ok || e.expiresAt.Before(time.Now()) {
+		return nil, ErrTicketInvalid
+	}
+	claims := e.claims
+	return &claims, nil
+}
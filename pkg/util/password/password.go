@@ -0,0 +1,42 @@
+package password
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cost bcrypt 计算强度，调高后旧哈希会在下次登录时自动重算
+const cost = bcrypt.DefaultCost
+
+// MaxLength bcrypt 只取前 72 字节，超出部分不参与校验，因此限制最大长度
+const MaxLength = 64
+
+// Hash 计算密码哈希
+func Hash(plain string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(plain), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// IsHashed 库中的值是否已是 bcrypt 哈希；否则为迁移前的明文
+func IsHashed(stored string) bool {
+	return len(stored) == 60 && (strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$"))
+}
+
+// Verify 校验密码。needRehash 为 true 表示校验通过但库中仍是明文或哈希强度过低，调用方应重新哈希后写回
+func Verify(stored string, plain string) (ok bool, needRehash bool) {
+	if !IsHashed(stored) {
+		// 旧数据为明文，使用常量时间比较避免时序侧信道
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(plain)) == 1
+		return ok, ok
+	}
+	if bcrypt.CompareHashAndPassword([]byte(stored), []byte(plain)) != nil {
+		return false, false
+	}
+	c, err := bcrypt.Cost([]byte(stored))
+	return true, err == nil && c < cost
+}
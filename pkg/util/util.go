@@ -35,6 +35,15 @@ func GenerateApplyID() string {
 	return GenerateID("A")
 }
 
+func GenerateDeviceID() string {
+	return GenerateID("D")
+}
+
+// GenerateInviteToken 群邀请令牌，会出现在分享链接中，使用更长的随机串防止被枚举
+func GenerateInviteToken() string {
+	return GenerateIDWithLen("I", 21)
+}
+
 func GenerateID(prefix string) string {
 	return GenerateIDWithLen(prefix, 11)
 }
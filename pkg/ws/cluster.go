@@ -0,0 +1,291 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"OmniLink/pkg/redis"
+	"OmniLink/pkg/zlog"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	clusterPresenceKeyPrefix = "omnilink:ws:presence:" // Set：用户当前在线的节点ID，各节点心跳时续期
+	clusterNodeKeyPrefix     = "omnilink:ws:node:"     // String：节点存活心跳
+	clusterChannelPrefix     = "omnilink:ws:route:"    // 每个节点一个投递频道
+
+	clusterNodeTTL       = 30 * time.Second
+	clusterPresenceTTL   = 30 * time.Second
+	clusterHeartbeat     = 10 * time.Second
+	clusterRedisDeadline = 3 * time.Second
+)
+
+// clusterEnvelope 跨节点投递的消息体
+type clusterEnvelope struct {
+	UserID   string `json:"u"`
+	DeviceID string `json:"d,omitempty"` // 非空时只投递该设备
+	Payload  []byte `json:"p,omitempty"`
+	Reliable bool   `json:"r,omitempty"`
+	Kick     string `json:"k,omitempty"` // 非空时表示踢下线指令，值为原因
+}
+
+// cluster 基于 Redis 的跨节点路由：
+// - presence：记录用户连接在哪些节点上
+// - pub/sub：每个节点订阅自己的频道，其他节点把目标用户的消息发布到该频道
+type cluster struct {
+	hub    *Hub
+	nodeID string
+	cancel context.CancelFunc
+	// locks 按用户分片串行化 presence 写入，见 sync
+	locks [64]sync.Mutex
+}
+
+// EnableCluster 开启分布式模式。开启后 Send/SendJSON 会先投递本地连接，
+// 再通过 Redis 把消息转发给该用户所在的其他节点；未开启时行为与单机一致
+func (h *Hub) EnableCluster(nodeID string) error {
+	if nodeID == "" {
+		return fmt.Errorf("ws cluster: nodeID 不能为空")
+	}
+	if !redis.IsConnected() {
+		return fmt.Errorf("ws cluster: Redis 未连接")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := redis.Subscribe(ctx, clusterChannelPrefix+nodeID)
+	if err != nil {
+		cancel()
+		return err
+	}
+	// 确认订阅成功后再对外宣告节点存活，避免路由到尚未就绪的节点
+	if _, err := sub.Receive(ctx); err != nil {
+		cancel()
+		_ = sub.Close()
+		return err
+	}
+
+	c := &cluster{hub: h, nodeID: nodeID, cancel: cancel}
+	if err := c.heartbeat(ctx); err != nil {
+		cancel()
+		_ = sub.Close()
+		return err
+	}
+
+	h.mu.Lock()
+	h.cluster = c
+	gens := make(map[string]uint64, len(h.clients))
+	for uid := range h.clients {
+		gens[uid] = h.presenceGen[uid]
+	}
+	h.mu.Unlock()
+	for uid, gen := range gens {
+		c.sync(uid, gen)
+	}
+
+	go c.consume(ctx, sub)
+	go c.keepAlive(ctx)
+	zlog.Info(fmt.Sprintf("ws cluster enabled, node=%s", nodeID))
+	return nil
+}
+
+// DisableCluster 关闭分布式模式并注销本节点
+func (h *Hub) DisableCluster() {
+	h.mu.Lock()
+	c := h.cluster
+	h.cluster = nil
+	users := make([]string, 0, len(h.clients))
+	for uid := range h.clients {
+		users = append(users, uid)
+	}
+	h.mu.Unlock()
+	if c == nil {
+		return
+	}
+
+	c.cancel()
+	for _, uid := range users {
+		c.offline(uid)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clusterRedisDeadline)
+	defer cancel()
+	_, _ = redis.Del(ctx, clusterNodeKeyPrefix+c.nodeID)
+}
+
+// NodeID 返回当前节点ID，单机模式下为空
+func (h *Hub) NodeID() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.cluster == nil {
+		return ""
+	}
+	return h.cluster.nodeID
+}
+
+func (c *cluster) heartbeat(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, clusterRedisDeadline)
+	defer cancel()
+	return redis.Set(ctx, clusterNodeKeyPrefix+c.nodeID, time.Now().Unix(), clusterNodeTTL)
+}
+
+func (c *cluster) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(clusterHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.heartbeat(ctx); err != nil && ctx.Err() == nil {
+				zlog.Error("ws cluster heartbeat failed: " + err.Error())
+			}
+			if err := c.refreshPresence(ctx); err != nil && ctx.Err() == nil {
+				zlog.Error("ws cluster presence refresh failed: " + err.Error())
+			}
+		}
+	}
+}
+
+func (c *cluster) consume(ctx context.Context, sub *goredis.PubSub) {
+	defer func() { _ = sub.Close() }()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var env clusterEnvelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				zlog.Error("ws cluster decode failed: " + err.Error())
+				continue
+			}
+			if env.Kick != "" {
+				c.hub.kickLocal(env.UserID, env.DeviceID, env.Kick)
+				continue
+			}
+			c.hub.sendLocal(env.UserID, env.DeviceID, env.Payload, env.Reliable)
+		}
+	}
+}
+
+// refreshPresence 重新写入本节点在线用户的 presence 并续期；所有节点都不再续期的用户记录会自动过期，
+// Redis 故障期间丢失的记录也会在这里补回
+func (c *cluster) refreshPresence(ctx context.Context) error {
+	c.hub.mu.RLock()
+	users := make([]string, 0, len(c.hub.clients))
+	for uid := range c.hub.clients {
+		users = append(users, uid)
+	}
+	c.hub.mu.RUnlock()
+	if len(users) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterRedisDeadline)
+	defer cancel()
+	_, err := redis.GetClient().Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, uid := range users {
+			pipe.SAdd(ctx, clusterPresenceKeyPrefix+uid, c.nodeID)
+			pipe.Expire(ctx, clusterPresenceKeyPrefix+uid, clusterPresenceTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// sync 把用户在本节点的上线 / 下线变更写入 presence。
+// Register/Unregister 在释放 Hub 锁后才调用，同一用户的变更可能乱序到达：
+// 按用户串行执行，且只处理序号仍是最新的那次变更，过期的变更交由更新的那次写入
+func (c *cluster) sync(userID string, gen uint64) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(userID))
+	lock := &c.locks[h.Sum32()%uint32(len(c.locks))]
+	lock.Lock()
+	defer lock.Unlock()
+
+	c.hub.mu.RLock()
+	cur, ok := c.hub.presenceGen[userID]
+	_, online := c.hub.clients[userID]
+	c.hub.mu.RUnlock()
+	if !ok || cur != gen {
+		return
+	}
+	if online {
+		c.online(userID)
+		return
+	}
+	c.offline(userID)
+	c.hub.mu.Lock()
+	if c.hub.presenceGen[userID] == gen && c.hub.clients[userID] == nil {
+		delete(c.hub.presenceGen, userID)
+	}
+	c.hub.mu.Unlock()
+}
+
+// online 用户在本节点建立了首个连接
+func (c *cluster) online(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterRedisDeadline)
+	defer cancel()
+	// 宕机节点残留的记录在 route 时按心跳判活清理，整个 key 在无人续期后过期
+	if _, err := redis.SAdd(ctx, clusterPresenceKeyPrefix+userID, c.nodeID); err != nil {
+		zlog.Error("ws cluster presence add failed: " + err.Error())
+		return
+	}
+	if _, err := redis.Expire(ctx, clusterPresenceKeyPrefix+userID, clusterPresenceTTL); err != nil {
+		zlog.Error("ws cluster presence expire failed: " + err.Error())
+	}
+}
+
+// offline 用户在本节点的最后一个连接断开
+func (c *cluster) offline(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterRedisDeadline)
+	defer cancel()
+	if _, err := redis.SRem(ctx, clusterPresenceKeyPrefix+userID, c.nodeID); err != nil {
+		zlog.Error("ws cluster presence remove failed: " + err.Error())
+	}
+}
+
+// route 把消息（或踢下线指令）转发给用户所在的其他节点，返回成功发布的节点数
+func (c *cluster) route(env clusterEnvelope) int {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterRedisDeadline)
+	defer cancel()
+
+	key := clusterPresenceKeyPrefix + env.UserID
+	nodes, err := redis.SMembers(ctx, key)
+	if err != nil {
+		zlog.Error("ws cluster presence lookup failed: " + err.Error())
+		return 0
+	}
+
+	var body []byte
+	routed := 0
+	for _, node := range nodes {
+		if node == c.nodeID {
+			continue
+		}
+		// 节点心跳过期说明已宕机，顺手清理残留的 presence
+		if n, err := redis.Exists(ctx, clusterNodeKeyPrefix+node); err == nil && n == 0 {
+			_, _ = redis.SRem(ctx, key, node)
+			continue
+		}
+		if body == nil {
+			body, err = json.Marshal(env)
+			if err != nil {
+				zlog.Error(err.Error())
+				return routed
+			}
+		}
+		if _, err := redis.Publish(ctx, clusterChannelPrefix+node, body); err != nil {
+			zlog.Error("ws cluster publish failed: " + err.Error())
+			continue
+		}
+		routed++
+	}
+	return routed
+}
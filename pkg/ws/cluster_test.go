@@ -0,0 +1,131 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"OmniLink/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func newClusterHub(t *testing.T, nodeID string) (*Hub, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	redis.SetClient(client)
+	t.Cleanup(func() {
+		redis.SetClient(nil)
+		_ = client.Close()
+	})
+
+	h := NewHub()
+	if err := h.EnableCluster(nodeID); err != nil {
+		t.Fatalf("enable cluster: %v", err)
+	}
+	t.Cleanup(func() {
+		h.mu.Lock()
+		c := h.cluster
+		h.cluster = nil
+		h.mu.Unlock()
+		if c != nil {
+			c.cancel()
+		}
+	})
+	return h, mr
+}
+
+func presenceNodes(t *testing.T, mr *miniredis.Miniredis, userID string) []string {
+	t.Helper()
+	key := clusterPresenceKeyPrefix + userID
+	if !mr.Exists(key) {
+		return nil
+	}
+	nodes, err := mr.Members(key)
+	if err != nil {
+		t.Fatalf("read presence: %v", err)
+	}
+	return nodes
+}
+
+// 同一用户的连接并发建立、断开，最终 presence 与本节点的实际连接一致
+func TestClusterPresenceConcurrentRegister(t *testing.T) {
+	h, mr := newClusterHub(t, "node-a")
+
+	for round := 0; round < 20; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c := NewClient("u1", "", false, nil)
+				h.Register(c)
+				h.Unregister(c)
+			}()
+		}
+		wg.Wait()
+		if nodes := presenceNodes(t, mr, "u1"); len(nodes) != 0 {
+			t.Fatalf("round %d: all connections closed but presence = %v", round, nodes)
+		}
+	}
+
+	keep := NewClient("u1", "", false, nil)
+	var wg sync.WaitGroup
+	h.Register(keep)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := NewClient("u1", "", false, nil)
+			h.Register(c)
+			h.Unregister(c)
+		}()
+	}
+	wg.Wait()
+	if nodes := presenceNodes(t, mr, "u1"); len(nodes) != 1 || nodes[0] != "node-a" {
+		t.Fatalf("one connection still open, presence = %v", nodes)
+	}
+
+	h.Unregister(keep)
+	if nodes := presenceNodes(t, mr, "u1"); len(nodes) != 0 {
+		t.Fatalf("last connection closed, presence = %v", nodes)
+	}
+	h.mu.RLock()
+	_, leaked := h.presenceGen["u1"]
+	h.mu.RUnlock()
+	if leaked {
+		t.Fatalf("presence generation not released after going offline")
+	}
+}
+
+// 没有节点续期时 presence 过期；续期会补回丢失的记录
+func TestClusterPresenceExpiresWithoutRefresh(t *testing.T) {
+	h, mr := newClusterHub(t, "node-a")
+
+	c := NewClient("u2", "", false, nil)
+	h.Register(c)
+	key := clusterPresenceKeyPrefix + "u2"
+	if ttl := mr.TTL(key); ttl <= 0 || ttl > clusterPresenceTTL {
+		t.Fatalf("presence ttl = %v, want (0, %v]", ttl, clusterPresenceTTL)
+	}
+
+	mr.FastForward(clusterPresenceTTL)
+	if mr.Exists(key) {
+		t.Fatalf("presence should expire when no node refreshes it")
+	}
+
+	h.mu.RLock()
+	cl := h.cluster
+	h.mu.RUnlock()
+	if err := cl.refreshPresence(context.Background()); err != nil {
+		t.Fatalf("refresh presence: %v", err)
+	}
+	if nodes := presenceNodes(t, mr, "u2"); len(nodes) != 1 || nodes[0] != "node-a" {
+		t.Fatalf("refresh should restore presence, got %v", nodes)
+	}
+	if ttl := mr.TTL(key); ttl <= 0 {
+		t.Fatalf("refreshed presence has no ttl")
+	}
+}
@@ -0,0 +1,252 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"OmniLink/pkg/util"
+	"OmniLink/pkg/zlog"
+)
+
+const (
+	// sendWait 发送缓冲满时的最长等待，超过即视为拥塞
+	sendWait = 200 * time.Millisecond
+	// slowConsumerTimeout 连接持续拥塞超过该时间则断开，待确认帧留待重连补发
+	slowConsumerTimeout = 30 * time.Second
+	// ackTimeout 帧发出后超过该时间未确认则重发
+	ackTimeout = 5 * time.Second
+	// retryInterval 重试扫描间隔
+	retryInterval = 5 * time.Second
+	// maxAttempts 同一连接上单帧最多发送次数，超过后等待重连再补发
+	maxAttempts = 3
+	// flushBatch 单次扫描最多处理的待确认帧数
+	flushBatch = 200
+
+	storeDeadline = 3 * time.Second
+)
+
+// reliableFrames 需要客户端确认的帧类型；ack/error/typing/presence/sync 等为尽力而为
+var reliableFrames = map[string]struct{}{
+	FrameMessage:      {},
+	FrameRecall:       {},
+	FrameEdit:         {},
+	FrameReaction:     {},
+	FrameRead:         {},
+	FrameNotification: {},
+}
+
+func isReliable(frameType string) bool {
+	_, ok := reliableFrames[frameType]
+	return ok
+}
+
+// DeliveryStats 单个用户在本节点上的投递统计，只在用户有本节点连接期间累计，最后一个连接断开后清零
+type DeliveryStats struct {
+	UserId      string `json:"user_id"`
+	Connections int    `json:"connections"` // 本节点当前连接数
+	Enqueued    int64  `json:"enqueued"`    // 进入待确认队列的可靠帧
+	Sent        int64  `json:"sent"`        // 写入连接发送缓冲的帧（含重发）
+	Acked       int64  `json:"acked"`       // 客户端已确认
+	Retried     int64  `json:"retried"`     // 超时未确认或重连后补发
+	Deferred    int64  `json:"deferred"`    // 可靠帧因缓冲满延后，由重试补发
+	Dropped     int64  `json:"dropped"`     // 非可靠帧因缓冲满丢弃 + 队列超限淘汰
+	Kicked      int64  `json:"kicked"`      // 因持续拥塞被断开的连接数
+	Pending     int64  `json:"pending"`     // 当前待确认帧数（所有设备）
+}
+
+type userCounters struct {
+	enqueued atomic.Int64
+	sent     atomic.Int64
+	acked    atomic.Int64
+	retried  atomic.Int64
+	deferred atomic.Int64
+	dropped  atomic.Int64
+	kicked   atomic.Int64
+}
+
+// deliveryStats 按用户计数，条目随用户在本节点的首个连接建立、最后一个连接断开而增删，不随发送过的用户无限增长
+type deliveryStats struct {
+	mu    sync.Mutex
+	users map[string]*userCounters
+}
+
+func newDeliveryStats() *deliveryStats {
+	return &deliveryStats{users: make(map[string]*userCounters)}
+}
+
+// track 用户在本节点建立首个连接时开始计数
+func (s *deliveryStats) track(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.users[userID] == nil {
+		s.users[userID] = &userCounters{}
+	}
+}
+
+// forget 用户在本节点的最后一个连接断开后丢弃计数
+func (s *deliveryStats) forget(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, userID)
+}
+
+// of 返回用户的计数；用户不在本节点时返回不保存的临时计数，写入即丢弃
+func (s *deliveryStats) of(userID string) *userCounters {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c := s.users[userID]; c != nil {
+		return c
+	}
+	return &userCounters{}
+}
+
+// SetPendingStore 替换待确认队列实现，多节点部署时应使用 NewRedisPendingStore
+func (h *Hub) SetPendingStore(store PendingStore) {
+	if store == nil {
+		return
+	}
+	h.mu.Lock()
+	h.pending = store
+	h.mu.Unlock()
+}
+
+func (h *Hub) pendingStore() PendingStore {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.pending
+}
+
+// sendReliable 先为用户所有设备入队，再尝试即时投递；未确认的由各连接的 deliveryLoop 重发
+func (h *Hub) sendReliable(userID string, deliveryID string, payload []byte) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), storeDeadline)
+	defer cancel()
+
+	dropped, err := h.pendingStore().Enqueue(ctx, userID, PendingFrame{
+		DeliveryId: deliveryID,
+		Payload:    payload,
+		EnqueuedAt: time.Now().UnixNano(),
+	})
+	if err != nil {
+		zlog.Error("ws pending enqueue failed: " + err.Error())
+	} else {
+		h.stats.of(userID).enqueued.Add(1)
+	}
+	if dropped > 0 {
+		h.stats.of(userID).dropped.Add(int64(dropped))
+		zlog.Warn(fmt.Sprintf("ws pending queue overflow, user=%s dropped=%d", userID, dropped))
+	}
+
+	return h.send(userID, payload, true)
+}
+
+// Ack 客户端确认已收到的帧
+func (h *Hub) Ack(userID string, deviceID string, deliveryIDs ...string) {
+	if userID == "" || deviceID == "" || len(deliveryIDs) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeDeadline)
+	defer cancel()
+	n, err := h.pendingStore().Ack(ctx, userID, deviceID, deliveryIDs...)
+	if err != nil {
+		zlog.Error("ws pending ack failed: " + err.Error())
+		return
+	}
+	h.stats.of(userID).acked.Add(int64(n))
+}
+
+// DeliveryStats 返回用户在本节点上的投递统计
+func (h *Hub) DeliveryStats(userID string) DeliveryStats {
+	c := h.stats.of(userID)
+	out := DeliveryStats{
+		UserId:   userID,
+		Enqueued: c.enqueued.Load(),
+		Sent:     c.sent.Load(),
+		Acked:    c.acked.Load(),
+		Retried:  c.retried.Load(),
+		Deferred: c.deferred.Load(),
+		Dropped:  c.dropped.Load(),
+		Kicked:   c.kicked.Load(),
+	}
+
+	h.mu.RLock()
+	out.Connections = len(h.clients[userID])
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeDeadline)
+	defer cancel()
+	if n, err := h.pendingStore().Count(ctx, userID); err == nil {
+		out.Pending = n
+	}
+	return out
+}
+
+// deliveryLoop 连接建立后先补发该设备的全部待确认帧，之后定期重发超时未确认的帧
+func (h *Hub) deliveryLoop(c *Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeDeadline)
+	if err := h.pendingStore().TouchDevice(ctx, c.userID, c.deviceID); err != nil {
+		zlog.Error("ws pending touch device failed: " + err.Error())
+	}
+	cancel()
+
+	attempts := make(map[string]int)
+	h.flushPending(c, attempts, 0)
+
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			h.flushPending(c, attempts, ackTimeout)
+		}
+	}
+}
+
+// flushPending 重发入队超过 minAge 仍未确认的帧；attempts 只在 deliveryLoop 协程内访问
+func (h *Hub) flushPending(c *Client, attempts map[string]int, minAge time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeDeadline)
+	frames, err := h.pendingStore().List(ctx, c.userID, c.deviceID, flushBatch)
+	cancel()
+	if err != nil {
+		zlog.Error("ws pending list failed: " + err.Error())
+		return
+	}
+
+	// 已确认的帧不会再出现在列表里，顺便清理计数
+	listed := make(map[string]struct{}, len(frames))
+	for _, f := range frames {
+		listed[f.DeliveryId] = struct{}{}
+	}
+	for id := range attempts {
+		if _, ok := listed[id]; !ok {
+			delete(attempts, id)
+		}
+	}
+
+	counters := h.stats.of(c.userID)
+	cutoff := time.Now().Add(-minAge).UnixNano()
+	for _, f := range frames {
+		if f.EnqueuedAt > cutoff {
+			break
+		}
+		if attempts[f.DeliveryId] >= maxAttempts {
+			continue
+		}
+		if !c.push(f.Payload, sendWait) {
+			counters.deferred.Add(1)
+			return
+		}
+		attempts[f.DeliveryId]++
+		counters.sent.Add(1)
+		counters.retried.Add(1)
+	}
+}
+
+// newDeliveryID 生成可靠帧的投递ID
+func newDeliveryID() string {
+	return util.GenerateIDWithLen("D", 19)
+}
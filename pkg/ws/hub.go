@@ -2,22 +2,48 @@ package ws
 
 import (
 	"encoding/json"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"OmniLink/pkg/util"
 	"OmniLink/pkg/zlog"
 
 	"github.com/gorilla/websocket"
 )
 
+// 踢下线原因，随 device.kicked 通知下发
+const (
+	KickReasonKicked   = "kicked"   // 被用户在其他设备上踢下线
+	KickReasonLogout   = "logout"   // 设备主动退出登录
+	KickReasonReplaced = "replaced" // 同平台新设备登录，旧设备被顶替
+	KickReasonRevoked  = "revoked"  // 检测到令牌被盗用，会话被强制吊销
+	KickReasonDisabled = "disabled" // 账号被管理员禁用或删除
+)
+
+// kickFlushDelay 踢下线时先下发通知，等待该时间后再断开连接
+const kickFlushDelay = time.Second
+
 type Hub struct {
 	mu      sync.RWMutex
 	clients map[string]map[*Client]struct{}
+	// cluster 非空时为分布式模式，见 EnableCluster
+	cluster *cluster
+	// presenceGen 用户最近一次上线 / 下线变更的序号，跨节点 presence 写入只接受最新一次变更，见 cluster.sync
+	presenceSeq uint64
+	presenceGen map[string]uint64
+	// pending 可靠帧的待确认队列，见 delivery.go
+	pending PendingStore
+	stats   *deliveryStats
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		clients: make(map[string]map[*Client]struct{}),
+		clients:     make(map[string]map[*Client]struct{}),
+		presenceGen: make(map[string]uint64),
+		pending:     NewMemoryPendingStore(),
+		stats:       newDeliveryStats(),
 	}
 }
 
@@ -26,13 +52,31 @@ func (h *Hub) Register(c *Client) {
 		return
 	}
 	h.mu.Lock()
-	defer h.mu.Unlock()
 	set := h.clients[c.userID]
-	if set == nil {
+	first := set == nil
+	if first {
 		set = make(map[*Client]struct{})
 		h.clients[c.userID] = set
 	}
 	set[c] = struct{}{}
+	var gen uint64
+	if first {
+		h.presenceSeq++
+		gen = h.presenceSeq
+		h.presenceGen[c.userID] = gen
+	}
+	cl := h.cluster
+	h.mu.Unlock()
+
+	if first {
+		h.stats.track(c.userID)
+	}
+	if first && cl != nil {
+		cl.sync(c.userID, gen)
+	}
+	if c.reliable {
+		go h.deliveryLoop(c)
+	}
 }
 
 func (h *Hub) Unregister(c *Client) {
@@ -41,43 +85,192 @@ func (h *Hub) Unregister(c *Client) {
 	}
 	h.mu.Lock()
 	set := h.clients[c.userID]
+	last := false
 	if set != nil {
-		delete(set, c)
-		if len(set) == 0 {
-			delete(h.clients, c.userID)
+		if _, ok := set[c]; ok {
+			delete(set, c)
+			if len(set) == 0 {
+				delete(h.clients, c.userID)
+				last = true
+			}
+		}
+	}
+	cl := h.cluster
+	var gen uint64
+	if last {
+		h.presenceSeq++
+		gen = h.presenceSeq
+		if cl != nil {
+			h.presenceGen[c.userID] = gen
+		} else {
+			delete(h.presenceGen, c.userID)
 		}
 	}
 	h.mu.Unlock()
 	c.Close()
+
+	if last {
+		h.stats.forget(c.userID)
+	}
+	if last && cl != nil {
+		cl.sync(c.userID, gen)
+	}
 }
 
+// Send 向用户的所有连接投递消息（尽力而为，不入待确认队列）；分布式模式下还会转发到该用户所在的其他节点
 func (h *Hub) Send(userID string, payload []byte) bool {
+	return h.send(userID, payload, false)
+}
+
+// SendToDevice 只向用户的指定设备投递（尽力而为），其余设备收不到
+func (h *Hub) SendToDevice(userID string, deviceID string, payload []byte) bool {
+	if deviceID == "" {
+		return false
+	}
+	return h.sendTo(userID, deviceID, payload, false)
+}
+
+func (h *Hub) send(userID string, payload []byte, reliable bool) bool {
+	return h.sendTo(userID, "", payload, reliable)
+}
+
+// sendTo deviceID 为空时投递用户的全部设备
+func (h *Hub) sendTo(userID string, deviceID string, payload []byte, reliable bool) bool {
 	if userID == "" || len(payload) == 0 {
 		return false
 	}
 
+	ok := h.sendLocal(userID, deviceID, payload, reliable)
+
 	h.mu.RLock()
-	set := h.clients[userID]
+	cl := h.cluster
 	h.mu.RUnlock()
+	if cl != nil && cl.route(clusterEnvelope{UserID: userID, DeviceID: deviceID, Payload: payload, Reliable: reliable}) > 0 {
+		ok = true
+	}
+	return ok
+}
+
+// localClients 返回用户在本节点上的连接，deviceID 非空时只返回该设备的连接
+func (h *Hub) localClients(userID string, deviceID string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	set := make([]*Client, 0, len(h.clients[userID]))
+	for c := range h.clients[userID] {
+		if deviceID != "" && c.deviceID != deviceID {
+			continue
+		}
+		set = append(set, c)
+	}
+	return set
+}
+
+// sendLocal 只投递本节点上的连接。
+// 群聊扇出与跨节点消费都在同一协程里逐个调用，因此不等待：发送缓冲满时可靠帧留在待确认队列，
+// 由该连接自己的 deliveryLoop 重试补发（背压只作用于该连接），非可靠帧计入 dropped；
+// 连接持续拥塞超过 slowConsumerTimeout 才断开
+func (h *Hub) sendLocal(userID string, deviceID string, payload []byte, reliable bool) bool {
+	if userID == "" || len(payload) == 0 {
+		return false
+	}
+
+	set := h.localClients(userID, deviceID)
 	if len(set) == 0 {
 		return false
 	}
 
 	ok := false
-	for c := range set {
+	for _, c := range set {
 		if c == nil {
 			continue
 		}
-		select {
-		case c.send <- payload:
+		if c.push(payload, 0) {
+			h.stats.of(userID).sent.Add(1)
+			c.congestedSince.Store(0)
 			ok = true
-		default:
+			continue
+		}
+
+		if reliable {
+			h.stats.of(userID).deferred.Add(1)
+		} else {
+			h.stats.of(userID).dropped.Add(1)
+		}
+		now := time.Now().UnixNano()
+		since := c.congestedSince.Load()
+		if since == 0 {
+			c.congestedSince.CompareAndSwap(0, now)
+		} else if time.Duration(now-since) > slowConsumerTimeout {
+			h.stats.of(userID).kicked.Add(1)
+			zlog.Warn(fmt.Sprintf("ws slow consumer disconnected, user=%s device=%s", userID, c.deviceID))
 			h.Unregister(c)
 		}
 	}
 	return ok
 }
 
+// LocalConnections 返回用户在本节点上的连接数
+func (h *Hub) LocalConnections(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID])
+}
+
+// KickDevice 断开用户指定设备的全部连接（含其他节点），断开前先下发 device.kicked 通知告知原因
+func (h *Hub) KickDevice(userID string, deviceID string, reason string) {
+	if userID == "" || deviceID == "" {
+		return
+	}
+	if reason == "" {
+		reason = KickReasonKicked
+	}
+	h.kickLocal(userID, deviceID, reason)
+
+	h.mu.RLock()
+	cl := h.cluster
+	h.mu.RUnlock()
+	if cl != nil {
+		cl.route(clusterEnvelope{UserID: userID, DeviceID: deviceID, Kick: reason})
+	}
+}
+
+func (h *Hub) kickLocal(userID string, deviceID string, reason string) {
+	set := h.localClients(userID, deviceID)
+	for _, c := range set {
+		h.CloseClient(c, NotificationDeviceKicked, map[string]string{"device_id": deviceID, "reason": reason})
+	}
+	if len(set) > 0 {
+		zlog.Info(fmt.Sprintf("ws device kicked, user=%s device=%s reason=%s", userID, deviceID, reason))
+	}
+}
+
+// SendFrameToClient 只向某一条连接推送一帧（不进待确认队列），用于只与该连接相关的回复
+func (h *Hub) SendFrameToClient(c *Client, frameType string, clientMsgID string, payload interface{}) bool {
+	if c == nil {
+		return false
+	}
+	env, err := NewEnvelope(frameType, clientMsgID, payload)
+	if err != nil {
+		zlog.Error(err.Error())
+		return false
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		zlog.Error(err.Error())
+		return false
+	}
+	return c.push(b, sendWait)
+}
+
+// CloseClient 先向连接下发通知说明原因，稍后再断开，留出时间让写协程把通知发出去
+func (h *Hub) CloseClient(c *Client, kind string, data interface{}) {
+	if c == nil {
+		return
+	}
+	h.SendFrameToClient(c, FrameNotification, "", NotificationPayload{Kind: kind, Data: data})
+	time.AfterFunc(kickFlushDelay, func() { h.Unregister(c) })
+}
+
 func (h *Hub) SendJSON(userID string, v interface{}) error {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -88,24 +281,107 @@ func (h *Hub) SendJSON(userID string, v interface{}) error {
 }
 
 type Client struct {
-	userID string
-	conn   *websocket.Conn
-	send   chan []byte
+	userID   string
+	deviceID string
+	// reliable 为 true 时启用待确认队列，客户端需对可靠帧回 ack
+	reliable bool
+	// expiresAt 连接所用令牌的过期时间（UnixNano），0 表示不限
+	expiresAt atomic.Int64
+	connID    string // 连接唯一标识，同一设备重连也会变化，在线状态按连接聚合
+	conn      *websocket.Conn
+	send      chan []byte
+	done      chan struct{}
+
+	// mu 保护 send 通道的关闭，避免向已关闭的通道写入
+	mu     sync.RWMutex
+	closed bool
+	// congestedSince 发送缓冲开始持续写满的时间（UnixNano），0 表示未拥塞
+	congestedSince atomic.Int64
 
 	closeOnce sync.Once
 }
 
-func NewClient(userID string, conn *websocket.Conn) *Client {
+// NewClient 创建连接。deviceID 用于按设备定向投递与踢下线；
+// reliable 为 false 时不启用待确认队列（兼容未实现 ack 的旧客户端）
+func NewClient(userID string, deviceID string, reliable bool, conn *websocket.Conn) *Client {
 	return &Client{
-		userID: userID,
-		conn:   conn,
-		send:   make(chan []byte, 64),
+		userID:   userID,
+		deviceID: deviceID,
+		reliable: reliable && deviceID != "",
+		connID:   util.GenerateID("W"),
+		conn:     conn,
+		send:     make(chan []byte, 64),
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *Client) UserID() string {
+	return c.userID
+}
+
+func (c *Client) DeviceID() string {
+	return c.deviceID
+}
+
+func (c *Client) ConnID() string {
+	return c.connID
+}
+
+// Done 连接关闭后返回的通道被关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// SetExpiresAt 更新连接所用令牌的过期时间，客户端续期后调用
+func (c *Client) SetExpiresAt(t time.Time) {
+	if t.IsZero() {
+		c.expiresAt.Store(0)
+		return
+	}
+	c.expiresAt.Store(t.UnixNano())
+}
+
+// ExpiresAt 连接所用令牌的过期时间，零值表示不限
+func (c *Client) ExpiresAt() time.Time {
+	n := c.expiresAt.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// push 写入发送缓冲，缓冲满时最多等待 wait
+func (c *Client) push(payload []byte, wait time.Duration) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- payload:
+		return true
+	default:
+	}
+	if wait <= 0 {
+		return false
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case c.send <- payload:
+		return true
+	case <-t.C:
+		return false
 	}
 }
 
 func (c *Client) Close() {
 	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closed = true
 		close(c.send)
+		close(c.done)
+		c.mu.Unlock()
 		if c.conn != nil {
 			_ = c.conn.Close()
 		}
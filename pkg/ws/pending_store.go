@@ -0,0 +1,140 @@
+package ws
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PendingFrame 待客户端确认的帧
+type PendingFrame struct {
+	DeliveryId string `json:"delivery_id"`
+	Payload    []byte `json:"payload"`
+	EnqueuedAt int64  `json:"enqueued_at"` // UnixNano，同时决定重放顺序
+}
+
+// PendingStore 按 (用户, 设备) 维护待确认队列。
+// 单机可用内存实现；多节点部署需使用 Redis 实现，保证重连到任意节点都能补发
+type PendingStore interface {
+	// TouchDevice 记录设备在线，之后发给该用户的可靠帧都会为此设备入队
+	TouchDevice(ctx context.Context, userID string, deviceID string) error
+	// Enqueue 为用户所有已知设备入队，返回因超出队列上限被淘汰的帧数
+	Enqueue(ctx context.Context, userID string, frame PendingFrame) (int, error)
+	// Ack 确认帧，返回实际移除的数量
+	Ack(ctx context.Context, userID string, deviceID string, deliveryIDs ...string) (int, error)
+	// List 按入队顺序返回设备的待确认帧
+	List(ctx context.Context, userID string, deviceID string, limit int) ([]PendingFrame, error)
+	// Count 返回用户所有设备的待确认帧总数
+	Count(ctx context.Context, userID string) (int64, error)
+}
+
+const (
+	// defaultPendingLimit 单设备待确认队列上限，超出淘汰最旧的帧并计入 dropped
+	defaultPendingLimit = 1000
+	// defaultDeviceTTL 设备超过该时间未连接则不再为其入队
+	defaultDeviceTTL = 7 * 24 * time.Hour
+)
+
+type memoryPendingStore struct {
+	mu      sync.Mutex
+	devices map[string]map[string]time.Time      // userID -> deviceID -> lastSeen
+	queues  map[string]map[string][]PendingFrame // userID -> deviceID -> frames
+}
+
+// NewMemoryPendingStore 进程内待确认队列，仅适用于单节点部署
+func NewMemoryPendingStore() PendingStore {
+	return &memoryPendingStore{
+		devices: make(map[string]map[string]time.Time),
+		queues:  make(map[string]map[string][]PendingFrame),
+	}
+}
+
+func (s *memoryPendingStore) TouchDevice(_ context.Context, userID string, deviceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := s.devices[userID]
+	if set == nil {
+		set = make(map[string]time.Time)
+		s.devices[userID] = set
+	}
+	set[deviceID] = time.Now()
+	return nil
+}
+
+func (s *memoryPendingStore) Enqueue(_ context.Context, userID string, frame PendingFrame) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := 0
+	now := time.Now()
+	for deviceID, lastSeen := range s.devices[userID] {
+		if now.Sub(lastSeen) > defaultDeviceTTL {
+			delete(s.devices[userID], deviceID)
+			delete(s.queues[userID], deviceID)
+			continue
+		}
+		qs := s.queues[userID]
+		if qs == nil {
+			qs = make(map[string][]PendingFrame)
+			s.queues[userID] = qs
+		}
+		q := append(qs[deviceID], frame)
+		if over := len(q) - defaultPendingLimit; over > 0 {
+			q = q[over:]
+			dropped += over
+		}
+		qs[deviceID] = q
+	}
+	return dropped, nil
+}
+
+func (s *memoryPendingStore) Ack(_ context.Context, userID string, deviceID string, deliveryIDs ...string) (int, error) {
+	if len(deliveryIDs) == 0 {
+		return 0, nil
+	}
+	ids := make(map[string]struct{}, len(deliveryIDs))
+	for _, id := range deliveryIDs {
+		ids[id] = struct{}{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := s.queues[userID][deviceID]
+	kept := q[:0]
+	removed := 0
+	for _, f := range q {
+		if _, ok := ids[f.DeliveryId]; ok {
+			removed++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	if qs := s.queues[userID]; qs != nil {
+		qs[deviceID] = kept
+	}
+	return removed, nil
+}
+
+func (s *memoryPendingStore) List(_ context.Context, userID string, deviceID string, limit int) ([]PendingFrame, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := s.queues[userID][deviceID]
+	if limit > 0 && len(q) > limit {
+		q = q[:limit]
+	}
+	out := make([]PendingFrame, len(q))
+	copy(out, q)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].EnqueuedAt < out[j].EnqueuedAt })
+	return out, nil
+}
+
+func (s *memoryPendingStore) Count(_ context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, q := range s.queues[userID] {
+		n += int64(len(q))
+	}
+	return n, nil
+}
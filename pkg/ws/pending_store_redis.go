@@ -0,0 +1,162 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"OmniLink/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	pendingDevicesKeyPrefix = "omnilink:ws:devices:" // ZSet：设备ID -> 最近在线时间
+	pendingQueueKeyPrefix   = "omnilink:ws:pending:" // ZSet：deliveryID -> 入队时间
+	pendingFrameKeyPrefix   = "omnilink:ws:frames:"  // Hash：deliveryID -> 帧内容
+)
+
+type redisPendingStore struct{}
+
+// NewRedisPendingStore 基于 Redis 的待确认队列，多节点共享
+func NewRedisPendingStore() PendingStore {
+	return &redisPendingStore{}
+}
+
+func pendingQueueKey(userID string, deviceID string) string {
+	return pendingQueueKeyPrefix + userID + ":" + deviceID
+}
+
+func pendingFrameKey(userID string, deviceID string) string {
+	return pendingFrameKeyPrefix + userID + ":" + deviceID
+}
+
+func (s *redisPendingStore) TouchDevice(ctx context.Context, userID string, deviceID string) error {
+	key := pendingDevicesKeyPrefix + userID
+	if _, err := redis.ZAdd(ctx, key, goredis.Z{Score: float64(time.Now().Unix()), Member: deviceID}); err != nil {
+		return err
+	}
+	_, err := redis.Expire(ctx, key, defaultDeviceTTL)
+	return err
+}
+
+func (s *redisPendingStore) Enqueue(ctx context.Context, userID string, frame PendingFrame) (int, error) {
+	devicesKey := pendingDevicesKeyPrefix + userID
+	cutoff := time.Now().Add(-defaultDeviceTTL).Unix()
+	devices, err := redis.GetClient().ZRangeByScore(ctx, devicesKey, &goredis.ZRangeBy{
+		Min: strconv.FormatInt(cutoff, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(devices) == 0 {
+		return 0, nil
+	}
+
+	body, err := json.Marshal(frame)
+	if err != nil {
+		return 0, err
+	}
+
+	pipe := redis.Pipeline()
+	for _, deviceID := range devices {
+		qKey := pendingQueueKey(userID, deviceID)
+		fKey := pendingFrameKey(userID, deviceID)
+		pipe.ZAdd(ctx, qKey, goredis.Z{Score: float64(frame.EnqueuedAt), Member: frame.DeliveryId})
+		pipe.HSet(ctx, fKey, frame.DeliveryId, body)
+		pipe.Expire(ctx, qKey, defaultDeviceTTL)
+		pipe.Expire(ctx, fKey, defaultDeviceTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	// 超出上限的最旧帧淘汰
+	dropped := 0
+	for _, deviceID := range devices {
+		qKey := pendingQueueKey(userID, deviceID)
+		n, err := redis.ZCard(ctx, qKey)
+		if err != nil || n <= defaultPendingLimit {
+			continue
+		}
+		over := n - defaultPendingLimit
+		popped, err := redis.GetClient().ZPopMin(ctx, qKey, over).Result()
+		if err != nil {
+			continue
+		}
+		ids := make([]string, 0, len(popped))
+		for _, z := range popped {
+			if id, ok := z.Member.(string); ok {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			_, _ = redis.HDel(ctx, pendingFrameKey(userID, deviceID), ids...)
+		}
+		dropped += len(ids)
+	}
+	return dropped, nil
+}
+
+func (s *redisPendingStore) Ack(ctx context.Context, userID string, deviceID string, deliveryIDs ...string) (int, error) {
+	if len(deliveryIDs) == 0 {
+		return 0, nil
+	}
+	members := make([]interface{}, 0, len(deliveryIDs))
+	for _, id := range deliveryIDs {
+		members = append(members, id)
+	}
+	n, err := redis.ZRem(ctx, pendingQueueKey(userID, deviceID), members...)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = redis.HDel(ctx, pendingFrameKey(userID, deviceID), deliveryIDs...)
+	return int(n), nil
+}
+
+func (s *redisPendingStore) List(ctx context.Context, userID string, deviceID string, limit int) ([]PendingFrame, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit - 1)
+	}
+	ids, err := redis.ZRange(ctx, pendingQueueKey(userID, deviceID), 0, stop)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	vals, err := redis.GetClient().HMGet(ctx, pendingFrameKey(userID, deviceID), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]PendingFrame, 0, len(vals))
+	for _, v := range vals {
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var f PendingFrame
+		if err := json.Unmarshal([]byte(str), &f); err != nil {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func (s *redisPendingStore) Count(ctx context.Context, userID string) (int64, error) {
+	devices, err := redis.ZRange(ctx, pendingDevicesKeyPrefix+userID, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, deviceID := range devices {
+		n, err := redis.ZCard(ctx, pendingQueueKey(userID, deviceID))
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
@@ -0,0 +1,169 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 在线状态
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+const (
+	// PresenceRefreshInterval 节点应按该间隔重写本地连接的状态，超过 presenceStaleAfter 未刷新的连接视为已断开
+	PresenceRefreshInterval = 2 * time.Minute
+	presenceStaleAfter      = 3 * PresenceRefreshInterval
+	// presenceWatchTTL 订阅关系的最长保留时间，订阅方每次订阅都会续期
+	presenceWatchTTL = 24 * time.Hour
+)
+
+// PresenceStore 按 (用户, 连接) 记录在线状态，并维护“谁订阅了谁”的关系。
+// 单机可用内存实现；多节点部署需使用 Redis 实现，保证任意节点上的状态变化都能推给订阅方
+type PresenceStore interface {
+	// SetState 记录连接的状态（online / away），同时刷新存活时间
+	SetState(ctx context.Context, userID string, connID string, state string) error
+	// RemoveConn 连接断开
+	RemoveConn(ctx context.Context, userID string, connID string) error
+	// States 返回用户聚合后的状态：任一连接 online 即 online，否则有连接即 away，没有连接为 offline
+	States(ctx context.Context, userIDs []string) (map[string]string, error)
+	// Watch 记录 watcherID 订阅 targetIDs 的状态变化
+	Watch(ctx context.Context, watcherID string, targetIDs []string) error
+	// Unwatch 取消订阅，targetIDs 为空表示取消全部
+	Unwatch(ctx context.Context, watcherID string, targetIDs []string) error
+	// Watchers 返回订阅了 targetID 的用户
+	Watchers(ctx context.Context, targetID string) ([]string, error)
+}
+
+// AggregatePresence 多端状态聚合，优先级 online > away > offline
+func AggregatePresence(states ...string) string {
+	out := PresenceOffline
+	for _, st := range states {
+		switch st {
+		case PresenceOnline:
+			return PresenceOnline
+		case PresenceAway:
+			out = PresenceAway
+		}
+	}
+	return out
+}
+
+type presenceConn struct {
+	state     string
+	updatedAt time.Time
+}
+
+type memoryPresenceStore struct {
+	mu       sync.Mutex
+	conns    map[string]map[string]presenceConn // userID -> connID -> 状态
+	watchers map[string]map[string]struct{}     // targetID -> watcherID
+	watching map[string]map[string]struct{}     // watcherID -> targetID
+}
+
+// NewMemoryPresenceStore 进程内在线状态，仅适用于单节点部署
+func NewMemoryPresenceStore() PresenceStore {
+	return &memoryPresenceStore{
+		conns:    make(map[string]map[string]presenceConn),
+		watchers: make(map[string]map[string]struct{}),
+		watching: make(map[string]map[string]struct{}),
+	}
+}
+
+func (s *memoryPresenceStore) SetState(_ context.Context, userID string, connID string, state string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := s.conns[userID]
+	if set == nil {
+		set = make(map[string]presenceConn)
+		s.conns[userID] = set
+	}
+	set[connID] = presenceConn{state: state, updatedAt: time.Now()}
+	return nil
+}
+
+func (s *memoryPresenceStore) RemoveConn(_ context.Context, userID string, connID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if set := s.conns[userID]; set != nil {
+		delete(set, connID)
+		if len(set) == 0 {
+			delete(s.conns, userID)
+		}
+	}
+	return nil
+}
+
+func (s *memoryPresenceStore) States(_ context.Context, userIDs []string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cutoff := time.Now().Add(-presenceStaleAfter)
+	out := make(map[string]string, len(userIDs))
+	for _, uid := range userIDs {
+		states := make([]string, 0, len(s.conns[uid]))
+		for _, c := range s.conns[uid] {
+			if c.updatedAt.After(cutoff) {
+				states = append(states, c.state)
+			}
+		}
+		out[uid] = AggregatePresence(states...)
+	}
+	return out, nil
+}
+
+func (s *memoryPresenceStore) Watch(_ context.Context, watcherID string, targetIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mine := s.watching[watcherID]
+	if mine == nil {
+		mine = make(map[string]struct{})
+		s.watching[watcherID] = mine
+	}
+	for _, tid := range targetIDs {
+		set := s.watchers[tid]
+		if set == nil {
+			set = make(map[string]struct{})
+			s.watchers[tid] = set
+		}
+		set[watcherID] = struct{}{}
+		mine[tid] = struct{}{}
+	}
+	return nil
+}
+
+func (s *memoryPresenceStore) Unwatch(_ context.Context, watcherID string, targetIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mine := s.watching[watcherID]
+	if len(targetIDs) == 0 {
+		for tid := range mine {
+			targetIDs = append(targetIDs, tid)
+		}
+	}
+	for _, tid := range targetIDs {
+		if set := s.watchers[tid]; set != nil {
+			delete(set, watcherID)
+			if len(set) == 0 {
+				delete(s.watchers, tid)
+			}
+		}
+		delete(mine, tid)
+	}
+	if len(mine) == 0 {
+		delete(s.watching, watcherID)
+	}
+	return nil
+}
+
+func (s *memoryPresenceStore) Watchers(_ context.Context, targetID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, 0, len(s.watchers[targetID]))
+	for uid := range s.watchers[targetID] {
+		out = append(out, uid)
+	}
+	return out, nil
+}
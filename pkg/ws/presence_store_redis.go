@@ -0,0 +1,124 @@
+package ws
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"OmniLink/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	presenceStateKeyPrefix    = "omnilink:ws:status:"   // Hash：连接ID -> "状态|刷新时间"
+	presenceWatchersKeyPrefix = "omnilink:ws:watchers:" // Set：订阅了该用户状态的用户
+	presenceWatchingKeyPrefix = "omnilink:ws:watching:" // Set：该用户订阅的目标，用于整体取消
+)
+
+type redisPresenceStore struct{}
+
+// NewRedisPresenceStore 基于 Redis 的在线状态，多节点共享
+func NewRedisPresenceStore() PresenceStore {
+	return &redisPresenceStore{}
+}
+
+func (s *redisPresenceStore) SetState(ctx context.Context, userID string, connID string, state string) error {
+	key := presenceStateKeyPrefix + userID
+	if _, err := redis.HSet(ctx, key, connID, state+"|"+strconv.FormatInt(time.Now().Unix(), 10)); err != nil {
+		return err
+	}
+	_, err := redis.Expire(ctx, key, presenceStaleAfter)
+	return err
+}
+
+func (s *redisPresenceStore) RemoveConn(ctx context.Context, userID string, connID string) error {
+	_, err := redis.HDel(ctx, presenceStateKeyPrefix+userID, connID)
+	return err
+}
+
+func (s *redisPresenceStore) States(ctx context.Context, userIDs []string) (map[string]string, error) {
+	out := make(map[string]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+	pipe := redis.Pipeline()
+	cmds := make([]*goredis.MapStringStringCmd, len(userIDs))
+	for i, uid := range userIDs {
+		cmds[i] = pipe.HGetAll(ctx, presenceStateKeyPrefix+uid)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// 宕机节点残留的连接不会再刷新，读取时按刷新时间过滤并顺手清理
+	cutoff := time.Now().Add(-presenceStaleAfter).Unix()
+	for i, uid := range userIDs {
+		fields, _ := cmds[i].Result()
+		states := make([]string, 0, len(fields))
+		var stale []string
+		for connID, v := range fields {
+			state, ts, _ := strings.Cut(v, "|")
+			at, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil || at < cutoff {
+				stale = append(stale, connID)
+				continue
+			}
+			states = append(states, state)
+		}
+		if len(stale) > 0 {
+			_, _ = redis.HDel(ctx, presenceStateKeyPrefix+uid, stale...)
+		}
+		out[uid] = AggregatePresence(states...)
+	}
+	return out, nil
+}
+
+func (s *redisPresenceStore) Watch(ctx context.Context, watcherID string, targetIDs []string) error {
+	if len(targetIDs) == 0 {
+		return nil
+	}
+	pipe := redis.Pipeline()
+	members := make([]interface{}, 0, len(targetIDs))
+	for _, tid := range targetIDs {
+		key := presenceWatchersKeyPrefix + tid
+		pipe.SAdd(ctx, key, watcherID)
+		pipe.Expire(ctx, key, presenceWatchTTL)
+		members = append(members, tid)
+	}
+	mine := presenceWatchingKeyPrefix + watcherID
+	pipe.SAdd(ctx, mine, members...)
+	pipe.Expire(ctx, mine, presenceWatchTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *redisPresenceStore) Unwatch(ctx context.Context, watcherID string, targetIDs []string) error {
+	mine := presenceWatchingKeyPrefix + watcherID
+	all := len(targetIDs) == 0
+	if all {
+		ids, err := redis.SMembers(ctx, mine)
+		if err != nil {
+			return err
+		}
+		targetIDs = ids
+	}
+	pipe := redis.Pipeline()
+	members := make([]interface{}, 0, len(targetIDs))
+	for _, tid := range targetIDs {
+		pipe.SRem(ctx, presenceWatchersKeyPrefix+tid, watcherID)
+		members = append(members, tid)
+	}
+	if all {
+		pipe.Del(ctx, mine)
+	} else if len(members) > 0 {
+		pipe.SRem(ctx, mine, members...)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *redisPresenceStore) Watchers(ctx context.Context, targetID string) ([]string, error) {
+	return redis.SMembers(ctx, presenceWatchersKeyPrefix+targetID)
+}
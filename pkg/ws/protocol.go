@@ -0,0 +1,125 @@
+package ws
+
+import (
+	"encoding/json"
+)
+
+// ProtocolVersion 当前 WS 协议版本，随 Envelope.V 下发
+const ProtocolVersion = 1
+
+// 帧类型
+const (
+	FrameSend         = "send"         // 客户端 -> 服务端：发送消息
+	FrameAck          = "ack"          // 服务端 -> 客户端：发送成功回执，携带落库后的消息 uuid；客户端 -> 服务端：确认收到可靠帧
+	FrameError        = "error"        // 服务端 -> 客户端：处理失败
+	FrameMessage      = "message"      // 服务端 -> 客户端：新消息
+	FrameRecall       = "recall"       // 双向：撤回请求 / 撤回事件
+	FrameEdit         = "edit"         // 双向：编辑请求 / 编辑事件
+	FrameReaction     = "reaction"     // 双向：表情回应请求（payload.action 为 add / remove）/ 回应事件
+	FrameTyping       = "typing"       // 双向：正在输入，payload.state 为 start / stop
+	FramePresence     = "presence"     // 双向：在线状态上报与订阅（payload.action 为 set / subscribe / unsubscribe）/ 订阅对象的状态变化
+	FrameRead         = "read"         // 双向：标记已读 / 已读回执
+	FrameSync         = "sync"         // 双向：增量同步请求 / 同步批次
+	FrameNotification = "notification" // 服务端 -> 客户端：系统通知（好友申请、AI 推送等）
+	FrameCall         = "call"         // 双向：音视频通话信令，payload.action 区分具体动作
+	FrameAuth         = "auth"         // 双向：客户端刷新令牌后上报新令牌为连接续期 / 续期结果
+)
+
+// 连接相关的通知种类
+const (
+	NotificationDeviceKicked = "device.kicked" // 设备被踢下线，下发后连接随即断开
+	NotificationAuthExpiring = "auth.expiring" // 连接所用令牌即将过期，客户端应刷新令牌后发送 auth 帧续期
+	NotificationAuthExpired  = "auth.expired"  // 令牌已过期且未续期，下发后连接随即断开
+)
+
+// 好友关系相关的通知种类，仅在关系实际发生变化时下发给对方
+const (
+	NotificationContactDeleted   = "contact.deleted"   // 被对方删除，客户端应刷新好友列表与会话
+	NotificationContactBlocked   = "contact.blocked"   // 被对方拉黑
+	NotificationContactUnblocked = "contact.unblocked" // 对方取消拉黑
+)
+
+// Envelope WS 帧统一外层结构
+// - Type 区分帧类型
+// - ClientMsgId 由客户端生成，服务端在 ack/error 中原样带回，用于请求响应关联与发送去重
+// - DeliveryId 仅可靠帧携带，客户端收到后需回 ack 确认，否则会被重发
+// - Payload 为具体帧内容
+type Envelope struct {
+	V           int             `json:"v"`
+	Type        string          `json:"type"`
+	ClientMsgId string          `json:"client_msg_id,omitempty"`
+	DeliveryId  string          `json:"delivery_id,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+}
+
+// AckPayload 发送回执
+type AckPayload struct {
+	MessageId string `json:"message_id"`
+	ConvId    string `json:"conv_id,omitempty"`
+	Seq       int64  `json:"seq,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// DeliveryAckPayload 客户端确认收到的可靠帧
+type DeliveryAckPayload struct {
+	DeliveryIds []string `json:"delivery_ids"`
+}
+
+// ErrorPayload 错误帧内容
+type ErrorPayload struct {
+	Code       int    `json:"code,omitempty"`
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retry_after,omitempty"` // 被限流时建议等待的秒数
+}
+
+// NotificationPayload 通知帧内容，Kind 区分通知种类，Data 为具体数据
+type NotificationPayload struct {
+	Kind string      `json:"kind"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// NewEnvelope 构造一个服务端下发的帧
+func NewEnvelope(frameType string, clientMsgID string, payload interface{}) (*Envelope, error) {
+	env := &Envelope{
+		V:           ProtocolVersion,
+		Type:        frameType,
+		ClientMsgId: clientMsgID,
+	}
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		env.Payload = b
+	}
+	return env, nil
+}
+
+// SendFrame 以 Envelope 格式向用户推送一帧；可靠帧会进入待确认队列，直到客户端 ack
+func (h *Hub) SendFrame(userID string, frameType string, clientMsgID string, payload interface{}) error {
+	env, err := NewEnvelope(frameType, clientMsgID, payload)
+	if err != nil {
+		return err
+	}
+	if !isReliable(frameType) {
+		return h.SendJSON(userID, env)
+	}
+
+	env.DeliveryId = newDeliveryID()
+	b, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	h.sendReliable(userID, env.DeliveryId, b)
+	return nil
+}
+
+// SendError 向用户推送错误帧
+func (h *Hub) SendError(userID string, clientMsgID string, code int, message string) error {
+	return h.SendFrame(userID, FrameError, clientMsgID, ErrorPayload{Code: code, Message: message})
+}
+
+// SendNotification 向用户推送通知帧
+func (h *Hub) SendNotification(userID string, kind string, data interface{}) error {
+	return h.SendFrame(userID, FrameNotification, "", NotificationPayload{Kind: kind, Data: data})
+}
@@ -25,6 +25,7 @@ const (
 	Unauthorized        = 401
 	Forbidden           = 403
 	NotFound            = 404
+	TooManyRequests     = 429
 	InternalServerError = 500
 )
 
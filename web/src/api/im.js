@@ -117,6 +117,16 @@ export const removeGroupMembers = (data) => {
     return request.post('/group/removeGroupMembers', data)
 }
 
+export const passGroupApply = (data) => {
+    // data: { apply_id }
+    return request.post('/group/passGroupApply', data)
+}
+
+export const refuseGroupApply = (data) => {
+    // data: { apply_id }
+    return request.post('/group/refuseGroupApply', data)
+}
+
 // 消息相关
 export const getMessageList = (data) => {
   // data: { user_one_id, user_two_id }